@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"mylab-api-go/internal/config"
+	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/schema"
+)
+
+// runLint mengecek semua file di SCHEMA_DIR terhadap database (kolom, PK, tenant, cast, relasi).
+// Exit code 1 kalau ada masalah, supaya bisa dipakai di CI sebelum deploy.
+func runLint(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print issues as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: mylab-api-go lint [flags]\n\nchecks every schema file in SCHEMA_DIR against the database\n\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if cfg.DatabaseURL == "" {
+		fmt.Fprintln(os.Stderr, "lint: DATABASE_URL is required")
+		return 1
+	}
+
+	conn, err := db.Open(cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint: database error: %v\n", err)
+		return 1
+	}
+	defer func() { _ = conn.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	issues, err := schema.Lint(ctx, conn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(issues)
+	} else {
+		for _, is := range issues {
+			where := is.Table
+			if is.Path != "" {
+				where = is.Path
+			}
+			fmt.Printf("%s: %s: %s\n", where, is.Field, is.Message)
+		}
+		fmt.Printf("lint: %d issue(s) in %s\n", len(issues), schema.SchemaDir())
+	}
+	if len(issues) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -11,11 +11,18 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // OUTPUT_TIMEZONE di image alpine (tanpa paket tzdata)
 
+	"mylab-api-go/internal/audit"
 	"mylab-api-go/internal/config"
+	"mylab-api-go/internal/database/dialect"
+	"mylab-api-go/internal/database/eloquent"
 	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/export"
 	"mylab-api-go/internal/routes"
 	routesauth "mylab-api-go/internal/routes/auth"
+	"mylab-api-go/internal/schema"
+	"mylab-api-go/internal/webhook"
 )
 
 func main() {
@@ -24,6 +31,51 @@ func main() {
 		log.Fatalf("config error: %v", err)
 	}
 
+	// Subcommand admin (contoh: `mylab-api-go rls apply`, `mylab-api-go lint`).
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "rls":
+			os.Exit(runRLS(cfg, os.Args[2:]))
+		case "lint":
+			os.Exit(runLint(cfg, os.Args[2:]))
+		}
+	}
+
+	// File schema yang rusak tidak lagi fallback diam-diam ke introspeksi: tabelnya menolak request
+	// (500 schema_invalid). Laporkan di startup; status lengkap ada di /healthz/schemas.
+	if statuses, err := schema.CheckDir(); err != nil {
+		log.Printf("schema dir error: %v", err)
+	} else {
+		for _, st := range statuses {
+			if !st.OK {
+				log.Printf("schema file invalid: %s: %s", st.Path, strings.Join(st.Problems, "; "))
+			}
+		}
+	}
+
+	// Rendering DB values di response (decimal, zona waktu datetime).
+	outputOpts := eloquent.OutputOptions{Decimals: strings.ToLower(strings.TrimSpace(cfg.OutputDecimals))}
+	if cfg.OutputTimezone != "" {
+		loc, err := time.LoadLocation(cfg.OutputTimezone)
+		if err != nil {
+			log.Fatalf("OUTPUT_TIMEZONE error: %v", err)
+		}
+		outputOpts.Location = loc
+	}
+	eloquent.SetOutputOptions(outputOpts)
+
+	// Export CSV/XLSX memakai zona tenant (jika diatur) untuk datetime.
+	zones, err := export.ParseTenantTimezones(cfg.TenantTimezones)
+	if err != nil {
+		log.Fatalf("TENANT_TIMEZONES error: %v", err)
+	}
+	export.SetTenantTimezones(zones)
+
+	// Counter generate= (kode pasien, no_lab, ...) disimpan di tabel milik gateway.
+	if err := eloquent.SetSequenceTable(cfg.SequenceTable); err != nil {
+		log.Fatalf("SEQUENCE_TABLE error: %v", err)
+	}
+
 	// Database optional untuk startup, tapi dibutuhkan untuk endpoint yang akses DB.
 	var dbConn *sql.DB
 	if cfg.DatabaseURL != "" {
@@ -33,6 +85,36 @@ func main() {
 		}
 		dbConn = opened
 		defer func() { _ = dbConn.Close() }()
+
+		// DDL di luar transaksi request (MySQL commit implisit). Gagal = generate= error saat insert.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := eloquent.EnsureSequenceTable(ctx, dbConn); err != nil {
+			log.Printf("sequence table error: %v", err)
+		}
+		cancel()
+	}
+
+	// Row-level security: setiap transaksi request menyet app.company_id/app.user_id (SET LOCAL),
+	// sehingga policy dari `mylab-api-go rls apply` ikut memfilter tenant.
+	if strings.EqualFold(strings.TrimSpace(cfg.DBRowLevelSecurity), "on") {
+		if dbConn == nil || !dialect.IsPostgres() {
+			log.Fatalf("DB_RLS=on requires a PostgreSQL DATABASE_URL")
+		}
+		db.SetTxScope(func(ctx context.Context) (int64, int64, bool) {
+			info, ok := routesauth.AuthInfoFromContext(ctx)
+			return info.CompanyID, info.UserID, ok
+		})
+	}
+
+	// Audit trail dan webhooks hanya ada di PostgreSQL (jsonb, SKIP LOCKED). Di database lain
+	// harus dimatikan eksplisit, supaya tidak ada yang mengira perubahan tercatat/terkirim.
+	if dbConn != nil && !dialect.IsPostgres() {
+		if audit.Enabled() {
+			log.Fatalf("%v; set AUDIT_TRAIL=off to run without it", audit.ErrUnsupportedDialect)
+		}
+		if webhook.Enabled() {
+			log.Fatalf("%v; set WEBHOOKS=off to run without them", webhook.ErrUnsupportedDialect)
+		}
 	}
 
 	// Laravel-like auth session store (server-side state for JWT sessions).
@@ -60,6 +142,34 @@ func main() {
 		log.Fatalf("auth session store driver not supported: %q", cfg.AuthSessionDriver)
 	}
 
+	// Webhook outbox dispatcher (background). Safe to run on several instances.
+	dispatchCtx, stopDispatcher := context.WithCancel(context.Background())
+	defer stopDispatcher()
+	if dbConn != nil {
+		switch strings.ToLower(strings.TrimSpace(cfg.WebhookDispatcher)) {
+		case "off", "false", "0", "disabled":
+		default:
+			store, err := webhook.NewStore(dbConn)
+			if errors.Is(err, webhook.ErrDisabled) {
+				break
+			}
+			if err != nil {
+				log.Fatalf("webhook store error: %v", err)
+			}
+			d := webhook.NewDispatcher(store, time.Duration(cfg.WebhookTimeout)*time.Second)
+			if err := d.AllowNetworks(cfg.WebhookAllowedNetworks); err != nil {
+				log.Fatalf("WEBHOOK_ALLOWED_NETWORKS: %v", err)
+			}
+			if cfg.WebhookMaxAttempts > 0 {
+				d.MaxAttempts = int(cfg.WebhookMaxAttempts)
+			}
+			if cfg.WebhookPollInterval > 0 {
+				d.PollInterval = time.Duration(cfg.WebhookPollInterval) * time.Second
+			}
+			go d.Run(dispatchCtx)
+		}
+	}
+
 	srv := routes.New(cfg.HTTPAddr, cfg.LogLevel, dbConn)
 
 	errCh := make(chan error, 1)
@@ -80,6 +190,7 @@ func main() {
 		}
 	}
 
+	stopDispatcher()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	_ = srv.Shutdown(ctx)
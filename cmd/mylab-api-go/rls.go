@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"mylab-api-go/internal/audit"
+	"mylab-api-go/internal/config"
+	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/history"
+	"mylab-api-go/internal/idempotency"
+	"mylab-api-go/internal/rls"
+	"mylab-api-go/internal/webhook"
+)
+
+const rlsUsage = `usage: mylab-api-go rls [print|apply|disable] [flags]
+
+  print    print the policy DDL for every tenant table (default)
+  apply    run the policy DDL in one transaction
+  disable  drop the policies and turn RLS off on tenant tables
+
+flags:
+`
+
+// runRLS membuat (dan opsional menerapkan) policy row-level security untuk tabel tenant.
+// Dipakai bersama DB_RLS=on supaya bug di querydsl/eloquent tidak bisa membocorkan data antar company.
+func runRLS(cfg config.Config, args []string) int {
+	action := "print"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("rls", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "hide every row when app.company_id is unset (outside request transactions)")
+	exclude := fs.String("exclude", "", "comma-separated tables to skip (e.g. users)")
+	dbSchema := fs.String("schema", "", "Postgres schema (default DB_SCHEMA or public)")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), rlsUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if action != "print" && action != "apply" && action != "disable" {
+		fs.Usage()
+		return 2
+	}
+	if cfg.DatabaseURL == "" {
+		fmt.Fprintln(os.Stderr, "rls: DATABASE_URL is required")
+		return 1
+	}
+
+	conn, err := db.Open(cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rls: database error: %v\n", err)
+		return 1
+	}
+	defer func() { _ = conn.Close() }()
+
+	// Tabel milik gateway (audit, webhook, session, sequence, history, idempotency) diakses di luar request dan tidak diberi policy.
+	opts := rls.Options{Schema: *dbSchema, Strict: *strict, Exclude: map[string]bool{}}
+	auditTable := strings.TrimSpace(os.Getenv("AUDIT_TABLE"))
+	if auditTable == "" {
+		auditTable = audit.DefaultTable
+	}
+	opts.Exclude[auditTable] = true
+	opts.Exclude[cfg.AuthSessionTable] = true
+	opts.Exclude[strings.ToLower(cfg.SequenceTable)] = true
+	opts.Exclude[history.Table] = true
+	opts.Exclude[idempotency.Table] = true
+	for _, t := range webhook.Tables() {
+		opts.Exclude[t] = true
+	}
+	for _, t := range strings.Split(*exclude, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			opts.Exclude[t] = true
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	tables, err := rls.TenantTables(ctx, conn, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rls: %v\n", err)
+		return 1
+	}
+	var stmts []string
+	if action == "disable" {
+		stmts, err = rls.DisableStatements(tables, opts)
+	} else {
+		stmts, err = rls.EnableStatements(tables, opts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rls: %v\n", err)
+		return 1
+	}
+
+	if action == "print" {
+		for _, stmt := range stmts {
+			fmt.Println(stmt + ";")
+		}
+		return 0
+	}
+	if err := rls.Apply(ctx, conn, stmts); err != nil {
+		fmt.Fprintf(os.Stderr, "rls: %v\n", err)
+		return 1
+	}
+	fmt.Printf("rls: %s on %d tables\n", action, len(tables))
+	return 0
+}
@@ -18,4 +18,11 @@ case "$(echo "$driver" | tr '[:upper:]' '[:lower:]')" in
     ;;
 esac
 
+# Same for CRUD file attachments on local disk (FILE_STORAGE=local).
+if [ "$(echo "${FILE_STORAGE:-local}" | tr '[:upper:]' '[:lower:]')" = "local" ]; then
+  uploads="${FILE_STORAGE_DIR:-/app/storage/files}"
+  mkdir -p "$uploads"
+  chown -R app:app "$uploads" 2>/dev/null || true
+fi
+
 exec su-exec app:app /usr/local/bin/mylab-api-go
@@ -0,0 +1,370 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"mylab-api-go/internal/database/dialect"
+	"mylab-api-go/internal/database/eloquent"
+)
+
+// Audit trail for generic CRUD mutations.
+//
+// Entries are written with the caller's transaction, so a mutation and its audit entry
+// commit (or roll back) together. The table is gateway-owned and created on first use.
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+const DefaultTable = "gateway_audit_log"
+
+var tableNameRE = regexp.MustCompile("^[a-z0-9_]+$")
+
+// ErrUnsupportedDialect is returned by NewRecorder on non-Postgres databases (jsonb storage).
+var ErrUnsupportedDialect = errors.New("audit trail requires postgres")
+
+// ErrDisabled is returned by NewRecorder when AUDIT_TRAIL=off.
+var ErrDisabled = errors.New("audit trail disabled (AUDIT_TRAIL=off)")
+
+// Enabled reports whether the audit trail is switched on (AUDIT_TRAIL, default on). The
+// server refuses to start on a database without audit support while it is on.
+func Enabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("AUDIT_TRAIL"))) {
+	case "off", "false", "0", "disabled":
+		return false
+	}
+	return true
+}
+
+// Entry is one audited mutation. Old is nil for create, New is nil for delete.
+type Entry struct {
+	Table     string
+	PK        string
+	Action    string
+	CompanyID int64
+	UserID    int64
+	Role      string
+	RequestID string
+	ClientIP  string
+	Old       map[string]any
+	New       map[string]any
+}
+
+// Change is the field-level diff value.
+type Change struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// Recorder writes and reads audit entries.
+type Recorder struct {
+	db    *sql.DB
+	table string
+
+	mu    sync.Mutex
+	ready bool
+}
+
+func NewRecorder(db *sql.DB, table string) (*Recorder, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	if !Enabled() {
+		return nil, ErrDisabled
+	}
+	if !dialect.IsPostgres() {
+		return nil, ErrUnsupportedDialect
+	}
+	table = strings.ToLower(strings.TrimSpace(table))
+	if table == "" {
+		table = DefaultTable
+	}
+	if !tableNameRE.MatchString(table) {
+		return nil, fmt.Errorf("invalid audit table name: %q", table)
+	}
+	return &Recorder{db: db, table: table}, nil
+}
+
+func (r *Recorder) Table() string { return r.table }
+
+// ensureTable creates the audit table once per process (best-effort auto-migration,
+// same approach as the postgres session store). It runs outside the caller's transaction
+// so a rolled-back mutation does not roll back the DDL.
+func (r *Recorder) ensureTable(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ready {
+		return nil
+	}
+
+	stmts := []string{
+		fmt.Sprintf(`
+create table if not exists %s (
+  id bigserial primary key,
+  created_at timestamptz not null default now(),
+  table_name text not null,
+  pk text not null,
+  action text not null,
+  company_id bigint not null,
+  user_id bigint not null default 0,
+  role text not null default '',
+  request_id text not null default '',
+  client_ip text not null default '',
+  old_row jsonb null,
+  new_row jsonb null,
+  diff jsonb null
+)
+`, r.table),
+		fmt.Sprintf(`create index if not exists %s_company_table_pk_idx on %s (company_id, table_name, pk)`, r.table, r.table),
+		fmt.Sprintf(`create index if not exists %s_company_created_idx on %s (company_id, created_at)`, r.table, r.table),
+	}
+	for _, stmt := range stmts {
+		if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	r.ready = true
+	return nil
+}
+
+// Record writes e using q (normally the mutation's *sql.Tx).
+func (r *Recorder) Record(ctx context.Context, q eloquent.Querier, e Entry) error {
+	if strings.TrimSpace(e.Table) == "" || strings.TrimSpace(e.PK) == "" {
+		return fmt.Errorf("audit: table and pk are required")
+	}
+	switch e.Action {
+	case ActionCreate, ActionUpdate, ActionDelete:
+	default:
+		return fmt.Errorf("audit: invalid action %q", e.Action)
+	}
+	if err := r.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	oldRow := normalizeRow(e.Old)
+	newRow := normalizeRow(e.New)
+	oldJSON, err := marshalNullable(oldRow)
+	if err != nil {
+		return err
+	}
+	newJSON, err := marshalNullable(newRow)
+	if err != nil {
+		return err
+	}
+	diffJSON, err := marshalNullable(Diff(oldRow, newRow))
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+insert into %s (table_name, pk, action, company_id, user_id, role, request_id, client_ip, old_row, new_row, diff)
+values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+`, r.table)
+	_, err = q.ExecContext(ctx, query,
+		e.Table, e.PK, e.Action, e.CompanyID, e.UserID,
+		strings.TrimSpace(e.Role), e.RequestID, e.ClientIP,
+		oldJSON, newJSON, diffJSON,
+	)
+	return err
+}
+
+// Diff returns the changed fields between two rows. Keys present on one side only are
+// reported with nil on the other side. Returns nil when nothing changed.
+func Diff(oldRow, newRow map[string]any) map[string]Change {
+	keys := map[string]bool{}
+	for k := range oldRow {
+		keys[k] = true
+	}
+	for k := range newRow {
+		keys[k] = true
+	}
+
+	out := map[string]Change{}
+	for k := range keys {
+		ov, oldOK := oldRow[k]
+		nv, newOK := newRow[k]
+		if oldOK && newOK && sameValue(ov, nv) {
+			continue
+		}
+		out[k] = Change{Old: ov, New: nv}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func sameValue(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	// Compare by JSON encoding so driver types (int32 vs int64, time zones) don't show up as changes.
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ab) == string(bb)
+}
+
+// normalizeRow converts driver values into JSON-friendly ones ([]byte → string, time → UTC).
+func normalizeRow(row map[string]any) map[string]any {
+	if row == nil {
+		return nil
+	}
+	out := make(map[string]any, len(row))
+	for k, v := range row {
+		switch t := v.(type) {
+		case []byte:
+			out[k] = string(t)
+		case time.Time:
+			out[k] = t.UTC()
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func marshalNullable(v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || (rv.Kind() == reflect.Map && rv.IsNil()) {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Filter narrows an audit query. CompanyID is mandatory (tenant scope).
+type Filter struct {
+	CompanyID int64
+	Table     string
+	PK        string
+	UserID    int64
+	From      *time.Time
+	To        *time.Time
+	Page      int
+	PerPage   int
+}
+
+// Record is one row as returned by List.
+type Record struct {
+	ID        int64           `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Table     string          `json:"table"`
+	PK        string          `json:"pk"`
+	Action    string          `json:"action"`
+	CompanyID int64           `json:"company_id"`
+	UserID    int64           `json:"user_id"`
+	Role      string          `json:"role"`
+	RequestID string          `json:"request_id"`
+	ClientIP  string          `json:"client_ip"`
+	Old       json.RawMessage `json:"old"`
+	New       json.RawMessage `json:"new"`
+	Diff      json.RawMessage `json:"diff"`
+}
+
+// ListResult is a page of audit records, newest first.
+type ListResult struct {
+	Rows    []Record
+	Page    int
+	PerPage int
+	HasMore bool
+}
+
+// List returns audit records for one tenant.
+func (r *Recorder) List(ctx context.Context, q eloquent.Querier, f Filter) (*ListResult, error) {
+	if f.CompanyID <= 0 {
+		return nil, &eloquent.ValidationError{Errors: map[string]string{"company_id": "invalid"}}
+	}
+	if err := r.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	page := f.Page
+	if page <= 0 {
+		page = 1
+	}
+	perPage := f.PerPage
+	if perPage <= 0 {
+		perPage = eloquent.DefaultPerPage
+	}
+	if perPage > eloquent.MaxPerPage {
+		perPage = eloquent.MaxPerPage
+	}
+
+	args := []any{f.CompanyID}
+	where := []string{"company_id = $1"}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Table != "" {
+		add("table_name = $%d", f.Table)
+	}
+	if f.PK != "" {
+		add("pk = $%d", f.PK)
+	}
+	if f.UserID > 0 {
+		add("user_id = $%d", f.UserID)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at <= $%d", *f.To)
+	}
+
+	query := fmt.Sprintf(`
+select id, created_at, table_name, pk, action, company_id, user_id, role, request_id, client_ip,
+  coalesce(old_row::text, 'null'), coalesce(new_row::text, 'null'), coalesce(diff::text, 'null')
+from %s
+where %s
+order by created_at desc, id desc
+limit %d offset %d
+`, r.table, strings.Join(where, " and "), perPage+1, (page-1)*perPage)
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Record{}
+	for rows.Next() {
+		var rec Record
+		var oldRaw, newRaw, diffRaw string
+		if err := rows.Scan(
+			&rec.ID, &rec.CreatedAt, &rec.Table, &rec.PK, &rec.Action, &rec.CompanyID,
+			&rec.UserID, &rec.Role, &rec.RequestID, &rec.ClientIP,
+			&oldRaw, &newRaw, &diffRaw,
+		); err != nil {
+			return nil, err
+		}
+		rec.Old = json.RawMessage(oldRaw)
+		rec.New = json.RawMessage(newRaw)
+		rec.Diff = json.RawMessage(diffRaw)
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	hasMore := len(out) > perPage
+	if hasMore {
+		out = out[:perPage]
+	}
+	return &ListResult{Rows: out, Page: page, PerPage: perPage, HasMore: hasMore}, nil
+}
@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	oldRow := normalizeRow(map[string]any{"kd_ps": "P1", "nama_ps": []byte("Budi"), "umur": int32(30), "updated_at": ts})
+	newRow := normalizeRow(map[string]any{"kd_ps": "P1", "nama_ps": "Budi S", "umur": int64(30), "updated_at": ts.In(time.FixedZone("WIB", 7*3600))})
+
+	d := Diff(oldRow, newRow)
+	if len(d) != 1 {
+		t.Fatalf("expected 1 changed field, got %#v", d)
+	}
+	if c := d["nama_ps"]; c.Old != "Budi" || c.New != "Budi S" {
+		t.Fatalf("unexpected nama_ps change: %#v", c)
+	}
+
+	// Create: every field is reported against nil.
+	d = Diff(nil, map[string]any{"kd_ps": "P1"})
+	if c, ok := d["kd_ps"]; !ok || c.Old != nil || c.New != "P1" {
+		t.Fatalf("unexpected create diff: %#v", d)
+	}
+
+	if d := Diff(oldRow, oldRow); d != nil {
+		t.Fatalf("expected no diff, got %#v", d)
+	}
+}
@@ -22,6 +22,27 @@ type Config struct {
 	AuthSessionDriver string
 	AuthSessionFiles  string
 	AuthSessionTable  string
+
+	// Outbound webhooks (transactional outbox dispatcher).
+	WebhookDispatcher   string // on|off
+	WebhookMaxAttempts  int64
+	WebhookPollInterval int64 // dalam detik
+	WebhookTimeout      int64 // dalam detik
+	// Jaringan privat (CIDR, dipisah koma) yang boleh dituju webhook, mis. plugin internal.
+	WebhookAllowedNetworks string
+
+	// Response rendering of DB values (see eloquent.OutputOptions).
+	OutputDecimals string // string|number
+	OutputTimezone string // IANA zone, kosong = zona server (TZ)
+
+	// Zona waktu export per tenant: "12:Asia/Makassar,15:Asia/Jayapura".
+	TenantTimezones string
+
+	// Postgres row-level security: set app.company_id/app.user_id on every request transaction.
+	DBRowLevelSecurity string // on|off
+
+	// Tabel counter untuk generate= (kode/nomor dokumen per tenant per periode).
+	SequenceTable string
 }
 
 // Load reads configuration from environment variables.
@@ -41,6 +62,12 @@ func Load() (Config, error) {
 	// - AUTH_SESSION_DRIVER (optional: file|database|none)
 	// - AUTH_SESSION_FILES (optional, saat driver=file)
 	// - AUTH_SESSION_TABLE (optional, saat driver=database)
+	// - WEBHOOK_DISPATCHER (optional: on|off, default on saat DATABASE_URL ada)
+	// - WEBHOOK_MAX_ATTEMPTS / WEBHOOK_POLL_INTERVAL / WEBHOOK_TIMEOUT (optional)
+	// - OUTPUT_DECIMALS (optional: string|number, default string)
+	// - OUTPUT_TIMEZONE (optional, contoh Asia/Jakarta)
+	// - TENANT_TIMEZONES (optional, zona export per company_id)
+	// - DB_RLS (optional: on|off, default off; policy dibuat dengan subcommand `rls`)
 	cfg := Config{
 		HTTPAddr:    getenv("HTTP_ADDR", ":8080"),
 		DatabaseURL: os.Getenv("DATABASE_URL"),
@@ -52,6 +79,21 @@ func Load() (Config, error) {
 		AuthSessionDriver: getenv("AUTH_SESSION_DRIVER", "file"),
 		AuthSessionFiles:  getenv("AUTH_SESSION_FILES", "storage/sessions"),
 		AuthSessionTable:  getenv("AUTH_SESSION_TABLE", "auth_sessions"),
+
+		WebhookDispatcher:      getenv("WEBHOOK_DISPATCHER", "on"),
+		WebhookMaxAttempts:     getenvInt64("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookPollInterval:    getenvInt64("WEBHOOK_POLL_INTERVAL", 5),
+		WebhookTimeout:         getenvInt64("WEBHOOK_TIMEOUT", 10),
+		WebhookAllowedNetworks: strings.TrimSpace(os.Getenv("WEBHOOK_ALLOWED_NETWORKS")),
+
+		OutputDecimals: getenv("OUTPUT_DECIMALS", "string"),
+		OutputTimezone: strings.TrimSpace(os.Getenv("OUTPUT_TIMEZONE")),
+
+		TenantTimezones: strings.TrimSpace(os.Getenv("TENANT_TIMEZONES")),
+
+		DBRowLevelSecurity: getenv("DB_RLS", "off"),
+
+		SequenceTable: getenv("SEQUENCE_TABLE", "gateway_sequences"),
 	}
 
 	if cfg.HTTPAddr == "" {
@@ -60,6 +102,16 @@ func Load() (Config, error) {
 	if cfg.JWTSecret == "" {
 		return Config{}, fmt.Errorf("JWT_SECRET is required")
 	}
+	switch strings.ToLower(strings.TrimSpace(cfg.OutputDecimals)) {
+	case "string", "number":
+	default:
+		return Config{}, fmt.Errorf("OUTPUT_DECIMALS must be string or number")
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.DBRowLevelSecurity)) {
+	case "on", "off":
+	default:
+		return Config{}, fmt.Errorf("DB_RLS must be on or off")
+	}
 	return cfg, nil
 }
 
@@ -0,0 +1,173 @@
+package auditcontroller
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mylab-api-go/internal/audit"
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/routes/auth"
+	"mylab-api-go/internal/routes/shared"
+)
+
+// AuditController exposes the CRUD audit trail, scoped to the caller's tenant.
+//
+// Route:
+// - GET /v1/audit?table=&pk=&user_id=&from=&to=&page=&per_page=
+//
+// from/to accept RFC3339 or YYYY-MM-DD (a date-only "to" includes the whole day).
+type AuditController struct {
+	sqlDB       *sql.DB
+	audit       *audit.Recorder
+	unavailable error // why audit is nil: disabled or unsupported database
+}
+
+func NewAuditController(sqlDB *sql.DB) *AuditController {
+	c := &AuditController{sqlDB: sqlDB}
+	if sqlDB != nil {
+		rec, err := audit.NewRecorder(sqlDB, os.Getenv("AUDIT_TABLE"))
+		if err != nil && !errors.Is(err, audit.ErrUnsupportedDialect) && !errors.Is(err, audit.ErrDisabled) {
+			log.Printf("audit: %v; falling back to %s", err, audit.DefaultTable)
+			rec, err = audit.NewRecorder(sqlDB, audit.DefaultTable)
+		}
+		if err == nil {
+			c.audit = rec
+		}
+		c.unavailable = err
+	}
+	return c
+}
+
+func (c *AuditController) HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/audit" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if c.sqlDB == nil {
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", map[string]string{"database": "not configured"})
+		return
+	}
+	if c.audit == nil {
+		msg := audit.ErrUnsupportedDialect.Error()
+		if c.unavailable != nil {
+			msg = c.unavailable.Error()
+		}
+		shared.WriteError(w, http.StatusNotImplemented, "Not implemented.", map[string]string{"database": msg})
+		return
+	}
+
+	authInfo, ok := auth.AuthInfoFromContext(r.Context())
+	if !ok {
+		shared.WriteError(w, http.StatusUnauthorized, "Unauthorized.", nil)
+		return
+	}
+
+	f, errs := parseFilter(r)
+	if len(errs) > 0 {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", errs)
+		return
+	}
+	f.CompanyID = authInfo.CompanyID
+
+	res, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (*audit.ListResult, error) {
+		return c.audit.List(r.Context(), tx, f)
+	})
+	if err != nil {
+		var ve *eloquent.ValidationError
+		if errors.As(err, &ve) {
+			shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", ve.Errors)
+			return
+		}
+		log.Printf(
+			`{"ts":%q,"level":"error","msg":"audit list failed","request_id":%q,"error":%q}`,
+			time.Now().UTC().Format(time.RFC3339Nano),
+			shared.RequestIDFromContext(r.Context()),
+			err.Error(),
+		)
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", nil)
+		return
+	}
+
+	shared.WriteJSON(w, http.StatusOK, map[string]any{
+		"ok":      true,
+		"message": "OK",
+		"data":    res.Rows,
+		"paging": map[string]any{
+			"page":     res.Page,
+			"per_page": res.PerPage,
+			"has_more": res.HasMore,
+		},
+	})
+}
+
+func parseFilter(r *http.Request) (audit.Filter, map[string]string) {
+	qs := r.URL.Query()
+	errs := map[string]string{}
+	f := audit.Filter{
+		Table: strings.ToLower(strings.TrimSpace(qs.Get("table"))),
+		PK:    strings.TrimSpace(qs.Get("pk")),
+	}
+
+	if v := strings.TrimSpace(qs.Get("user_id")); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			errs["user_id"] = "must be a positive integer"
+		}
+		f.UserID = n
+	}
+	if v := strings.TrimSpace(qs.Get("from")); v != "" {
+		t, _, err := parseTime(v)
+		if err != nil {
+			errs["from"] = "invalid date (use RFC3339 or YYYY-MM-DD)"
+		} else {
+			f.From = &t
+		}
+	}
+	if v := strings.TrimSpace(qs.Get("to")); v != "" {
+		t, dateOnly, err := parseTime(v)
+		if err != nil {
+			errs["to"] = "invalid date (use RFC3339 or YYYY-MM-DD)"
+		} else {
+			if dateOnly {
+				t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+			f.To = &t
+		}
+	}
+	for _, key := range []string{"page", "per_page"} {
+		v := strings.TrimSpace(qs.Get(key))
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			errs[key] = "must be a positive integer"
+			continue
+		}
+		if key == "page" {
+			f.Page = n
+		} else {
+			f.PerPage = n
+		}
+	}
+	return f, errs
+}
+
+func parseTime(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	return t, true, err
+}
@@ -1,22 +1,32 @@
 package crudcontroller
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"mylab-api-go/internal/audit"
 	"mylab-api-go/internal/database/eloquent"
 	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/export"
+	"mylab-api-go/internal/history"
+	"mylab-api-go/internal/idempotency"
 	"mylab-api-go/internal/routes/auth"
 	"mylab-api-go/internal/routes/shared"
 	"mylab-api-go/internal/schema"
+	"mylab-api-go/internal/storage"
+	"mylab-api-go/internal/webhook"
 )
 
 var tableNameRE = regexp.MustCompile("^[a-z0-9_]+$")
@@ -25,25 +35,52 @@ var tableNameRE = regexp.MustCompile("^[a-z0-9_]+$")
 //
 // Routes:
 // - POST   /v1/crud/{table}
-// - GET    /v1/crud/{table}/{pk}
+// - GET    /v1/crud/{table}/{pk}     (?with=rel1,rel2&fields[rel]=a,b&limit[rel]=n; ?as_of= on versioned tables)
 // - PUT    /v1/crud/{table}/{pk}
 // - PATCH  /v1/crud/{table}/{pk}
 // - DELETE /v1/crud/{table}/{pk}
-// - POST   /v1/crud/{table}/select  (eloquent.SelectRequest)
+// - POST   /v1/crud/{table}/select  (eloquent.SelectRequest; "export" streams CSV/XLSX, see export.go)
+// - POST   /v1/crud/{table}/import  (CSV/XLSX multipart, see import.go)
+// - POST|GET|DELETE /v1/crud/{table}/{pk}/files/{column}  (see files.go)
+// - GET /v1/crud/{table}/{pk}/history, POST /v1/crud/{table}/{pk}/revert  (versioned tables, see history.go)
+// - POST   /v1/batch                (create/update/delete across tables in one tx, see batch.go)
 //
 // Security:
 // - Table access is controlled by env policy (denylist-only): CRUD_DENIED_TABLES.
-// - Tenant enforcement uses company_id and rejects tables without company_id.
+// - Tenant enforcement uses the schema's tenant column (tenant_column=, else company_id, else com_id)
+//   and rejects tables without one.
+// - Global tables (schema file tenant=global) are readable by every tenant and writable only by
+//   super-admins (auth.IsSuperAdmin).
+//
+// Audit:
+// - Every create/update/delete writes an audit entry (old/new row + diff) in the same tx.
+// - Audit table is gateway-owned: AUDIT_TABLE (default gateway_audit_log).
+// - Code generator counters are gateway-owned: SEQUENCE_TABLE (default gateway_sequences).
+// - Tables declared versioned=true also keep full row snapshots (history.Table) in the same tx.
+//
+// Webhooks:
+// - Every create/update/delete enqueues created/updated/deleted events in the webhook outbox
+//   (same tx); delivery is done by webhook.Dispatcher.
+//
+// Gateway-owned tables (audit, webhooks, sequences, history, idempotency keys) are never
+// reachable through this controller.
 type TableCRUDController struct {
-	sqlDB   *sql.DB
-	denyAll bool
-	denied  map[string]bool
+	sqlDB    *sql.DB
+	denyAll  bool
+	denied   map[string]bool
+	reserved map[string]bool
+	audit    *audit.Recorder
+	history  *history.Store // nil = row history disabled (non-Postgres)
+	webhooks *webhook.Store
+	files    storage.Backend // nil = file routes disabled
+	fileCfg  storage.Config
+	signer   *storage.Signer
 }
 
 func NewTableCRUDController(sqlDB *sql.DB) *TableCRUDController {
 	deniedRaw := strings.TrimSpace(os.Getenv("CRUD_DENIED_TABLES"))
 
-	c := &TableCRUDController{sqlDB: sqlDB, denied: map[string]bool{}}
+	c := &TableCRUDController{sqlDB: sqlDB, denied: map[string]bool{}, reserved: map[string]bool{}}
 	if deniedRaw != "" {
 		for _, part := range strings.Split(deniedRaw, ",") {
 			name := strings.ToLower(strings.TrimSpace(part))
@@ -57,6 +94,47 @@ func NewTableCRUDController(sqlDB *sql.DB) *TableCRUDController {
 			c.denied[name] = true
 		}
 	}
+
+	if sqlDB != nil {
+		rec, err := audit.NewRecorder(sqlDB, os.Getenv("AUDIT_TABLE"))
+		if err != nil && !errors.Is(err, audit.ErrUnsupportedDialect) && !errors.Is(err, audit.ErrDisabled) {
+			log.Printf("crud: %v; falling back to %s", err, audit.DefaultTable)
+			rec, err = audit.NewRecorder(sqlDB, audit.DefaultTable)
+		}
+		switch {
+		case errors.Is(err, audit.ErrDisabled):
+		case err != nil:
+			log.Printf("crud: %v; audit trail disabled", err)
+		default:
+			c.audit = rec
+			c.reserved[rec.Table()] = true
+		}
+
+		store, err := webhook.NewStore(sqlDB)
+		if err == nil {
+			c.webhooks = store
+		}
+
+		if hs, err := history.NewStore(sqlDB); err != nil {
+			log.Printf("crud: %v; writes to versioned tables are rejected", err)
+		} else {
+			c.history = hs
+		}
+	}
+	for _, t := range webhook.Tables() {
+		c.reserved[t] = true
+	}
+	c.reserved[eloquent.SequenceTable()] = true
+	c.reserved[history.Table] = true
+	c.reserved[idempotency.Table] = true
+
+	if cfg, err := storage.ConfigFromEnv(); err != nil {
+		log.Printf("crud: file storage disabled: %v", err)
+	} else if backend, err := storage.New(cfg); err != nil {
+		log.Printf("crud: file storage disabled: %v", err)
+	} else {
+		c.files, c.fileCfg, c.signer = backend, cfg, storage.NewSigner(cfg.SigningKey, cfg.URLTTL)
+	}
 	return c
 }
 
@@ -65,7 +143,7 @@ func (c *TableCRUDController) Allows(table string) bool {
 	if t == "" {
 		return false
 	}
-	if c.denyAll {
+	if c.denyAll || c.reserved[t] {
 		return false
 	}
 	return !c.denied[t]
@@ -125,6 +203,16 @@ func (c *TableCRUDController) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Optional subroute: /import
+	if len(segs) == 2 && segs[1] == "import" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		c.handleImport(w, r, authInfo.CompanyID, table)
+		return
+	}
+
 	if len(segs) == 1 {
 		// Collection: POST create only (safe default).
 		if r.Method != http.MethodPost {
@@ -146,6 +234,30 @@ func (c *TableCRUDController) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// File subroute: /{pk}/files/{column}
+	if len(segs) == 4 && segs[2] == "files" {
+		c.handleFile(w, r, authInfo.CompanyID, table, pk, strings.ToLower(segs[3]))
+		return
+	}
+
+	// Versioned tables: /{pk}/history and /{pk}/revert
+	if len(segs) == 3 && segs[2] == "history" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		c.handleHistory(w, r, authInfo.CompanyID, table, pk)
+		return
+	}
+	if len(segs) == 3 && segs[2] == "revert" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		c.handleRevert(w, r, authInfo.CompanyID, table, pk)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		c.handleGet(w, r, authInfo.CompanyID, table, pk)
@@ -171,26 +283,62 @@ func (c *TableCRUDController) handleCreate(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	pk, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (any, error) {
+	type result struct {
+		pk        any
+		relations map[string]nestedResult
+	}
+	res, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (result, error) {
 		s, err := schema.LoadSchema(r.Context(), tx, table)
 		if err != nil {
-			return nil, err
+			return result{}, err
 		}
 		tenantCol, verr := resolveTenantColumn(s)
 		if verr != nil {
-			return nil, verr
+			return result{}, verr
 		}
-		return eloquent.Insert(r.Context(), tx, s, withTenant(payload, tenantCol, companyID))
+		if err := checkWritable(r, s); err != nil {
+			return result{}, err
+		}
+		nested, err := splitNested(s, payload)
+		if err != nil {
+			return result{}, err
+		}
+		pk, err := eloquent.Insert(r.Context(), tx, s, withTenant(payload, tenantCol, companyID))
+		if err != nil {
+			return result{}, err
+		}
+		newRow, err := auditRow(r.Context(), tx, s, pk, tenantCol, companyID)
+		if err != nil {
+			return result{}, err
+		}
+		if err := c.recordChange(r, tx, companyID, s, pk, audit.ActionCreate, nil, newRow); err != nil {
+			return result{}, err
+		}
+		relations, err := c.writeNested(r, tx, companyID, nested, newRow, true)
+		if err != nil {
+			return result{}, err
+		}
+		return result{pk: pk, relations: relations}, nil
 	})
 	if err != nil {
 		writeDomainError(w, r, err)
 		return
 	}
 
-	shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "Created.", "table": table, "pk": pk})
+	shared.WriteJSON(w, http.StatusOK, nestedEnvelope(map[string]any{"ok": true, "message": "Created.", "table": table, "pk": res.pk}, res.relations))
 }
 
 func (c *TableCRUDController) handleGet(w http.ResponseWriter, r *http.Request, companyID int64, table, pk string) {
+	if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+		c.handleGetAsOf(w, r, companyID, table, pk, asOf)
+		return
+	}
+	with, verr := parseWithQuery(r.URL.Query())
+	if verr != nil {
+		writeDomainError(w, r, verr)
+		return
+	}
+
 	row, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (map[string]any, error) {
 		s, err := schema.LoadSchema(r.Context(), tx, table)
 		if err != nil {
@@ -200,7 +348,14 @@ func (c *TableCRUDController) handleGet(w http.ResponseWriter, r *http.Request,
 		if verr != nil {
 			return nil, verr
 		}
-		return eloquent.FindByPKAndTenant(r.Context(), tx, s, pk, tenantCol, companyID)
+		row, err := eloquent.FindByPKAndTenant(r.Context(), tx, s, pk, tenantCol, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if err := eloquent.EagerLoad(r.Context(), tx, s, []map[string]any{row}, with, companyID, c.relatedSchemaResolver(tx)); err != nil {
+			return nil, err
+		}
+		return row, nil
 	})
 	if err != nil {
 		writeDomainError(w, r, err)
@@ -218,7 +373,7 @@ func (c *TableCRUDController) handleUpdate(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	_, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (any, error) {
+	relations, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (map[string]nestedResult, error) {
 		s, err := schema.LoadSchema(r.Context(), tx, table)
 		if err != nil {
 			return nil, err
@@ -227,13 +382,37 @@ func (c *TableCRUDController) handleUpdate(w http.ResponseWriter, r *http.Reques
 		if verr != nil {
 			return nil, verr
 		}
-		return nil, eloquent.UpdateByPKAndTenant(r.Context(), tx, s, pk, tenantCol, companyID, withTenant(payload, tenantCol, companyID))
+		if err := checkWritable(r, s); err != nil {
+			return nil, err
+		}
+		nested, err := splitNested(s, payload)
+		if err != nil {
+			return nil, err
+		}
+		oldRow, err := auditRow(r.Context(), tx, s, pk, tenantCol, companyID)
+		if err != nil {
+			return nil, err
+		}
+		newRow := oldRow
+		// A body with only relation keys syncs the children and leaves the parent row as is.
+		if len(payload) > 0 || len(nested) == 0 {
+			if err := eloquent.UpdateByPKAndTenant(r.Context(), tx, s, pk, tenantCol, companyID, withTenant(payload, tenantCol, companyID)); err != nil {
+				return nil, err
+			}
+			if newRow, err = auditRow(r.Context(), tx, s, pk, tenantCol, companyID); err != nil {
+				return nil, err
+			}
+			if err := c.recordChange(r, tx, companyID, s, pk, audit.ActionUpdate, oldRow, newRow); err != nil {
+				return nil, err
+			}
+		}
+		return c.writeNested(r, tx, companyID, nested, newRow, false)
 	})
 	if err != nil {
 		writeDomainError(w, r, err)
 		return
 	}
-	shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "Updated.", "table": table, "pk": pk})
+	shared.WriteJSON(w, http.StatusOK, nestedEnvelope(map[string]any{"ok": true, "message": "Updated.", "table": table, "pk": pk}, relations))
 }
 
 func (c *TableCRUDController) handleDelete(w http.ResponseWriter, r *http.Request, companyID int64, table, pk string) {
@@ -246,7 +425,17 @@ func (c *TableCRUDController) handleDelete(w http.ResponseWriter, r *http.Reques
 		if verr != nil {
 			return nil, verr
 		}
-		return nil, eloquent.DeleteByPKAndTenant(r.Context(), tx, s, pk, tenantCol, companyID)
+		if err := checkWritable(r, s); err != nil {
+			return nil, err
+		}
+		oldRow, err := auditRow(r.Context(), tx, s, pk, tenantCol, companyID)
+		if err != nil {
+			return nil, err
+		}
+		if err := eloquent.DeleteByPKAndTenant(r.Context(), tx, s, pk, tenantCol, companyID); err != nil {
+			return nil, err
+		}
+		return nil, c.recordChange(r, tx, companyID, s, pk, audit.ActionDelete, oldRow, nil)
 	})
 	if err != nil {
 		writeDomainError(w, r, err)
@@ -256,14 +445,22 @@ func (c *TableCRUDController) handleDelete(w http.ResponseWriter, r *http.Reques
 }
 
 func (c *TableCRUDController) handleSelect(w http.ResponseWriter, r *http.Request, companyID int64, table string) {
-	var req eloquent.SelectRequest
+	var body struct {
+		eloquent.SelectRequest
+		Export *export.Options `json:"export"` // stream a CSV/XLSX file instead of a page (export.go)
+	}
 	dec := json.NewDecoder(r.Body)
 	dec.UseNumber()
 	dec.DisallowUnknownFields()
-	if err := dec.Decode(&req); err != nil {
+	if err := dec.Decode(&body); err != nil {
 		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"body": "invalid JSON"})
 		return
 	}
+	if body.Export != nil {
+		c.handleExport(w, r, companyID, table, body.SelectRequest, *body.Export)
+		return
+	}
+	req := body.SelectRequest
 
 	selectOnce := func() (*eloquent.PageResult, error) {
 		return db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (*eloquent.PageResult, error) {
@@ -274,7 +471,14 @@ func (c *TableCRUDController) handleSelect(w http.ResponseWriter, r *http.Reques
 		if _, verr := resolveTenantColumn(s); verr != nil {
 			return nil, verr
 		}
-		return eloquent.SelectPage(r.Context(), tx, s, companyID, req)
+		res, err := eloquent.SelectPage(r.Context(), tx, s, companyID, req)
+		if err != nil {
+			return nil, err
+		}
+		if err := eloquent.EagerLoad(r.Context(), tx, s, res.Rows, req.With, companyID, c.relatedSchemaResolver(tx)); err != nil {
+			return nil, err
+		}
+		return res, nil
 		})
 	}
 
@@ -303,35 +507,210 @@ func (c *TableCRUDController) handleSelect(w http.ResponseWriter, r *http.Reques
 		"ok":      true,
 		"message": "OK",
 		"data":    res.Rows,
-		"paging": map[string]any{
-			"page":        res.Page,
-			"per_page":    res.PerPage,
-			"has_more":    res.HasMore,
-			"total_rows":  res.TotalRows,
-			"total_pages": res.TotalPages,
-		},
+		"paging":  pagingEnvelope(res),
 	})
 }
 
+// pagingEnvelope renders paging metadata. Totals are null when count=none; cursors are only
+// present in cursor mode.
+func pagingEnvelope(res *eloquent.PageResult) map[string]any {
+	paging := map[string]any{
+		"per_page":    res.PerPage,
+		"has_more":    res.HasMore,
+		"total_rows":  res.TotalRows,
+		"total_pages": res.TotalPages,
+	}
+	if res.Count == eloquent.CountNone {
+		paging["total_rows"] = nil
+		paging["total_pages"] = nil
+	}
+	if res.Count == eloquent.CountEstimate {
+		paging["estimated"] = true
+	}
+	if res.Pagination == eloquent.PaginationCursor {
+		paging["next_cursor"] = nullIfEmpty(res.NextCursor)
+		paging["prev_cursor"] = nullIfEmpty(res.PrevCursor)
+	} else {
+		paging["page"] = res.Page
+	}
+	return paging
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// auditRow loads the stored (visible, non-computed) row for the audit trail.
+// Hidden columns such as password hashes never reach the audit table.
+func auditRow(ctx context.Context, tx *sql.Tx, s eloquent.Schema, pk any, tenantCol string, companyID int64) (map[string]any, error) {
+	row, err := eloquent.FindByPKAndTenant(ctx, tx, s, pk, tenantCol, companyID)
+	if err != nil {
+		return nil, err
+	}
+	for name := range s.Computed {
+		if s.IsComputed(name) {
+			delete(row, name)
+		}
+	}
+	return row, nil
+}
+
+// recordChange writes the audit entry, enqueues webhook events and (on versioned tables) stores
+// the row snapshot for a mutation inside tx. A failure here fails (rolls back) the mutation.
+func (c *TableCRUDController) recordChange(r *http.Request, tx *sql.Tx, companyID int64, s eloquent.Schema, pk any, action string, oldRow, newRow map[string]any) error {
+	if err := c.auditAndNotify(r, tx, companyID, s.Table, pk, action, oldRow, newRow); err != nil {
+		return err
+	}
+	_, err := c.recordVersion(r, tx, companyID, s, pk, action, 0, oldRow, newRow)
+	return err
+}
+
+// auditAndNotify writes the audit entry and enqueues webhook events for a mutation inside tx.
+func (c *TableCRUDController) auditAndNotify(r *http.Request, tx *sql.Tx, companyID int64, table string, pk any, action string, oldRow, newRow map[string]any) error {
+	authInfo, _ := auth.AuthInfoFromContext(r.Context())
+	rid := shared.RequestIDFromContext(r.Context())
+	pkStr := fmt.Sprint(pk)
+
+	if c.audit != nil {
+		err := c.audit.Record(r.Context(), tx, audit.Entry{
+			Table:     table,
+			PK:        pkStr,
+			Action:    action,
+			CompanyID: companyID,
+			UserID:    authInfo.UserID,
+			Role:      authInfo.Role,
+			RequestID: rid,
+			ClientIP:  shared.ClientIP(r),
+			Old:       oldRow,
+			New:       newRow,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.webhooks != nil {
+		return c.webhooks.Enqueue(r.Context(), tx, webhook.Event{
+			Type:      webhookEventFor(action),
+			Table:     table,
+			PK:        pkStr,
+			CompanyID: companyID,
+			UserID:    authInfo.UserID,
+			RequestID: rid,
+			Old:       oldRow,
+			New:       newRow,
+		})
+	}
+	return nil
+}
+
+func webhookEventFor(action string) string {
+	switch action {
+	case audit.ActionCreate:
+		return webhook.EventCreated
+	case audit.ActionUpdate:
+		return webhook.EventUpdated
+	default:
+		return webhook.EventDeleted
+	}
+}
+
+// relatedSchemaResolver loads related-table schemas for eager loading, applying the CRUD table policy.
+func (c *TableCRUDController) relatedSchemaResolver(tx *sql.Tx) eloquent.SchemaResolver {
+	return func(ctx context.Context, table string) (eloquent.Schema, error) {
+		if !c.Allows(table) {
+			return eloquent.Schema{}, &eloquent.ValidationError{Errors: map[string]string{"with": "relation table not allowed: " + table}}
+		}
+		return schema.LoadSchema(ctx, tx, table)
+	}
+}
+
+// parseWithQuery parses eager-load query params:
+//
+//	?with=dokter,orders&fields[orders]=no_lab,tanggal&limit[orders]=5
+func parseWithQuery(qs url.Values) ([]eloquent.WithSpec, error) {
+	raw := strings.TrimSpace(qs.Get("with"))
+	if raw == "" {
+		return nil, nil
+	}
+	out := []eloquent.WithSpec{}
+	for _, part := range strings.Split(raw, ",") {
+		name := strings.TrimSpace(part)
+		if name == "" {
+			continue
+		}
+		spec := eloquent.WithSpec{Relation: name}
+		if fields := strings.TrimSpace(qs.Get("fields[" + name + "]")); fields != "" {
+			for _, f := range strings.Split(fields, ",") {
+				if f = strings.TrimSpace(f); f != "" {
+					spec.Select = append(spec.Select, f)
+				}
+			}
+		}
+		if lim := strings.TrimSpace(qs.Get("limit[" + name + "]")); lim != "" {
+			n, err := strconv.Atoi(lim)
+			if err != nil || n <= 0 {
+				return nil, &eloquent.ValidationError{Errors: map[string]string{"limit[" + name + "]": "must be a positive integer"}}
+			}
+			spec.Limit = n
+		}
+		out = append(out, spec)
+	}
+	return out, nil
+}
+
+// withTenant forces the caller's tenant into the payload (no-op for global tables).
 func withTenant(payload map[string]any, tenantCol string, companyID int64) map[string]any {
 	if payload == nil {
 		payload = map[string]any{}
 	}
-	payload[tenantCol] = companyID
+	if tenantCol != "" {
+		payload[tenantCol] = companyID
+	}
 	return payload
 }
 
+// resolveTenantColumn returns the tenant column of s; "" for global (shared) tables.
 func resolveTenantColumn(s eloquent.Schema) (string, error) {
-	if s.HasColumn("company_id") {
-		return "company_id", nil
+	if s.Global {
+		return "", nil
 	}
-	if s.HasColumn("com_id") {
-		return "com_id", nil
+	if col := s.TenantColumnName(); col != "" {
+		return col, nil
 	}
-	return "", &eloquent.ValidationError{Errors: map[string]string{"tenant": "schema does not support tenant filter (company_id/com_id missing)"}}
+	return "", &eloquent.ValidationError{Errors: map[string]string{"tenant": "schema does not support tenant filter (tenant_column/company_id/com_id missing)"}}
+}
+
+// errGlobalReadOnly is returned for writes to a global table by a non-super-admin.
+var errGlobalReadOnly = errors.New("global table is writable by super-admin only")
+
+func checkWritable(r *http.Request, s eloquent.Schema) error {
+	if !s.Global {
+		return nil
+	}
+	authInfo, _ := auth.AuthInfoFromContext(r.Context())
+	if !auth.IsSuperAdmin(authInfo) {
+		return errGlobalReadOnly
+	}
+	return nil
 }
 
 func writeDomainError(w http.ResponseWriter, r *http.Request, err error) {
+	writeDomainErrorWith(w, r, err, nil)
+}
+
+// writeDomainErrorWith is writeDomainError with extra keys added to errors (e.g. the failing
+// operation of a batch).
+func writeDomainErrorWith(w http.ResponseWriter, r *http.Request, err error, extra map[string]string) {
+	writeError := func(status int, msg string, errs map[string]string) {
+		for k, v := range extra {
+			errs[k] = v
+		}
+		shared.WriteError(w, status, msg, errs)
+	}
 	rid := ""
 	if r != nil {
 		rid = shared.RequestIDFromContext(r.Context())
@@ -347,7 +726,16 @@ func writeDomainError(w http.ResponseWriter, r *http.Request, err error) {
 		if rid != "" {
 			out["request_id"] = rid
 		}
-		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", out)
+		writeError(http.StatusUnprocessableEntity, "Validation failed.", out)
+		return
+	}
+
+	if errors.Is(err, errGlobalReadOnly) {
+		errs := map[string]string{"table": errGlobalReadOnly.Error(), "code": "forbidden"}
+		if rid != "" {
+			errs["request_id"] = rid
+		}
+		writeError(http.StatusForbidden, "Forbidden.", errs)
 		return
 	}
 
@@ -357,7 +745,25 @@ func writeDomainError(w http.ResponseWriter, r *http.Request, err error) {
 		if rid != "" {
 			errs["request_id"] = rid
 		}
-		shared.WriteError(w, http.StatusNotFound, "Not found.", errs)
+		writeError(http.StatusNotFound, "Not found.", errs)
+		return
+	}
+
+	// Invalid schema file: fail closed (never serve the table without its file restrictions).
+	var fe *schema.FileError
+	if errors.As(err, &fe) {
+		log.Printf(
+			`{"ts":%q,"level":"error","msg":"schema file invalid","request_id":%q,"table":%q,"error":%q}`,
+			time.Now().UTC().Format(time.RFC3339Nano),
+			rid,
+			fe.Table,
+			fe.Error(),
+		)
+		errs := map[string]string{"code": "schema_invalid", "table": fe.Table}
+		if rid != "" {
+			errs["request_id"] = rid
+		}
+		writeError(http.StatusInternalServerError, "Internal server error.", errs)
 		return
 	}
 
@@ -380,5 +786,5 @@ func writeDomainError(w http.ResponseWriter, r *http.Request, err error) {
 	if rid != "" {
 		errs["request_id"] = rid
 	}
-	shared.WriteError(w, status, msg, errs)
+	writeError(status, msg, errs)
 }
@@ -0,0 +1,318 @@
+package crudcontroller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mylab-api-go/internal/audit"
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/routes/auth"
+	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/schema"
+)
+
+// Batch: POST /v1/batch runs an ordered list of create/update/delete operations across tables
+// in one transaction. Every operation goes through the same checks, audit entries, webhook
+// events and row versions as its single-row route; the first failure rolls back everything.
+//
+// Later operations can use earlier results: a string value that is exactly "$ops[N].pk" or
+// "$ops[N].data.<column>" (N < the current index) is replaced by that value, in data and in pk.
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// DefaultBatchMaxOperations caps one batch unless BATCH_MAX_OPERATIONS says otherwise.
+const DefaultBatchMaxOperations = 100
+
+type batchOperation struct {
+	Op    string         `json:"op"`
+	Table string         `json:"table"`
+	PK    any            `json:"pk,omitempty"`
+	Data  map[string]any `json:"data,omitempty"`
+}
+
+type batchResult struct {
+	Op    string         `json:"op"`
+	Table string         `json:"table"`
+	PK    any            `json:"pk"`
+	Data  map[string]any `json:"data"` // the stored row after the operation (nil for delete)
+}
+
+// batchError ties a failure to the operation that caused it.
+type batchError struct {
+	Index int
+	Err   error
+}
+
+func (e *batchError) Error() string { return fmt.Sprintf("operations[%d]: %v", e.Index, e.Err) }
+func (e *batchError) Unwrap() error { return e.Err }
+
+var batchRefRE = regexp.MustCompile(`^\$ops\[([0-9]+)\]\.(pk|data\.([A-Za-z0-9_]+))$`)
+
+func batchMaxOperations() int {
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("BATCH_MAX_OPERATIONS"))); err == nil && n > 0 {
+		return n
+	}
+	return DefaultBatchMaxOperations
+}
+
+// HandleBatch serves POST /v1/batch.
+func (c *TableCRUDController) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/batch" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if c.sqlDB == nil {
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", map[string]string{"database": "not configured"})
+		return
+	}
+	authInfo, ok := auth.AuthInfoFromContext(r.Context())
+	if !ok {
+		shared.WriteError(w, http.StatusUnauthorized, "Unauthorized.", nil)
+		return
+	}
+
+	var body struct {
+		Operations []batchOperation `json:"operations"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"body": "invalid JSON"})
+		return
+	}
+	if verr := c.validateBatch(body.Operations); verr != nil {
+		writeDomainError(w, r, verr)
+		return
+	}
+
+	results, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) ([]batchResult, error) {
+		return c.runBatch(r, tx, authInfo.CompanyID, body.Operations)
+	})
+	if err != nil {
+		writeBatchError(w, r, err)
+		return
+	}
+	shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "OK", "results": results})
+}
+
+// validateBatch checks the shape of every operation before anything runs.
+func (c *TableCRUDController) validateBatch(ops []batchOperation) *eloquent.ValidationError {
+	if len(ops) == 0 {
+		return &eloquent.ValidationError{Errors: map[string]string{"operations": "required"}}
+	}
+	if max := batchMaxOperations(); len(ops) > max {
+		return &eloquent.ValidationError{Errors: map[string]string{"operations": fmt.Sprintf("at most %d operations per batch", max)}}
+	}
+	errs := map[string]string{}
+	for i, op := range ops {
+		key := fmt.Sprintf("operations[%d]", i)
+		table := strings.ToLower(strings.TrimSpace(op.Table))
+		switch {
+		case !tableNameRE.MatchString(table):
+			errs[key+".table"] = "invalid name (allowed: a-z0-9_ only)"
+		case !c.Allows(table):
+			errs[key+".table"] = "not allowed"
+		}
+		switch op.Op {
+		case BatchCreate:
+			if op.PK != nil {
+				errs[key+".pk"] = "not allowed for create (send it in data)"
+			}
+		case BatchUpdate, BatchDelete:
+			if op.PK == nil || fmt.Sprint(op.PK) == "" {
+				errs[key+".pk"] = "required"
+			}
+			if op.Op == BatchDelete && op.Data != nil {
+				errs[key+".data"] = "not allowed for delete"
+			}
+		default:
+			errs[key+".op"] = "must be create, update or delete"
+		}
+	}
+	if len(errs) > 0 {
+		return &eloquent.ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+type batchTable struct {
+	schema    eloquent.Schema
+	tenantCol string
+}
+
+func (c *TableCRUDController) runBatch(r *http.Request, tx *sql.Tx, companyID int64, ops []batchOperation) ([]batchResult, error) {
+	ctx := r.Context()
+	tables := map[string]batchTable{}
+	results := make([]batchResult, 0, len(ops))
+	for i, op := range ops {
+		fail := func(err error) ([]batchResult, error) { return nil, &batchError{Index: i, Err: err} }
+
+		table := strings.ToLower(strings.TrimSpace(op.Table))
+		bt, ok := tables[table]
+		if !ok {
+			s, err := schema.LoadSchema(ctx, tx, table)
+			if err != nil {
+				return fail(err)
+			}
+			tenantCol, err := resolveTenantColumn(s)
+			if err != nil {
+				return fail(err)
+			}
+			bt = batchTable{schema: s, tenantCol: tenantCol}
+			tables[table] = bt
+		}
+		s, tenantCol := bt.schema, bt.tenantCol
+		if err := checkWritable(r, s); err != nil {
+			return fail(err)
+		}
+
+		resolved, err := resolveBatchRefs(op.Data, results, "data")
+		if err != nil {
+			return fail(err)
+		}
+		data, _ := resolved.(map[string]any)
+		var pk any
+		if op.PK != nil {
+			if pk, err = resolveBatchRefs(op.PK, results, "pk"); err != nil {
+				return fail(err)
+			}
+			if n, ok := pk.(json.Number); ok {
+				pk = n.String()
+			}
+		}
+
+		res := batchResult{Op: op.Op, Table: table}
+		switch op.Op {
+		case BatchCreate:
+			pk, err = eloquent.Insert(ctx, tx, s, withTenant(data, tenantCol, companyID))
+			if err != nil {
+				return fail(err)
+			}
+			newRow, err := auditRow(ctx, tx, s, pk, tenantCol, companyID)
+			if err != nil {
+				return fail(err)
+			}
+			if err := c.recordChange(r, tx, companyID, s, pk, audit.ActionCreate, nil, newRow); err != nil {
+				return fail(err)
+			}
+			res.Data = newRow
+		case BatchUpdate:
+			oldRow, err := auditRow(ctx, tx, s, pk, tenantCol, companyID)
+			if err != nil {
+				return fail(err)
+			}
+			if err := eloquent.UpdateByPKAndTenant(ctx, tx, s, pk, tenantCol, companyID, withTenant(data, tenantCol, companyID)); err != nil {
+				return fail(err)
+			}
+			newRow, err := auditRow(ctx, tx, s, pk, tenantCol, companyID)
+			if err != nil {
+				return fail(err)
+			}
+			if err := c.recordChange(r, tx, companyID, s, pk, audit.ActionUpdate, oldRow, newRow); err != nil {
+				return fail(err)
+			}
+			res.Data = newRow
+		case BatchDelete:
+			oldRow, err := auditRow(ctx, tx, s, pk, tenantCol, companyID)
+			if err != nil {
+				return fail(err)
+			}
+			if err := eloquent.DeleteByPKAndTenant(ctx, tx, s, pk, tenantCol, companyID); err != nil {
+				return fail(err)
+			}
+			if err := c.recordChange(r, tx, companyID, s, pk, audit.ActionDelete, oldRow, nil); err != nil {
+				return fail(err)
+			}
+		}
+		res.PK = pk
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// resolveBatchRefs replaces "$ops[N].pk" / "$ops[N].data.<column>" strings in v (recursively
+// through objects and arrays) with results of earlier operations. Other strings starting with
+// "$ops[" are rejected so a typo is not stored as text.
+func resolveBatchRefs(v any, done []batchResult, path string) (any, error) {
+	switch t := v.(type) {
+	case string:
+		if !strings.HasPrefix(t, "$ops[") {
+			return t, nil
+		}
+		m := batchRefRE.FindStringSubmatch(t)
+		if m == nil {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{path: fmt.Sprintf("invalid reference %q (use $ops[N].pk or $ops[N].data.<column>)", t)}}
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n >= len(done) {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{path: fmt.Sprintf("%s refers to an operation that has not run yet", t)}}
+		}
+		if m[2] == "pk" {
+			return done[n].PK, nil
+		}
+		val, ok := done[n].Data[m[3]]
+		if !ok {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{path: fmt.Sprintf("%s: operation %d has no column %q", t, n, m[3])}}
+		}
+		return val, nil
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, item := range t {
+			resolved, err := resolveBatchRefs(item, done, path+"."+k)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = resolved
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(t))
+		for i, item := range t {
+			resolved, err := resolveBatchRefs(item, done, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// writeBatchError reports the failing operation: validation keys are prefixed with
+// "operations[i]." and every other error carries errors.operation.
+func writeBatchError(w http.ResponseWriter, r *http.Request, err error) {
+	var be *batchError
+	if !errors.As(err, &be) {
+		writeDomainError(w, r, err)
+		return
+	}
+	var ve *eloquent.ValidationError
+	if errors.As(be.Err, &ve) {
+		prefixed := make(map[string]string, len(ve.Errors))
+		for k, v := range ve.Errors {
+			prefixed[fmt.Sprintf("operations[%d].%s", be.Index, k)] = v
+		}
+		prefixed["operation"] = strconv.Itoa(be.Index)
+		writeDomainError(w, r, &eloquent.ValidationError{Errors: prefixed})
+		return
+	}
+	writeDomainErrorWith(w, r, be.Err, map[string]string{"operation": strconv.Itoa(be.Index)})
+}
@@ -0,0 +1,71 @@
+package crudcontroller
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"mylab-api-go/internal/database/eloquent"
+)
+
+func TestResolveBatchRefs(t *testing.T) {
+	done := []batchResult{
+		{Op: BatchCreate, Table: "lab_order", PK: int64(41), Data: map[string]any{"no_lab": "LAB2603180001", "kd_ps": "P1"}},
+	}
+	in := map[string]any{
+		"order_id": "$ops[0].pk",
+		"no_lab":   "$ops[0].data.no_lab",
+		"hasil":    []any{"$ops[0].data.kd_ps", "$ 5"},
+		"catatan":  "ops[0].pk",
+		"qty":      json.Number("2"),
+	}
+	got, err := resolveBatchRefs(in, done, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"order_id": int64(41),
+		"no_lab":   "LAB2603180001",
+		"hasil":    []any{"P1", "$ 5"},
+		"catatan":  "ops[0].pk",
+		"qty":      json.Number("2"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("resolved = %#v, want %#v", got, want)
+	}
+
+	for ref, key := range map[string]string{
+		"$ops[1].pk":           "data.x", // not run yet
+		"$ops[0].data.missing": "data.x",
+		"$ops[0].id":           "data.x",
+	} {
+		_, err := resolveBatchRefs(map[string]any{"x": ref}, done, "data")
+		var ve *eloquent.ValidationError
+		if !errors.As(err, &ve) || ve.Errors[key] == "" {
+			t.Errorf("%s: err = %v", ref, err)
+		}
+	}
+}
+
+func TestValidateBatch(t *testing.T) {
+	c := &TableCRUDController{denied: map[string]bool{"users": true}, reserved: map[string]bool{}}
+	verr := c.validateBatch([]batchOperation{
+		{Op: BatchCreate, Table: "lab_order", Data: map[string]any{"kd_ps": "P1"}},
+		{Op: BatchUpdate, Table: "lab_order"},
+		{Op: "upsert", Table: "Lab-Order"},
+		{Op: BatchDelete, Table: "users", PK: "1"},
+	})
+	if verr == nil {
+		t.Fatal("expected errors")
+	}
+	want := map[string]string{
+		"operations[1].pk":    "required",
+		"operations[2].op":    "must be create, update or delete",
+		"operations[2].table": "invalid name (allowed: a-z0-9_ only)",
+		"operations[3].table": "not allowed",
+	}
+	if !reflect.DeepEqual(verr.Errors, want) {
+		t.Fatalf("errors = %v", verr.Errors)
+	}
+}
@@ -0,0 +1,72 @@
+package crudcontroller
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/export"
+	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/schema"
+)
+
+// handleExport streams the rows matching a select request (filters, order_by, select) as a
+// CSV/XLSX download: POST /v1/crud/{table}/select with an "export" object. Paging fields are
+// ignored; more than EXPORT_MAX_ROWS matches is a 422 before anything is streamed.
+func (c *TableCRUDController) handleExport(w http.ResponseWriter, r *http.Request, companyID int64, table string, req eloquent.SelectRequest, opt export.Options) {
+	format, verr := opt.Validate()
+	if verr != nil {
+		writeDomainError(w, r, verr)
+		return
+	}
+	if len(req.With) > 0 {
+		writeDomainError(w, r, &eloquent.ValidationError{Errors: map[string]string{"with": "not supported in exports"}})
+		return
+	}
+	if len(opt.Columns) > 0 {
+		req.Select = opt.Columns
+	}
+	loc := export.Location(companyID, opt.Timezone)
+	lang := export.Lang(r, opt.Lang)
+	st := export.NewStream(w, format, export.Filename(opt.Filename, table, format, loc), opt)
+
+	_, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (any, error) {
+		s, err := schema.LoadSchema(r.Context(), tx, table)
+		if err != nil {
+			return nil, err
+		}
+		if _, verr := resolveTenantColumn(s); verr != nil {
+			return nil, verr
+		}
+		d, _, err := schema.LoadDirectives(table)
+		if err != nil {
+			return nil, err
+		}
+		// Labels may be keyed by alias; result columns are real names.
+		labels := map[string]string{}
+		for k, v := range opt.Labels {
+			if col, ok := s.Aliases[k]; ok {
+				k = col
+			}
+			labels[k] = v
+		}
+		st.Title = func(col string) string { return export.Title(col, labels, d.UI, lang) }
+
+		if err := eloquent.SelectStream(r.Context(), tx, s, companyID, req, export.MaxRows(), loc, st); err != nil {
+			return nil, err
+		}
+		return nil, st.Close()
+	})
+	if err != nil {
+		if st.Started() {
+			// Headers are out: abort the connection so the client sees a failed download.
+			log.Printf(`{"ts":%q,"level":"error","msg":"crud export aborted","request_id":%q,"table":%q,"error":%q}`,
+				time.Now().UTC().Format(time.RFC3339Nano), shared.RequestIDFromContext(r.Context()), table, err.Error())
+			panic(http.ErrAbortHandler)
+		}
+		writeDomainError(w, r, err)
+	}
+}
@@ -0,0 +1,427 @@
+package crudcontroller
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"mylab-api-go/internal/audit"
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/schema"
+	"mylab-api-go/internal/storage"
+)
+
+// File attachments:
+// - POST   /v1/crud/{table}/{pk}/files/{column}  (multipart, field "file"): store and set the column
+// - GET    /v1/crud/{table}/{pk}/files/{column}  signed download URL (FILE_URL_TTL)
+// - DELETE /v1/crud/{table}/{pk}/files/{column}  clear the column and remove the file
+// - GET    /files/{key}?expires=&signature=       public signed download (HandleDownload)
+//
+// Only columns declared with files= in the schema file accept uploads. The column stores the
+// storage key {tenant}/{table}/{pk}/{column}/{name}; GET only signs keys under that prefix, so a
+// key copied from another row or tenant is never served.
+
+var fileKeySegRE = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// fileExts maps accepted content types to stored file extensions (and back for downloads).
+var fileExts = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+	"text/csv":        ".csv",
+	"application/zip": ".zip",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":       ".xlsx",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+}
+
+// inlineTypes are rendered in the browser; everything else is served as an attachment.
+var inlineTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true, "application/pdf": true}
+
+type fileInfo struct {
+	Key         string    `json:"key"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size,omitempty"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (c *TableCRUDController) handleFile(w http.ResponseWriter, r *http.Request, companyID int64, table, pk, column string) {
+	if c.files == nil {
+		shared.WriteError(w, http.StatusServiceUnavailable, "Service unavailable.", map[string]string{"files": "file storage not configured", "code": "storage_unavailable"})
+		return
+	}
+	d, _, err := schema.LoadDirectives(table)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	fc, ok := d.Files[column]
+	if !ok {
+		writeDomainError(w, r, &eloquent.ValidationError{Errors: map[string]string{"column": "not a file column (declare it with files= in the schema file)"}})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		c.handleFileUpload(w, r, companyID, table, pk, column, fc)
+	case http.MethodGet:
+		c.handleFileURL(w, r, companyID, table, pk, column)
+	case http.MethodDelete:
+		c.handleFileDelete(w, r, companyID, table, pk, column)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *TableCRUDController) handleFileUpload(w http.ResponseWriter, r *http.Request, companyID int64, table, pk, column string, fc schema.FileColumn) {
+	limit := c.fileCfg.MaxSize
+	if fc.MaxSize > 0 && fc.MaxSize < limit {
+		limit = fc.MaxSize
+	}
+	tooLarge := func() {
+		shared.WriteError(w, http.StatusRequestEntityTooLarge, "File too large.", map[string]string{"file": fmt.Sprintf("must not exceed %d bytes", limit), "code": "file_too_large"})
+	}
+
+	// Multipart overhead on top of the file itself; larger parts are spooled to temp files.
+	r.Body = http.MaxBytesReader(w, r.Body, limit+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			tooLarge()
+			return
+		}
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"body": "invalid multipart form"})
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	f, fh, err := r.FormFile("file")
+	if err != nil {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"file": "required"})
+		return
+	}
+	defer f.Close()
+	if fh.Size > limit {
+		tooLarge()
+		return
+	}
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	contentType := detectContentType(head[:n], fh.Header.Get("Content-Type"))
+	if !fc.AllowsType(contentType) {
+		shared.WriteError(w, http.StatusUnsupportedMediaType, "Unsupported file type.", map[string]string{
+			"file": fmt.Sprintf("content type %s is not allowed (allowed: %s)", contentType, strings.Join(fc.Types, ", ")),
+			"code": "unsupported_file_type",
+		})
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	var (
+		key    string
+		stored bool
+	)
+	oldKey, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (string, error) {
+		s, tenantCol, err := c.fileSchema(r, tx, table)
+		if err != nil {
+			return "", err
+		}
+		prefix := fileKeyPrefix(s, companyID, table, pk, column)
+		key = prefix + time.Now().UTC().Format("20060102") + "-" + randomHex(8) + fileExt(contentType)
+		// Check the row (and tenant) before storing anything.
+		if _, err := storedFileKey(r.Context(), tx, s, pk, tenantCol, companyID, column); err != nil {
+			return "", err
+		}
+		if err := c.files.Put(r.Context(), key, f, fh.Size, contentType); err != nil {
+			return "", fmt.Errorf("store file: %w", err)
+		}
+		stored = true
+		old, err := c.setFileColumn(r, tx, s, tenantCol, companyID, table, pk, column, key)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(old, prefix) {
+			old = "" // legacy value or foreign key: not ours to delete
+		}
+		return old, nil
+	})
+	if err != nil {
+		if stored {
+			c.removeFile(key) // rolled back: the row still points at the old file
+		}
+		writeDomainError(w, r, err)
+		return
+	}
+	if oldKey != "" {
+		c.removeFile(oldKey)
+	}
+
+	url, exp := c.signer.URL(key, time.Now())
+	shared.WriteJSON(w, http.StatusOK, map[string]any{
+		"ok": true, "message": "Uploaded.", "table": table, "pk": pk, "column": column,
+		"data": fileInfo{Key: key, ContentType: contentType, Size: fh.Size, URL: url, ExpiresAt: exp},
+	})
+}
+
+func (c *TableCRUDController) handleFileURL(w http.ResponseWriter, r *http.Request, companyID int64, table, pk, column string) {
+	key, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (string, error) {
+		s, tenantCol, err := c.fileSchema(r, tx, table)
+		if err != nil {
+			return "", err
+		}
+		key, err := storedFileKey(r.Context(), tx, s, pk, tenantCol, companyID, column)
+		if err != nil {
+			return "", err
+		}
+		if key == "" || !strings.HasPrefix(key, fileKeyPrefix(s, companyID, table, pk, column)) {
+			return "", errFileNotSet
+		}
+		return key, nil
+	})
+	if err != nil {
+		writeFileError(w, r, err)
+		return
+	}
+	url, exp := c.signer.URL(key, time.Now())
+	shared.WriteJSON(w, http.StatusOK, map[string]any{
+		"ok": true, "message": "OK",
+		"data": fileInfo{Key: key, ContentType: downloadContentType(key), URL: url, ExpiresAt: exp},
+	})
+}
+
+func (c *TableCRUDController) handleFileDelete(w http.ResponseWriter, r *http.Request, companyID int64, table, pk, column string) {
+	oldKey, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (string, error) {
+		s, tenantCol, err := c.fileSchema(r, tx, table)
+		if err != nil {
+			return "", err
+		}
+		old, err := storedFileKey(r.Context(), tx, s, pk, tenantCol, companyID, column)
+		if err != nil {
+			return "", err
+		}
+		if old == "" {
+			return "", errFileNotSet
+		}
+		if _, err := c.setFileColumn(r, tx, s, tenantCol, companyID, table, pk, column, nil); err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(old, fileKeyPrefix(s, companyID, table, pk, column)) {
+			return "", nil
+		}
+		return old, nil
+	})
+	if err != nil {
+		writeFileError(w, r, err)
+		return
+	}
+	if oldKey != "" {
+		c.removeFile(oldKey)
+	}
+	shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "Deleted.", "table": table, "pk": pk, "column": column})
+}
+
+// HandleDownload serves GET /files/{key}?expires=&signature= without a bearer token.
+func (c *TableCRUDController) HandleDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if c.files == nil {
+		shared.WriteError(w, http.StatusServiceUnavailable, "Service unavailable.", map[string]string{"code": "storage_unavailable"})
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, storage.DownloadPrefix)
+	qs := r.URL.Query()
+	if !storage.ValidKey(key) || !c.signer.Verify(key, qs.Get("expires"), qs.Get("signature"), time.Now()) {
+		shared.WriteError(w, http.StatusForbidden, "Forbidden.", map[string]string{"signature": "invalid or expired", "code": "forbidden"})
+		return
+	}
+
+	body, err := c.files.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		shared.WriteError(w, http.StatusNotFound, "Not found.", map[string]string{"file": "not found", "code": "not_found"})
+		return
+	}
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	defer body.Close()
+
+	ct := downloadContentType(key)
+	disposition := "attachment"
+	if inlineTypes[ct] {
+		disposition = "inline"
+	}
+	h := w.Header()
+	h.Set("Content-Type", ct)
+	h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": path.Base(key)}))
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	h.Set("Cache-Control", "private, max-age=60")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = io.Copy(w, body)
+	}
+}
+
+// fileSchema loads the table schema for a file route and resolves its tenant column.
+func (c *TableCRUDController) fileSchema(r *http.Request, tx *sql.Tx, table string) (eloquent.Schema, string, error) {
+	s, err := schema.LoadSchema(r.Context(), tx, table)
+	if err != nil {
+		return eloquent.Schema{}, "", err
+	}
+	tenantCol, err := resolveTenantColumn(s)
+	if err != nil {
+		return eloquent.Schema{}, "", err
+	}
+	if r.Method != http.MethodGet {
+		if err := checkWritable(r, s); err != nil {
+			return eloquent.Schema{}, "", err
+		}
+	}
+	return s, tenantCol, nil
+}
+
+// setFileColumn writes the file key (nil clears it) with the usual audit entry and webhook event,
+// and returns the previous key. The column is written even when it is hidden or not fillable:
+// file columns change only through these routes.
+func (c *TableCRUDController) setFileColumn(r *http.Request, tx *sql.Tx, s eloquent.Schema, tenantCol string, companyID int64, table, pk, column string, value any) (string, error) {
+	old, err := storedFileKey(r.Context(), tx, s, pk, tenantCol, companyID, column)
+	if err != nil {
+		return "", err
+	}
+	oldRow, err := auditRow(r.Context(), tx, s, pk, tenantCol, companyID)
+	if err != nil {
+		return "", err
+	}
+	fs := s
+	fs.Fillable = []string{column}
+	fs.Guarded = nil
+	if err := eloquent.UpdateByPKAndTenant(r.Context(), tx, fs, pk, tenantCol, companyID, map[string]any{column: value}); err != nil {
+		return "", err
+	}
+	newRow, err := auditRow(r.Context(), tx, s, pk, tenantCol, companyID)
+	if err != nil {
+		return "", err
+	}
+	return old, c.recordChange(r, tx, companyID, s, pk, audit.ActionUpdate, oldRow, newRow)
+}
+
+// storedFileKey returns the column's current value ("" when empty); NotFoundError when the row
+// does not exist for the tenant.
+func storedFileKey(ctx context.Context, tx *sql.Tx, s eloquent.Schema, pk, tenantCol string, companyID int64, column string) (string, error) {
+	fs := s
+	fs.Hidden = nil
+	row, err := eloquent.FindByPKAndTenant(ctx, tx, fs, pk, tenantCol, companyID)
+	if err != nil {
+		return "", err
+	}
+	if v, ok := row[column]; ok && v != nil {
+		return strings.TrimSpace(fmt.Sprint(v)), nil
+	}
+	return "", nil
+}
+
+// fileKeyPrefix is {tenant}/{table}/{pk}/{column}/; global tables use "global" as the tenant.
+func fileKeyPrefix(s eloquent.Schema, companyID int64, table, pk, column string) string {
+	tenant := fmt.Sprint(companyID)
+	if s.Global {
+		tenant = "global"
+	}
+	seg := fileKeySegRE.ReplaceAllString(pk, "_")
+	if strings.Trim(seg, ".") == "" {
+		seg = "_" + seg
+	}
+	return tenant + "/" + table + "/" + seg + "/" + column + "/"
+}
+
+// detectContentType sniffs the upload. The client's declared type is only trusted when the
+// sniffer returns a generic container type that the declared type is a refinement of
+// (an .xlsx sniffs as a zip, a .csv as text).
+func detectContentType(head []byte, declared string) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	declared, _, _ = mime.ParseMediaType(declared)
+	switch {
+	case declared == "":
+	case sniffed == "application/octet-stream":
+		return declared
+	case sniffed == "application/zip" && strings.HasPrefix(declared, "application/vnd.openxmlformats-officedocument."):
+		return declared
+	case sniffed == "text/plain" && strings.HasPrefix(declared, "text/"):
+		return declared
+	}
+	return sniffed
+}
+
+func fileExt(contentType string) string {
+	if ext, ok := fileExts[contentType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+func downloadContentType(key string) string {
+	ext := strings.ToLower(path.Ext(key))
+	for ct, e := range fileExts {
+		if e == ext {
+			return ct
+		}
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// removeFile deletes a replaced or orphaned object; failures only leave garbage behind.
+func (c *TableCRUDController) removeFile(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.files.Delete(ctx, key); err != nil {
+		log.Printf("crud: delete file %s: %v", key, err)
+	}
+}
+
+var errFileNotSet = errors.New("file not set")
+
+func writeFileError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errFileNotSet) {
+		errs := map[string]string{"file": "not set", "code": "not_found"}
+		if rid := shared.RequestIDFromContext(r.Context()); rid != "" {
+			errs["request_id"] = rid
+		}
+		shared.WriteError(w, http.StatusNotFound, "Not found.", errs)
+		return
+	}
+	writeDomainError(w, r, err)
+}
@@ -0,0 +1,43 @@
+package crudcontroller
+
+import (
+	"testing"
+
+	"mylab-api-go/internal/database/eloquent"
+)
+
+func TestDetectContentType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	zip := []byte("PK\x03\x04\x14\x00\x06\x00")
+	xlsx := "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	for _, tc := range []struct {
+		head     []byte
+		declared string
+		want     string
+	}{
+		{png, "application/pdf", "image/png"}, // declared type never overrides a sniffed one
+		{zip, xlsx, xlsx},                     // OOXML refines zip
+		{zip, "image/png", "application/zip"},
+		{[]byte("a,b\n1,2\n"), "text/csv", "text/csv"}, // csv refines text
+		{[]byte("a,b\n1,2\n"), "image/png", "text/plain"},
+		{[]byte{0, 1, 2, 3}, "application/x-custom", "application/x-custom"},
+	} {
+		if got := detectContentType(tc.head, tc.declared); got != tc.want {
+			t.Errorf("detectContentType(%q, %q) = %q, want %q", tc.head, tc.declared, got, tc.want)
+		}
+	}
+}
+
+func TestFileKeyPrefix(t *testing.T) {
+	s := eloquent.Schema{Table: "pasien"}
+	if got := fileKeyPrefix(s, 12, "pasien", "P/001 x", "gambar"); got != "12/pasien/P_001_x/gambar/" {
+		t.Fatalf("prefix %q", got)
+	}
+	if got := fileKeyPrefix(s, 12, "pasien", "..", "gambar"); got != "12/pasien/_../gambar/" {
+		t.Fatalf("prefix %q", got)
+	}
+	s.Global = true
+	if got := fileKeyPrefix(s, 12, "dokter", "D1", "foto"); got != "global/dokter/D1/foto/" {
+		t.Fatalf("prefix %q", got)
+	}
+}
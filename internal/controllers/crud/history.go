@@ -0,0 +1,289 @@
+package crudcontroller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mylab-api-go/internal/audit"
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/history"
+	"mylab-api-go/internal/routes/auth"
+	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/schema"
+)
+
+// Row history for tables declared versioned=true (see history package):
+//
+// - GET  /v1/crud/{table}/{pk}/history?page=&per_page=   versions, newest first
+// - GET  /v1/crud/{table}/{pk}?as_of=<RFC3339|YYYY-MM-DD>  the row as it was at that time
+// - POST /v1/crud/{table}/{pk}/revert {"version": n}      restore a version through the update path
+
+// versionedSchema loads the table schema and checks that history is available for it.
+func (c *TableCRUDController) versionedSchema(r *http.Request, tx *sql.Tx, table string) (eloquent.Schema, string, error) {
+	s, err := schema.LoadSchema(r.Context(), tx, table)
+	if err != nil {
+		return eloquent.Schema{}, "", err
+	}
+	tenantCol, verr := resolveTenantColumn(s)
+	if verr != nil {
+		return eloquent.Schema{}, "", verr
+	}
+	if !s.Versioned {
+		return eloquent.Schema{}, "", &eloquent.ValidationError{Errors: map[string]string{"history": "table is not versioned (versioned=true in its schema file)"}}
+	}
+	if c.history == nil {
+		return eloquent.Schema{}, "", &eloquent.ValidationError{Errors: map[string]string{"history": "not available (requires postgres)"}}
+	}
+	return s, tenantCol, nil
+}
+
+// versionScope is the tenant a row's versions are kept under (0 for global tables).
+func versionScope(s eloquent.Schema, companyID int64) int64 {
+	if s.Global {
+		return 0
+	}
+	return companyID
+}
+
+func (c *TableCRUDController) handleHistory(w http.ResponseWriter, r *http.Request, companyID int64, table, pk string) {
+	qs := r.URL.Query()
+	paging := map[string]int{}
+	for _, key := range []string{"page", "per_page"} {
+		v := strings.TrimSpace(qs.Get(key))
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeDomainError(w, r, &eloquent.ValidationError{Errors: map[string]string{key: "must be a positive integer"}})
+			return
+		}
+		paging[key] = n
+	}
+
+	res, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (*history.ListResult, error) {
+		s, tenantCol, err := c.versionedSchema(r, tx, table)
+		if err != nil {
+			return nil, err
+		}
+		res, err := c.history.List(r.Context(), tx, versionScope(s, companyID), table, pk, paging["page"], paging["per_page"])
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Rows) == 0 && res.Page == 1 {
+			// No versions: only say so for a row the caller can see.
+			if _, err := eloquent.FindByPKAndTenant(r.Context(), tx, s, pk, tenantCol, companyID); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	})
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	shared.WriteJSON(w, http.StatusOK, map[string]any{
+		"ok":      true,
+		"message": "OK",
+		"data":    res.Rows,
+		"paging": map[string]any{
+			"page":     res.Page,
+			"per_page": res.PerPage,
+			"has_more": res.HasMore,
+		},
+	})
+}
+
+// handleGetAsOf answers GET /v1/crud/{table}/{pk}?as_of=. A row with no versions has not
+// changed since versioning was enabled, so the live row is returned (unless its created_at
+// is later than as_of).
+func (c *TableCRUDController) handleGetAsOf(w http.ResponseWriter, r *http.Request, companyID int64, table, pk, raw string) {
+	asOf, err := parseAsOf(raw)
+	if err != nil {
+		writeDomainError(w, r, &eloquent.ValidationError{Errors: map[string]string{"as_of": "invalid time (use RFC3339 or YYYY-MM-DD)"}})
+		return
+	}
+	if strings.TrimSpace(r.URL.Query().Get("with")) != "" {
+		writeDomainError(w, r, &eloquent.ValidationError{Errors: map[string]string{"with": "not supported with as_of"}})
+		return
+	}
+
+	type result struct {
+		data    any
+		version *history.Version
+	}
+	res, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (result, error) {
+		s, tenantCol, err := c.versionedSchema(r, tx, table)
+		if err != nil {
+			return result{}, err
+		}
+		v, versioned, err := c.history.AsOf(r.Context(), tx, versionScope(s, companyID), table, pk, asOf)
+		if err != nil {
+			return result{}, err
+		}
+		notFound := &eloquent.NotFoundError{Table: table, PK: pk}
+		if versioned {
+			if v == nil || v.Action == history.ActionDelete {
+				return result{}, notFound
+			}
+			return result{data: v.Data, version: v}, nil
+		}
+		row, err := auditRow(r.Context(), tx, s, pk, tenantCol, companyID)
+		if err != nil {
+			return result{}, err
+		}
+		if created, ok := rowTime(row["created_at"]); s.Timestamps && ok && created.After(asOf) {
+			return result{}, notFound
+		}
+		return result{data: row}, nil
+	})
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	var version any
+	if res.version != nil {
+		version = map[string]any{"version": res.version.Version, "action": res.version.Action, "valid_from": res.version.ValidFrom}
+	}
+	shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "OK", "data": res.data, "as_of": asOf.UTC(), "version": version})
+}
+
+// parseAsOf accepts RFC3339 or a date; a date means the end of that day (UTC), like the
+// audit log's to= filter.
+func parseAsOf(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+func rowTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		if parsed, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (c *TableCRUDController) handleRevert(w http.ResponseWriter, r *http.Request, companyID int64, table, pk string) {
+	var body struct {
+		Version int64 `json:"version"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"body": "invalid JSON"})
+		return
+	}
+	if body.Version <= 0 {
+		writeDomainError(w, r, &eloquent.ValidationError{Errors: map[string]string{"version": "required (a version number from /history)"}})
+		return
+	}
+
+	version, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (int64, error) {
+		s, tenantCol, err := c.versionedSchema(r, tx, table)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkWritable(r, s); err != nil {
+			return 0, err
+		}
+		scope := versionScope(s, companyID)
+		v, err := c.history.Get(r.Context(), tx, scope, table, pk, body.Version)
+		if err != nil {
+			return 0, err
+		}
+		if v == nil {
+			return 0, &eloquent.ValidationError{Errors: map[string]string{"version": "unknown version"}}
+		}
+		payload, err := revertPayload(s, tenantCol, v.Data)
+		if err != nil {
+			return 0, err
+		}
+
+		oldRow, err := auditRow(r.Context(), tx, s, pk, tenantCol, companyID)
+		if err != nil {
+			return 0, err
+		}
+		if err := eloquent.UpdateByPKAndTenant(r.Context(), tx, s, pk, tenantCol, companyID, withTenant(payload, tenantCol, companyID)); err != nil {
+			return 0, err
+		}
+		newRow, err := auditRow(r.Context(), tx, s, pk, tenantCol, companyID)
+		if err != nil {
+			return 0, err
+		}
+		if err := c.auditAndNotify(r, tx, companyID, table, pk, audit.ActionUpdate, oldRow, newRow); err != nil {
+			return 0, err
+		}
+		return c.recordVersion(r, tx, companyID, s, pk, history.ActionRevert, body.Version, oldRow, newRow)
+	})
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "Reverted.", "table": table, "pk": pk, "version": version, "reverted_from": body.Version})
+}
+
+// revertPayload turns a stored snapshot into an update payload. Keys the server owns (primary
+// key, tenant column, timestamps) are dropped; the update path ignores non-fillable columns and
+// validates the rest like any other update.
+func revertPayload(s eloquent.Schema, tenantCol string, data json.RawMessage) (map[string]any, error) {
+	var payload map[string]any
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("history: snapshot: %w", err)
+	}
+	if payload == nil {
+		return nil, errors.New("history: empty snapshot")
+	}
+	delete(payload, s.PrimaryKey)
+	if tenantCol != "" {
+		delete(payload, tenantCol)
+	}
+	if s.Timestamps {
+		delete(payload, "created_at")
+		delete(payload, "updated_at")
+	}
+	return payload, nil
+}
+
+// recordVersion stores the row snapshot of a change on versioned tables (no-op otherwise).
+// revertedFrom > 0 marks a revert to that version. Without a history store the change is
+// rejected rather than written unversioned.
+func (c *TableCRUDController) recordVersion(r *http.Request, tx *sql.Tx, companyID int64, s eloquent.Schema, pk any, action string, revertedFrom int64, oldRow, newRow map[string]any) (int64, error) {
+	if !s.Versioned {
+		return 0, nil
+	}
+	if c.history == nil {
+		return 0, &eloquent.ValidationError{Errors: map[string]string{"history": "not available (requires postgres)"}}
+	}
+	authInfo, _ := auth.AuthInfoFromContext(r.Context())
+	return c.history.Record(r.Context(), tx, history.Entry{
+		Table:        s.Table,
+		PK:           fmt.Sprint(pk),
+		Scope:        versionScope(s, companyID),
+		Action:       action,
+		UserID:       authInfo.UserID,
+		RequestID:    shared.RequestIDFromContext(r.Context()),
+		RevertedFrom: revertedFrom,
+		Old:          oldRow,
+		New:          newRow,
+	})
+}
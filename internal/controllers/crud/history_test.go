@@ -0,0 +1,54 @@
+package crudcontroller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"mylab-api-go/internal/database/eloquent"
+)
+
+func TestRevertPayload(t *testing.T) {
+	s := eloquent.Schema{Table: "pasien", PrimaryKey: "kd_ps", Timestamps: true}
+	raw := json.RawMessage(`{"kd_ps":"P1","company_id":12,"nama_ps":"Budi","berat":62.5,"created_at":"2026-01-02T03:04:05Z","updated_at":"2026-02-01T00:00:00Z"}`)
+	got, err := revertPayload(s, "company_id", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"nama_ps": "Budi", "berat": json.Number("62.5")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("payload = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseAsOf(t *testing.T) {
+	got, err := parseAsOf("2026-03-18")
+	if err != nil || !got.Equal(time.Date(2026, 3, 18, 23, 59, 59, 999999999, time.UTC)) {
+		t.Fatalf("date: %v %v", got, err)
+	}
+	got, err = parseAsOf("2026-03-18T10:00:00+08:00")
+	if err != nil || !got.Equal(time.Date(2026, 3, 18, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("rfc3339: %v %v", got, err)
+	}
+	if _, err := parseAsOf("last week"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestRecordVersionWithoutStore(t *testing.T) {
+	c := &TableCRUDController{}
+	r := httptest.NewRequest("POST", "/v1/crud/pasien", nil)
+	row := map[string]any{"kd_ps": "P1"}
+
+	// Unversioned tables do not need the store.
+	if _, err := c.recordVersion(r, nil, 12, eloquent.Schema{Table: "pasien"}, "P1", "create", 0, nil, row); err != nil {
+		t.Fatalf("unversioned: %v", err)
+	}
+	// A versioned table must not be written without its history.
+	_, err := c.recordVersion(r, nil, 12, eloquent.Schema{Table: "pasien", Versioned: true}, "P1", "create", 0, nil, row)
+	if ve, ok := err.(*eloquent.ValidationError); !ok || ve.Errors["history"] == "" {
+		t.Fatalf("versioned: err = %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package crudcontroller
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mylab-api-go/internal/audit"
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/schema"
+	"mylab-api-go/internal/spreadsheet"
+)
+
+// Spreadsheet import:
+// - POST /v1/crud/{table}/import  (multipart: file, mapping, dry_run, sheet; ?report=csv)
+//
+// The first row is the header. Each header maps to a column through the optional mapping
+// ({"Nama Pasien": "nama"}), else by name (case-insensitive, spaces as "_", aliases included);
+// anything else is ignored and reported. Every row goes through the same fillable filter,
+// casts, rules and tenant stamping as POST /v1/crud/{table}. The import is all-or-nothing:
+// rows are only inserted (in batches, one transaction) when every row is valid.
+
+const (
+	importMaxBytes       = 20 << 20
+	defaultImportMaxRows = 5000
+)
+
+type importRowError struct {
+	Row    int               `json:"row"` // spreadsheet row number (header = 1)
+	Errors map[string]string `json:"errors"`
+}
+
+type importReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Rows     int               `json:"rows"`
+	Valid    int               `json:"valid"`
+	Invalid  int               `json:"invalid"`
+	Skipped  int               `json:"skipped"` // blank rows
+	Inserted int               `json:"inserted"`
+	Columns  map[string]string `json:"columns"` // header -> column
+	Ignored  []string          `json:"ignored_headers,omitempty"`
+	Errors   []importRowError  `json:"errors"`
+}
+
+func importMaxRows() int {
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("IMPORT_MAX_ROWS"))); err == nil && n > 0 {
+		return n
+	}
+	return defaultImportMaxRows
+}
+
+func (c *TableCRUDController) handleImport(w http.ResponseWriter, r *http.Request, companyID int64, table string) {
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxBytes+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			shared.WriteError(w, http.StatusRequestEntityTooLarge, "File too large.", map[string]string{"file": fmt.Sprintf("must not exceed %d bytes", importMaxBytes), "code": "file_too_large"})
+			return
+		}
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"body": "invalid multipart form"})
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	f, fh, err := r.FormFile("file")
+	if err != nil {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"file": "required"})
+		return
+	}
+	defer f.Close()
+
+	dryRun, err := parseFormBool(r.FormValue("dry_run"))
+	if err != nil {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"dry_run": "must be a boolean"})
+		return
+	}
+	mapping := map[string]string{}
+	if raw := strings.TrimSpace(r.FormValue("mapping")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"mapping": `must be a JSON object {"header": "column"}`})
+			return
+		}
+	}
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	format, err := spreadsheet.DetectFormat(fh.Filename, head[:n])
+	if err != nil {
+		shared.WriteError(w, http.StatusUnsupportedMediaType, "Unsupported file type.", map[string]string{"file": err.Error(), "code": "unsupported_file_type"})
+		return
+	}
+	maxRows := importMaxRows()
+	rows, err := spreadsheet.Read(f, fh.Size, format, spreadsheet.ReadOptions{Sheet: r.FormValue("sheet"), MaxRows: maxRows + 1})
+	if errors.Is(err, spreadsheet.ErrTooManyRows) {
+		shared.WriteError(w, http.StatusRequestEntityTooLarge, "Too many rows.", map[string]string{"file": fmt.Sprintf("must not exceed %d data rows", maxRows), "code": "too_many_rows"})
+		return
+	}
+	if err != nil {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"file": err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"file": "empty (expected a header row)"})
+		return
+	}
+
+	report, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (*importReport, error) {
+		s, err := schema.LoadSchema(r.Context(), tx, table)
+		if err != nil {
+			return nil, err
+		}
+		tenantCol, verr := resolveTenantColumn(s)
+		if verr != nil {
+			return nil, verr
+		}
+		if err := checkWritable(r, s); err != nil {
+			return nil, err
+		}
+		targets, report, err := importColumns(s, rows[0], mapping)
+		if err != nil {
+			return nil, err
+		}
+		report.DryRun = dryRun
+
+		seen := eloquent.UniqueSeen{}
+		prepared := []map[string]any{}
+		for i, cells := range rows[1:] {
+			payload := map[string]any{}
+			for j, cell := range cells {
+				if j < len(targets) && targets[j] != "" && strings.TrimSpace(cell) != "" {
+					payload[targets[j]] = cell
+				}
+			}
+			if len(payload) == 0 {
+				report.Skipped++
+				continue
+			}
+			report.Rows++
+			data, err := eloquent.PrepareInsert(r.Context(), tx, s, withTenant(payload, tenantCol, companyID), seen)
+			var ve *eloquent.ValidationError
+			if errors.As(err, &ve) {
+				report.Invalid++
+				report.Errors = append(report.Errors, importRowError{Row: i + 2, Errors: ve.Errors})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			report.Valid++
+			prepared = append(prepared, data)
+		}
+		if dryRun || report.Invalid > 0 {
+			return report, nil
+		}
+
+		pks, err := eloquent.InsertMany(r.Context(), tx, s, prepared)
+		if err != nil {
+			return nil, err
+		}
+		for _, pk := range pks {
+			newRow, err := auditRow(r.Context(), tx, s, pk, tenantCol, companyID)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.recordChange(r, tx, companyID, s, pk, audit.ActionCreate, nil, newRow); err != nil {
+				return nil, err
+			}
+		}
+		report.Inserted = len(pks)
+		return report, nil
+	})
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	status, message := http.StatusOK, "Imported."
+	switch {
+	case report.Invalid > 0 && !dryRun:
+		status, message = http.StatusUnprocessableEntity, "Validation failed."
+	case dryRun:
+		message = "Dry run."
+	}
+	if strings.EqualFold(r.URL.Query().Get("report"), "csv") || strings.EqualFold(r.FormValue("report"), "csv") {
+		writeImportReportCSV(w, status, table, report)
+		return
+	}
+	body := map[string]any{"ok": status == http.StatusOK && report.Invalid == 0, "message": message, "table": table, "data": report}
+	if status != http.StatusOK {
+		body["errors"] = map[string]string{"rows": fmt.Sprintf("%d of %d rows are invalid; nothing was imported", report.Invalid, report.Rows), "code": "validation_error"}
+	}
+	shared.WriteJSON(w, status, body)
+}
+
+// importColumns resolves each header to a payload key (column or alias); "" = ignored.
+func importColumns(s eloquent.Schema, header []string, mapping map[string]string) ([]string, *importReport, error) {
+	known := map[string]bool{}
+	for _, col := range s.Columns {
+		known[col] = true
+	}
+	for alias := range s.Aliases {
+		known[alias] = true
+	}
+	fillable := map[string]bool{}
+	for _, col := range s.FillableColumns() {
+		fillable[col] = true
+	}
+	column := func(key string) string {
+		if col, ok := s.Aliases[key]; ok {
+			return col
+		}
+		return key
+	}
+
+	errs := map[string]string{}
+	for h, target := range mapping {
+		if target != "" && !known[target] {
+			errs["mapping."+h] = fmt.Sprintf("unknown column %q", target)
+		}
+	}
+
+	report := &importReport{Columns: map[string]string{}, Errors: []importRowError{}}
+	targets := make([]string, len(header))
+	used := map[string]string{}
+	for i, h := range header {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		target, mapped := mapping[h]
+		if !mapped {
+			target = strings.ToLower(strings.Join(strings.Fields(h), "_"))
+			if !known[target] {
+				target = ""
+			}
+		}
+		if target == "" || !fillable[column(target)] {
+			report.Ignored = append(report.Ignored, h)
+			continue
+		}
+		if prev, dup := used[column(target)]; dup {
+			errs["header."+h] = fmt.Sprintf("maps to %s, already mapped from %q", column(target), prev)
+			continue
+		}
+		used[column(target)] = h
+		targets[i] = target
+		report.Columns[h] = column(target)
+	}
+	if len(errs) > 0 {
+		return nil, nil, &eloquent.ValidationError{Errors: errs}
+	}
+	if len(report.Columns) == 0 {
+		return nil, nil, &eloquent.ValidationError{Errors: map[string]string{"header": "no header matches a fillable column (send a mapping)"}}
+	}
+	return targets, report, nil
+}
+
+// writeImportReportCSV renders one line per row error, in row then column order. It goes
+// through the export writer: headers come from the uploaded file and are escaped like exports.
+func writeImportReportCSV(w http.ResponseWriter, status int, table string, report *importReport) {
+	headers := map[string]string{}
+	for h, col := range report.Columns {
+		headers[col] = h
+	}
+	var buf bytes.Buffer
+	cw, _ := spreadsheet.NewWriter(&buf, spreadsheet.CSV, spreadsheet.WriteOptions{})
+	_ = cw.Header([]spreadsheet.Column{{Title: "row", Kind: spreadsheet.KindNumber}, {Title: "column"}, {Title: "header"}, {Title: "error"}})
+	for _, re := range report.Errors {
+		cols := make([]string, 0, len(re.Errors))
+		for col := range re.Errors {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+		for _, col := range cols {
+			_ = cw.Row([]any{int64(re.Row), col, headers[col], re.Errors[col]})
+		}
+	}
+	_ = cw.Close()
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-import-report.csv"`, table))
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func parseFormBool(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "0", "false", "no":
+		return false, nil
+	case "1", "true", "yes", "on":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", v)
+}
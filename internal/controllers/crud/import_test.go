@@ -0,0 +1,75 @@
+package crudcontroller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"mylab-api-go/internal/database/eloquent"
+)
+
+func TestImportColumns(t *testing.T) {
+	s := eloquent.Schema{
+		Table:      "pasien",
+		PrimaryKey: "id",
+		Columns:    []string{"id", "company_id", "nama", "tgl_lahir", "hp", "saldo"},
+		Aliases:    map[string]string{"telepon": "hp"},
+		Guarded:    []string{"saldo"},
+	}
+
+	targets, report, err := importColumns(s,
+		[]string{"Nama Pasien", " TGL LAHIR ", "Telepon", "Saldo", "Catatan", ""},
+		map[string]string{"Nama Pasien": "nama"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"nama", "tgl_lahir", "telepon", "", "", ""}; !reflect.DeepEqual(targets, want) {
+		t.Fatalf("targets = %q, want %q", targets, want)
+	}
+	if want := map[string]string{"Nama Pasien": "nama", "TGL LAHIR": "tgl_lahir", "Telepon": "hp"}; !reflect.DeepEqual(report.Columns, want) {
+		t.Fatalf("columns = %v, want %v", report.Columns, want)
+	}
+	if want := []string{"Saldo", "Catatan"}; !reflect.DeepEqual(report.Ignored, want) {
+		t.Fatalf("ignored = %q, want %q", report.Ignored, want)
+	}
+
+	for name, tc := range map[string]struct {
+		header  []string
+		mapping map[string]string
+		key     string
+	}{
+		"unknown target": {[]string{"Nama"}, map[string]string{"Nama": "name"}, "mapping.Nama"},
+		"duplicate":      {[]string{"hp", "Telepon"}, nil, "header.Telepon"},
+		"nothing mapped": {[]string{"Catatan"}, nil, "header"},
+	} {
+		_, _, err := importColumns(s, tc.header, tc.mapping)
+		var ve *eloquent.ValidationError
+		if !errors.As(err, &ve) || ve.Errors[tc.key] == "" {
+			t.Errorf("%s: err = %v, want errors[%s]", name, err, tc.key)
+		}
+	}
+}
+
+func TestWriteImportReportCSV(t *testing.T) {
+	report := &importReport{
+		Columns: map[string]string{"=HYPERLINK(\"http://x\")": "nama_ps", "NIK": "nik"},
+		Errors: []importRowError{
+			{Row: 7, Errors: map[string]string{"nama_ps": "required", "nik": "duplicates an earlier row"}},
+		},
+	}
+	w := httptest.NewRecorder()
+	writeImportReportCSV(w, http.StatusUnprocessableEntity, "pasien", report)
+
+	if w.Code != http.StatusUnprocessableEntity || w.Header().Get("Content-Disposition") != `attachment; filename="pasien-import-report.csv"` {
+		t.Fatalf("status %d, headers %v", w.Code, w.Header())
+	}
+	// Headers come from the uploaded file, so formula-like text is escaped as in exports.
+	want := "\ufeffrow,column,header,error\r\n" +
+		"7,nama_ps,\"'=HYPERLINK(\"\"http://x\"\")\",required\r\n" +
+		"7,nik,NIK,duplicates an earlier row\r\n"
+	if got := w.Body.String(); got != want {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,322 @@
+package crudcontroller
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"mylab-api-go/internal/audit"
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/schema"
+)
+
+// Nested writes: a create/update body may carry child rows under the name of a hasMany or
+// hasOne relation of the table, e.g. POST /v1/crud/orders {"no_lab": "...", "details": [...]}.
+// The parent and its children are written in one transaction (one level deep):
+//
+// - create: every child is inserted with its foreign key set to the parent's local key
+// - update: children are synced with the request: items whose pk is an existing child are
+//   updated, items without pk are inserted, existing children missing from the list are
+//   deleted. A relation key that is absent leaves its children untouched.
+//
+// Children go through the same checks, audit entries, webhook events and row versions as
+// their own CRUD routes, always scoped to the caller's tenant.
+
+// nestedWrite is the body of one relation key.
+type nestedWrite struct {
+	rel   eloquent.Relation
+	items []map[string]any // hasOne: at most one; none on update deletes the child
+}
+
+// nestedResult counts what a nested write did to one relation.
+type nestedResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+// splitNested removes relation keys from payload and returns them in relation-name order.
+func splitNested(s eloquent.Schema, payload map[string]any) ([]nestedWrite, error) {
+	names := []string{}
+	for name := range s.Relations {
+		if _, ok := payload[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	errs := map[string]string{}
+	out := []nestedWrite{}
+	for _, name := range names {
+		rel := s.Relations[name]
+		raw := payload[name]
+		delete(payload, name)
+
+		nw := nestedWrite{rel: rel, items: []map[string]any{}}
+		switch rel.Type {
+		case eloquent.HasMany:
+			list, ok := raw.([]any)
+			if !ok {
+				errs[name] = "must be an array of objects"
+				continue
+			}
+			for i, item := range list {
+				obj, ok := item.(map[string]any)
+				if !ok {
+					errs[fmt.Sprintf("%s[%d]", name, i)] = "must be an object"
+					continue
+				}
+				nw.items = append(nw.items, obj)
+			}
+		case eloquent.HasOne:
+			if raw != nil {
+				obj, ok := raw.(map[string]any)
+				if !ok {
+					errs[name] = "must be an object or null"
+					continue
+				}
+				nw.items = append(nw.items, obj)
+			}
+		default:
+			errs[name] = "belongsTo relations cannot be written through this table"
+			continue
+		}
+		out = append(out, nw)
+	}
+	if len(errs) > 0 {
+		return nil, &eloquent.ValidationError{Errors: errs}
+	}
+	return out, nil
+}
+
+// nestedPlan is the set of child writes for one relation. Indexes point into the request items.
+type nestedPlan struct {
+	inserts []int
+	updates []nestedUpdate
+	deletes []any // pks of existing children not in the request
+}
+
+type nestedUpdate struct {
+	index int
+	pk    any
+}
+
+// planNestedSync matches request items against the existing children (by primary key).
+// An item whose pk is not an existing child is inserted when the pk is client-assigned
+// (fillable) and rejected otherwise. A hasOne item without pk replaces the existing child.
+func planNestedSync(nw nestedWrite, pkCol string, pkFillable bool, existing []any) (nestedPlan, error) {
+	byKey := make(map[string]any, len(existing))
+	for _, pk := range existing {
+		byKey[fmt.Sprint(pk)] = pk
+	}
+
+	plan := nestedPlan{}
+	kept := map[string]bool{}
+	errs := map[string]string{}
+	for i, item := range nw.items {
+		key := nestedKey(nw.rel, i)
+		v, hasPK := item[pkCol]
+		if hasPK && v != nil && fmt.Sprint(v) != "" {
+			k := fmt.Sprint(v)
+			switch {
+			case kept[k]:
+				errs[key+"."+pkCol] = "duplicate"
+			case byKey[k] != nil:
+				kept[k] = true
+				plan.updates = append(plan.updates, nestedUpdate{index: i, pk: byKey[k]})
+			case pkFillable:
+				kept[k] = true
+				plan.inserts = append(plan.inserts, i)
+			default:
+				errs[key+"."+pkCol] = "not a child of this record (omit it to add a new row)"
+			}
+			continue
+		}
+		if nw.rel.Type == eloquent.HasOne && len(existing) > 0 {
+			k := fmt.Sprint(existing[0])
+			kept[k] = true
+			plan.updates = append(plan.updates, nestedUpdate{index: i, pk: existing[0]})
+			continue
+		}
+		plan.inserts = append(plan.inserts, i)
+	}
+	if len(errs) > 0 {
+		return nestedPlan{}, &eloquent.ValidationError{Errors: errs}
+	}
+	for _, pk := range existing {
+		if !kept[fmt.Sprint(pk)] {
+			plan.deletes = append(plan.deletes, pk)
+		}
+	}
+	return plan, nil
+}
+
+// nestedKey is the error-key prefix of item i: details[2] (hasMany) or profile (hasOne).
+func nestedKey(rel eloquent.Relation, i int) string {
+	if rel.Type == eloquent.HasOne {
+		return rel.Name
+	}
+	return fmt.Sprintf("%s[%d]", rel.Name, i)
+}
+
+// prefixNestedError moves a child's validation keys under its item key.
+func prefixNestedError(err error, prefix string) error {
+	var ve *eloquent.ValidationError
+	if !errors.As(err, &ve) {
+		return err
+	}
+	out := make(map[string]string, len(ve.Errors))
+	for k, v := range ve.Errors {
+		out[prefix+"."+k] = v
+	}
+	return &eloquent.ValidationError{Errors: out}
+}
+
+// writeNested applies the nested writes of a parent row inside tx. parentRow is the parent as
+// stored after its own write; create is true when the parent was just inserted (no children
+// exist yet, so nothing is matched or deleted).
+func (c *TableCRUDController) writeNested(r *http.Request, tx *sql.Tx, companyID int64, writes []nestedWrite, parentRow map[string]any, create bool) (map[string]nestedResult, error) {
+	ctx := r.Context()
+	out := make(map[string]nestedResult, len(writes))
+	for _, nw := range writes {
+		rel := nw.rel
+		if !c.Allows(rel.Table) {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{rel.Name: "relation table not allowed: " + rel.Table}}
+		}
+		cs, err := schema.LoadSchema(ctx, tx, rel.Table)
+		if err != nil {
+			return nil, err
+		}
+		tenantCol, err := resolveTenantColumn(cs)
+		if err != nil {
+			return nil, prefixNestedError(err, rel.Name)
+		}
+		if err := checkWritable(r, cs); err != nil {
+			return nil, err
+		}
+		if !cs.HasColumn(rel.ForeignKey) {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{rel.Name: "unknown foreign key"}}
+		}
+		parentKey := parentRow[rel.LocalKey]
+		if parentKey == nil {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{rel.Name: fmt.Sprintf("parent %s is empty", rel.LocalKey)}}
+		}
+		for i, item := range nw.items {
+			for name := range cs.Relations {
+				if _, ok := item[name]; ok {
+					return nil, &eloquent.ValidationError{Errors: map[string]string{nestedKey(rel, i) + "." + name: "nested writes are one level deep"}}
+				}
+			}
+		}
+
+		var existing []any
+		if !create {
+			if existing, err = childKeys(r, tx, companyID, cs, rel.ForeignKey, parentKey); err != nil {
+				return nil, err
+			}
+		}
+		pkFillable := false
+		for _, col := range cs.FillableColumns() {
+			if col == cs.PrimaryKey {
+				pkFillable = true
+			}
+		}
+		plan, err := planNestedSync(nw, cs.PrimaryKey, pkFillable, existing)
+		if err != nil {
+			return nil, err
+		}
+
+		childPayload := func(i int) map[string]any {
+			data := make(map[string]any, len(nw.items[i])+2)
+			for k, v := range nw.items[i] {
+				data[k] = v
+			}
+			data[rel.ForeignKey] = parentKey
+			return withTenant(data, tenantCol, companyID)
+		}
+		res := nestedResult{}
+		// Deletes first so a replacement row can reuse a unique value of a removed one.
+		for _, pk := range plan.deletes {
+			oldRow, err := auditRow(ctx, tx, cs, pk, tenantCol, companyID)
+			if err != nil {
+				return nil, err
+			}
+			if err := eloquent.DeleteByPKAndTenant(ctx, tx, cs, pk, tenantCol, companyID); err != nil {
+				return nil, err
+			}
+			if err := c.recordChange(r, tx, companyID, cs, pk, audit.ActionDelete, oldRow, nil); err != nil {
+				return nil, err
+			}
+			res.Deleted++
+		}
+		for _, u := range plan.updates {
+			data := childPayload(u.index)
+			delete(data, cs.PrimaryKey)
+			oldRow, err := auditRow(ctx, tx, cs, u.pk, tenantCol, companyID)
+			if err != nil {
+				return nil, err
+			}
+			if err := eloquent.UpdateByPKAndTenant(ctx, tx, cs, u.pk, tenantCol, companyID, data); err != nil {
+				return nil, prefixNestedError(err, nestedKey(rel, u.index))
+			}
+			newRow, err := auditRow(ctx, tx, cs, u.pk, tenantCol, companyID)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.recordChange(r, tx, companyID, cs, u.pk, audit.ActionUpdate, oldRow, newRow); err != nil {
+				return nil, err
+			}
+			res.Updated++
+		}
+		for _, i := range plan.inserts {
+			pk, err := eloquent.Insert(ctx, tx, cs, childPayload(i))
+			if err != nil {
+				return nil, prefixNestedError(err, nestedKey(rel, i))
+			}
+			newRow, err := auditRow(ctx, tx, cs, pk, tenantCol, companyID)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.recordChange(r, tx, companyID, cs, pk, audit.ActionCreate, nil, newRow); err != nil {
+				return nil, err
+			}
+			res.Created++
+		}
+		out[rel.Name] = res
+	}
+	return out, nil
+}
+
+// childKeys returns the primary keys of the caller's children of one parent, in pk order.
+func childKeys(r *http.Request, tx *sql.Tx, companyID int64, cs eloquent.Schema, foreignKey string, parentKey any) ([]any, error) {
+	keys := []any{}
+	for page := 1; ; page++ {
+		res, err := eloquent.SelectPage(r.Context(), tx, cs, companyID, eloquent.SelectRequest{
+			Select:  []string{cs.PrimaryKey},
+			Where:   map[string]any{foreignKey: parentKey},
+			OrderBy: []eloquent.OrderBy{{Field: cs.PrimaryKey, Dir: "asc"}},
+			Page:    page,
+			PerPage: eloquent.MaxPerPage,
+			Count:   eloquent.CountNone,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range res.Rows {
+			keys = append(keys, row[cs.PrimaryKey])
+		}
+		if !res.HasMore {
+			return keys, nil
+		}
+	}
+}
+
+// nestedEnvelope adds the per-relation counts to a create/update response.
+func nestedEnvelope(body map[string]any, res map[string]nestedResult) map[string]any {
+	if len(res) > 0 {
+		body["relations"] = res
+	}
+	return body
+}
@@ -0,0 +1,97 @@
+package crudcontroller
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"mylab-api-go/internal/database/eloquent"
+)
+
+func nestedSchema() eloquent.Schema {
+	return eloquent.Schema{
+		Table:      "orders",
+		PrimaryKey: "id",
+		Columns:    []string{"id", "no_lab", "company_id"},
+		Relations: map[string]eloquent.Relation{
+			"details": {Name: "details", Type: eloquent.HasMany, LocalKey: "no_lab", Table: "order_details", ForeignKey: "no_lab"},
+			"invoice": {Name: "invoice", Type: eloquent.HasOne, LocalKey: "no_lab", Table: "invoices", ForeignKey: "no_lab"},
+			"pasien":  {Name: "pasien", Type: eloquent.BelongsTo, LocalKey: "kd_ps", Table: "pasien", ForeignKey: "kd_ps"},
+		},
+	}
+}
+
+func TestSplitNested(t *testing.T) {
+	payload := map[string]any{
+		"no_lab":  "LAB1",
+		"details": []any{map[string]any{"kd_tes": "A"}, map[string]any{"id": json.Number("7"), "kd_tes": "B"}},
+		"invoice": nil,
+	}
+	writes, err := splitNested(nestedSchema(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(payload, map[string]any{"no_lab": "LAB1"}) {
+		t.Fatalf("payload = %#v, relation keys must be removed", payload)
+	}
+	if len(writes) != 2 || writes[0].rel.Name != "details" || len(writes[0].items) != 2 || writes[1].rel.Name != "invoice" || len(writes[1].items) != 0 {
+		t.Fatalf("writes = %#v", writes)
+	}
+
+	for name, body := range map[string]map[string]any{
+		"details":    {"details": map[string]any{}},
+		"details[1]": {"details": []any{map[string]any{}, "x"}},
+		"invoice":    {"invoice": []any{}},
+		"pasien":     {"pasien": map[string]any{}},
+	} {
+		_, err := splitNested(nestedSchema(), body)
+		var ve *eloquent.ValidationError
+		if !errors.As(err, &ve) || ve.Errors[name] == "" {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestPlanNestedSync(t *testing.T) {
+	details := nestedSchema().Relations["details"]
+	nw := nestedWrite{rel: details, items: []map[string]any{
+		{"id": json.Number("2"), "kd_tes": "A"},
+		{"kd_tes": "B"},
+	}}
+	plan, err := planNestedSync(nw, "id", false, []any{int64(1), int64(2), int64(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := nestedPlan{
+		inserts: []int{1},
+		updates: []nestedUpdate{{index: 0, pk: int64(2)}},
+		deletes: []any{int64(1), int64(3)},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("plan = %#v, want %#v", plan, want)
+	}
+
+	// A pk that is not a child: rejected for server-assigned keys, inserted for client-assigned ones.
+	stranger := nestedWrite{rel: details, items: []map[string]any{{"id": json.Number("9")}}}
+	_, err = planNestedSync(stranger, "id", false, []any{int64(1)})
+	var ve *eloquent.ValidationError
+	if !errors.As(err, &ve) || ve.Errors["details[0].id"] == "" {
+		t.Fatalf("err = %v", err)
+	}
+	plan, err = planNestedSync(stranger, "id", true, []any{int64(1)})
+	if err != nil || !reflect.DeepEqual(plan.inserts, []int{0}) || !reflect.DeepEqual(plan.deletes, []any{int64(1)}) {
+		t.Fatalf("client-assigned: plan = %#v, err = %v", plan, err)
+	}
+
+	// hasOne without pk replaces the existing child; null (no items) deletes it.
+	invoice := nestedSchema().Relations["invoice"]
+	plan, err = planNestedSync(nestedWrite{rel: invoice, items: []map[string]any{{"total": json.Number("10")}}}, "id", false, []any{int64(5)})
+	if err != nil || !reflect.DeepEqual(plan.updates, []nestedUpdate{{index: 0, pk: int64(5)}}) || len(plan.deletes) != 0 {
+		t.Fatalf("hasOne: plan = %#v, err = %v", plan, err)
+	}
+	plan, err = planNestedSync(nestedWrite{rel: invoice, items: []map[string]any{}}, "id", false, []any{int64(5)})
+	if err != nil || !reflect.DeepEqual(plan.deletes, []any{int64(5)}) {
+		t.Fatalf("hasOne null: plan = %#v, err = %v", plan, err)
+	}
+}
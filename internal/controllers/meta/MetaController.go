@@ -0,0 +1,289 @@
+package metacontroller
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/routes/auth"
+	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/schema"
+)
+
+var tableNameRE = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// MetaController exposes the data dictionary used to generate admin forms.
+//
+// Routes:
+// - GET /v1/meta/tables          tables the CRUD policy allows
+// - GET /v1/meta/tables/{table}  resolved schema (columns, casts, fillable, PK, tenant, UI hints)
+//
+// Hidden columns are only listed when fillable (write-only form fields, e.g. password).
+type MetaController struct {
+	sqlDB  *sql.DB
+	allows func(table string) bool
+}
+
+// NewMetaController takes the CRUD controller's table policy (TableCRUDController.Allows).
+func NewMetaController(sqlDB *sql.DB, allows func(table string) bool) *MetaController {
+	return &MetaController{sqlDB: sqlDB, allows: allows}
+}
+
+type tableSummary struct {
+	Table         string `json:"table"`
+	Global        bool   `json:"global"`
+	SchemaFile    bool   `json:"schema_file"`
+	SchemaInvalid bool   `json:"schema_invalid,omitempty"`
+}
+
+type tableMeta struct {
+	Table        string            `json:"table"`
+	PrimaryKey   string            `json:"primary_key"`
+	TenantColumn string            `json:"tenant_column,omitempty"`
+	Global       bool              `json:"global"`
+	Writable     bool              `json:"writable"`
+	Timestamps   bool              `json:"timestamps"`
+	Versioned    bool              `json:"versioned"`
+	Aliases      map[string]string `json:"aliases,omitempty"`
+	Relations    []relationMeta    `json:"relations,omitempty"`
+	Columns      []columnMeta      `json:"columns"`
+}
+
+type relationMeta struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	LocalKey   string `json:"local_key"`
+	Table      string `json:"table"`
+	ForeignKey string `json:"foreign_key"`
+}
+
+type columnMeta struct {
+	Name       string             `json:"name"`
+	DataType   string             `json:"data_type,omitempty"`
+	Cast       string             `json:"cast,omitempty"`
+	Nullable   bool               `json:"nullable"`
+	MaxLength  int                `json:"max_length,omitempty"`
+	Precision  int                `json:"numeric_precision,omitempty"`
+	Scale      int                `json:"numeric_scale,omitempty"`
+	Default    string             `json:"default,omitempty"`
+	Enum       []string           `json:"enum,omitempty"`
+	PrimaryKey bool               `json:"primary_key,omitempty"`
+	Fillable   bool               `json:"fillable"`
+	Required   bool               `json:"required"`
+	Computed   bool               `json:"computed,omitempty"`
+	Generated  string             `json:"generated,omitempty"` // generate= pattern, filled when left empty
+	WriteOnly  bool               `json:"write_only,omitempty"`
+	Rules      []string           `json:"rules,omitempty"`
+	Aliases    []string           `json:"aliases,omitempty"`
+	UI         *schema.ColumnUI   `json:"ui,omitempty"`
+	File       *schema.FileColumn `json:"file,omitempty"` // files=: upload via /v1/crud/{table}/{pk}/files/{column}
+}
+
+func (c *MetaController) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/meta/tables" && !strings.HasPrefix(r.URL.Path, "/v1/meta/tables/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/meta/tables"), "/")
+	if strings.Contains(path, "/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if c.sqlDB == nil {
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", map[string]string{"database": "not configured"})
+		return
+	}
+	authInfo, ok := auth.AuthInfoFromContext(r.Context())
+	if !ok {
+		shared.WriteError(w, http.StatusUnauthorized, "Unauthorized.", nil)
+		return
+	}
+
+	if path == "" {
+		c.handleList(w, r)
+		return
+	}
+	table := strings.ToLower(path)
+	if !tableNameRE.MatchString(table) {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"table": "invalid name (allowed: a-z0-9_ only)"})
+		return
+	}
+	if !c.allows(table) {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"table": "not allowed"})
+		return
+	}
+
+	s, err := schema.LoadSchema(r.Context(), c.sqlDB, table)
+	if err == nil {
+		var d schema.Directives
+		d, _, err = schema.LoadDirectives(table)
+		if err == nil {
+			meta := describe(s, d)
+			meta.Writable = !s.Global || auth.IsSuperAdmin(authInfo)
+			shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "OK", "data": meta})
+			return
+		}
+	}
+
+	var (
+		ve *eloquent.ValidationError
+		fe *schema.FileError
+	)
+	switch {
+	case errors.As(err, &ve) && ve.Errors["table"] == "not found":
+		shared.WriteError(w, http.StatusNotFound, "Not found.", map[string]string{"table": "not found"})
+	case errors.As(err, &ve):
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", ve.Errors)
+	case errors.As(err, &fe):
+		log.Printf("meta: %v", err)
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", map[string]string{"code": "schema_invalid", "table": fe.Table})
+	default:
+		log.Printf("meta: %s: %v", table, err)
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", map[string]string{"code": "internal_error"})
+	}
+}
+
+func (c *MetaController) handleList(w http.ResponseWriter, r *http.Request) {
+	names, err := schema.ListTables(r.Context(), c.sqlDB)
+	if err != nil {
+		log.Printf("meta: %v", err)
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", map[string]string{"code": "internal_error"})
+		return
+	}
+	out := []tableSummary{}
+	for _, name := range names {
+		if !c.allows(name) {
+			continue
+		}
+		d, hasFile, err := schema.LoadDirectives(name)
+		var fe *schema.FileError
+		if errors.As(err, &fe) {
+			out = append(out, tableSummary{Table: name, SchemaFile: true, SchemaInvalid: true})
+			continue
+		}
+		out = append(out, tableSummary{Table: name, Global: d.Global, SchemaFile: hasFile})
+	}
+	shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "OK", "data": out})
+}
+
+// describe flattens a resolved schema into form metadata. Columns with a UI order come first
+// (ascending), then the rest in table order, then computed columns.
+func describe(s eloquent.Schema, d schema.Directives) tableMeta {
+	meta := tableMeta{
+		Table:        s.Table,
+		PrimaryKey:   s.PrimaryKey,
+		TenantColumn: s.TenantColumnName(),
+		Global:       s.Global,
+		Timestamps:   s.Timestamps,
+		Versioned:    s.Versioned,
+		Columns:      []columnMeta{},
+	}
+	for _, name := range sortedKeys(s.Relations) {
+		rel := s.Relations[name]
+		meta.Relations = append(meta.Relations, relationMeta{Name: name, Type: string(rel.Type), LocalKey: rel.LocalKey, Table: rel.Table, ForeignKey: rel.ForeignKey})
+	}
+
+	fillable := map[string]bool{}
+	for _, col := range s.FillableColumns() {
+		fillable[col] = true
+	}
+	aliases := map[string][]string{}
+	for _, alias := range sortedKeys(s.Aliases) {
+		aliases[s.Aliases[alias]] = append(aliases[s.Aliases[alias]], alias)
+	}
+	// The server fills the tenant column and managed timestamps.
+	serverSet := func(col string) bool {
+		return col == meta.TenantColumn || (s.Timestamps && (col == "created_at" || col == "updated_at"))
+	}
+
+	for _, col := range s.Columns {
+		isFillable := fillable[col] && !serverSet(col)
+		if s.IsHidden(col) && !isFillable {
+			continue
+		}
+		info := s.ColumnInfo[col]
+		cm := columnMeta{
+			Name:       col,
+			DataType:   info.DataType,
+			Cast:       string(s.Casts[col]),
+			Nullable:   info.Nullable,
+			MaxLength:  info.MaxLength,
+			Precision:  info.Precision,
+			Scale:      info.Scale,
+			Default:    info.Default,
+			Enum:       info.EnumValues,
+			PrimaryKey: col == s.PrimaryKey,
+			Fillable:   isFillable,
+			Required:   isFillable && s.RequiredOnCreate(col),
+			WriteOnly:  s.IsHidden(col),
+			Generated:  s.Generators[col].Pattern.String(),
+			Rules:      ruleStrings(s.Rules[col]),
+			Aliases:    aliases[col],
+		}
+		if vals := s.Casts[col].EnumValues(); len(vals) > 0 {
+			cm.Enum = vals
+		}
+		if hint, ok := d.UI[col]; ok {
+			cm.UI = &hint
+		}
+		if fc, ok := d.Files[col]; ok {
+			cm.File = &fc
+		}
+		meta.Columns = append(meta.Columns, cm)
+		for _, alias := range aliases[col] {
+			if meta.Aliases == nil {
+				meta.Aliases = map[string]string{}
+			}
+			meta.Aliases[alias] = col
+		}
+	}
+	for _, name := range sortedKeys(s.Computed) {
+		cm := columnMeta{Name: name, Cast: string(s.Casts[name]), Nullable: true, Computed: true}
+		if hint, ok := d.UI[name]; ok {
+			cm.UI = &hint
+		}
+		meta.Columns = append(meta.Columns, cm)
+	}
+
+	order := func(cm columnMeta) int {
+		if cm.UI == nil || cm.UI.Order == 0 {
+			return int(^uint(0) >> 1)
+		}
+		return cm.UI.Order
+	}
+	sort.SliceStable(meta.Columns, func(i, j int) bool { return order(meta.Columns[i]) < order(meta.Columns[j]) })
+	return meta
+}
+
+func ruleStrings(rules []eloquent.Rule) []string {
+	out := []string{}
+	for _, r := range rules {
+		if len(r.Args) == 0 {
+			out = append(out, r.Name)
+			continue
+		}
+		out = append(out, r.Name+":"+strings.Join(r.Args, ","))
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
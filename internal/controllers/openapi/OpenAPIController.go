@@ -0,0 +1,117 @@
+package openapicontroller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/openapi"
+	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/schema"
+)
+
+// cacheTTL bounds how long a generated document is served before schemas are reloaded.
+const cacheTTL = time.Minute
+
+// OpenAPIController serves the runtime OpenAPI document.
+//
+// Route:
+// - GET /v1/openapi.json
+//
+// The static contract (OPENAPI_FILE, default Docs/openapi/openapi.yaml) is merged with one set of
+// /v1/crud/<table> paths per table the CRUD deny policy allows. Tables whose schema cannot be
+// loaded, or that have no tenant column and are not global, are left out (CRUD rejects them).
+type OpenAPIController struct {
+	sqlDB  *sql.DB
+	allows func(table string) bool
+	file   string
+
+	mu      sync.Mutex
+	cached  map[string]any
+	builtAt time.Time
+}
+
+// NewOpenAPIController takes the CRUD controller's table policy (TableCRUDController.Allows).
+func NewOpenAPIController(sqlDB *sql.DB, allows func(table string) bool) *OpenAPIController {
+	file := strings.TrimSpace(os.Getenv("OPENAPI_FILE"))
+	if file == "" {
+		file = openapi.DefaultFile
+	}
+	return &OpenAPIController{sqlDB: sqlDB, allows: allows, file: file}
+}
+
+func (c *OpenAPIController) HandleSpec(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/openapi.json" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if c.sqlDB == nil {
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", map[string]string{"database": "not configured"})
+		return
+	}
+
+	doc, err := c.document(r.Context())
+	if err != nil {
+		log.Printf("openapi: %v", err)
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", map[string]string{"code": "internal_error"})
+		return
+	}
+	shared.WriteJSON(w, http.StatusOK, doc)
+}
+
+func (c *OpenAPIController) document(ctx context.Context) (map[string]any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != nil && time.Since(c.builtAt) < cacheTTL {
+		return c.cached, nil
+	}
+
+	base, err := openapi.LoadBase(c.file)
+	if err != nil {
+		// Serve the generated part anyway; the static contract is documentation.
+		log.Printf("openapi: static contract unavailable: %v", err)
+		base = openapi.Skeleton()
+	}
+
+	names, err := schema.ListTables(ctx, c.sqlDB)
+	if err != nil {
+		return nil, err
+	}
+	tables := []openapi.Table{}
+	for _, name := range names {
+		if !c.allows(name) {
+			continue
+		}
+		s, err := schema.LoadSchema(ctx, c.sqlDB, name)
+		var (
+			fe *schema.FileError
+			ve *eloquent.ValidationError
+		)
+		if errors.As(err, &fe) || errors.As(err, &ve) {
+			log.Printf("openapi: skipping %s: %v", name, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if s.TenantColumnName() == "" && !s.Global {
+			continue
+		}
+		tables = append(tables, openapi.Table{Name: name, Schema: s})
+	}
+
+	c.cached = openapi.Build(base, tables)
+	c.builtAt = time.Now()
+	return c.cached, nil
+}
@@ -8,11 +8,16 @@ import (
 	"os"
 	"strings"
 
+	"mylab-api-go/internal/audit"
 	"mylab-api-go/internal/database/eloquent"
 	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/export"
+	"mylab-api-go/internal/history"
+	"mylab-api-go/internal/idempotency"
 	"mylab-api-go/internal/querydsl"
 	"mylab-api-go/internal/routes/auth"
 	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/webhook"
 )
 
 type QueryController struct {
@@ -21,7 +26,8 @@ type QueryController struct {
 }
 
 type LaravelQueryRequest struct {
-	LaravelQuery string `json:"laravel_query"`
+	LaravelQuery string          `json:"laravel_query"`
+	Export       *export.Options `json:"export,omitempty"` // stream the result as CSV/XLSX instead of JSON
 }
 
 // NewQueryController registers the allowed tables for safe query execution.
@@ -36,12 +42,23 @@ func NewQueryController(sqlDB *sql.DB) *QueryController {
 	// - Supports '*' meaning deny all tables.
 	deniedRaw := strings.TrimSpace(os.Getenv("QUERYDSL_DENIED_TABLES"))
 
+	// Gateway-owned tables (audit trail, webhook subscriptions/outbox, code counters, row history,
+	// idempotency keys) are always denied.
+	reserved := append([]string{audit.DefaultTable, eloquent.SequenceTable(), history.Table, idempotency.Table}, webhook.Tables()...)
+	if t := strings.ToLower(strings.TrimSpace(os.Getenv("AUDIT_TABLE"))); t != "" {
+		reserved = append(reserved, t)
+	}
+	if deniedRaw != "" {
+		deniedRaw += ","
+	}
+	deniedRaw += strings.Join(reserved, ",")
+
 	policy := querydsl.ParseTablePolicy("", deniedRaw)
 	return &QueryController{sqlDB: sqlDB, policy: policy}
 }
 
 // HandleQuery executes a safe, tenant-enforced query built from a restricted Laravel-style DSL.
-// Endpoint: POST /v1/query
+// Endpoint: POST /v1/query (with "export": CSV/XLSX download, see handleExport)
 func (c *QueryController) HandleQuery(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/v1/query" {
 		w.WriteHeader(http.StatusNotFound)
@@ -76,6 +93,10 @@ func (c *QueryController) HandleQuery(w http.ResponseWriter, r *http.Request) {
 		writeQueryError(w, err)
 		return
 	}
+	if req.Export != nil {
+		c.handleExport(w, r, authInfo.CompanyID, spec, *req.Export)
+		return
+	}
 	// Default/cap limit to keep endpoint safe.
 	if spec.Limit <= 0 {
 		spec.Limit = 200
@@ -118,34 +139,6 @@ func writeQueryError(w http.ResponseWriter, err error) {
 }
 
 func scanRowsToMaps(rows *sql.Rows) ([]map[string]any, error) {
-	cols, err := rows.Columns()
-	if err != nil {
-		return nil, err
-	}
-	out := make([]map[string]any, 0, 32)
-	for rows.Next() {
-		vals := make([]any, len(cols))
-		ptrs := make([]any, len(cols))
-		for i := range vals {
-			ptrs[i] = &vals[i]
-		}
-		if err := rows.Scan(ptrs...); err != nil {
-			return nil, err
-		}
-		m := map[string]any{}
-		for i, c := range cols {
-			v := vals[i]
-			// Convert []byte to string for JSON friendliness.
-			if b, ok := v.([]byte); ok {
-				m[c] = string(b)
-				continue
-			}
-			m[c] = v
-		}
-		out = append(out, m)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return out, nil
+	// Ad-hoc joins have no single schema; output types come from the driver's column types.
+	return eloquent.ScanRows(rows, nil)
 }
@@ -0,0 +1,83 @@
+package querycontroller
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/db"
+	"mylab-api-go/internal/export"
+	"mylab-api-go/internal/querydsl"
+	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/schema"
+)
+
+// handleExport streams a /v1/query result as a CSV/XLSX download. The query's own limit is
+// honoured up to EXPORT_MAX_ROWS (instead of the 200-row JSON cap); an unlimited query that
+// matches more rows than that is a 422 before anything is streamed.
+func (c *QueryController) handleExport(w http.ResponseWriter, r *http.Request, companyID int64, spec *querydsl.QuerySpec, opt export.Options) {
+	format, verr := opt.Validate()
+	if verr != nil {
+		writeQueryError(w, verr)
+		return
+	}
+	max := export.MaxRows()
+	if spec.Limit <= 0 || spec.Limit > max {
+		// One extra row tells "exactly max" apart from "too many".
+		spec.Limit = max + 1
+	}
+	loc := export.Location(companyID, opt.Timezone)
+	lang := export.Lang(r, opt.Lang)
+	st := export.NewStream(w, format, export.Filename(opt.Filename, spec.FromTable, format, loc), opt)
+	st.Project = opt.Columns
+
+	_, err := db.WithTx(r.Context(), c.sqlDB, func(tx *sql.Tx) (any, error) {
+		built, err := querydsl.BuildSQLWithIntrospection(r.Context(), tx, companyID, spec, c.policy)
+		if err != nil {
+			return nil, err
+		}
+		var n int
+		if err := tx.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM ("+built.SQL+") export_count", built.Args...).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n > max {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{"export": fmt.Sprintf("more than %d rows match; exports are limited to %d (narrow the filters or set a limit)", max, max)}}
+		}
+
+		// Headers come from the ui labels of each column's source table.
+		ui := map[string]map[string]schema.ColumnUI{}
+		for _, table := range built.Sources {
+			if _, ok := ui[table]; ok {
+				continue
+			}
+			d, _, err := schema.LoadDirectives(table)
+			if err != nil {
+				return nil, err
+			}
+			ui[table] = d.UI
+		}
+		st.Title = func(col string) string { return export.Title(col, opt.Labels, ui[built.Sources[col]], lang) }
+
+		rs, err := tx.QueryContext(r.Context(), built.SQL, built.Args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rs.Close()
+		if err := eloquent.StreamRows(rs, nil, loc, st); err != nil {
+			return nil, err
+		}
+		return nil, st.Close()
+	})
+	if err != nil {
+		if st.Started() {
+			// Headers are out: abort the connection so the client sees a failed download.
+			log.Printf(`{"ts":%q,"level":"error","msg":"query export aborted","request_id":%q,"table":%q,"error":%q}`,
+				time.Now().UTC().Format(time.RFC3339Nano), shared.RequestIDFromContext(r.Context()), spec.FromTable, err.Error())
+			panic(http.ErrAbortHandler)
+		}
+		writeQueryError(w, err)
+	}
+}
@@ -0,0 +1,213 @@
+package webhookscontroller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/routes/auth"
+	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/webhook"
+)
+
+// WebhookController is the tenant admin API for outbound webhooks.
+//
+// Routes (admin role required, see auth.IsAdmin):
+// - GET    /v1/webhooks/subscriptions
+// - POST   /v1/webhooks/subscriptions          {"table":"pasien","events":["created"],"url":"https://...","secret":"..."}
+// - DELETE /v1/webhooks/subscriptions/{id}
+// - GET    /v1/webhooks/deliveries?status=pending|delivered|dead&page=&per_page=
+// - POST   /v1/webhooks/deliveries/{id}/replay
+type WebhookController struct {
+	store       *webhook.Store
+	unavailable error // webhooks disabled or unsupported database
+}
+
+func NewWebhookController(sqlDB *sql.DB) *WebhookController {
+	c := &WebhookController{}
+	if sqlDB != nil {
+		store, err := webhook.NewStore(sqlDB)
+		if err == nil {
+			c.store = store
+		}
+		if errors.Is(err, webhook.ErrUnsupportedDialect) || errors.Is(err, webhook.ErrDisabled) {
+			c.unavailable = err
+		}
+	}
+	return c
+}
+
+type createSubscriptionRequest struct {
+	Table  string   `json:"table"`
+	Events []string `json:"events"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+}
+
+func (c *WebhookController) Handle(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/v1/webhooks/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if c.unavailable != nil {
+		shared.WriteError(w, http.StatusNotImplemented, "Not implemented.", map[string]string{"database": c.unavailable.Error()})
+		return
+	}
+	if c.store == nil {
+		shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", map[string]string{"database": "not configured"})
+		return
+	}
+	authInfo, ok := auth.AuthInfoFromContext(r.Context())
+	if !ok {
+		shared.WriteError(w, http.StatusUnauthorized, "Unauthorized.", nil)
+		return
+	}
+	if !auth.IsAdmin(authInfo) {
+		shared.WriteError(w, http.StatusForbidden, "Forbidden.", map[string]string{"role": "admin role required"})
+		return
+	}
+
+	segs := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/webhooks/"), "/"), "/")
+	switch {
+	case len(segs) == 1 && segs[0] == "subscriptions":
+		switch r.Method {
+		case http.MethodGet:
+			c.handleListSubscriptions(w, r, authInfo.CompanyID)
+		case http.MethodPost:
+			c.handleCreateSubscription(w, r, authInfo.CompanyID)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case len(segs) == 2 && segs[0] == "subscriptions":
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		id, ok := parseID(w, segs[1])
+		if !ok {
+			return
+		}
+		if err := c.store.DeleteSubscription(r.Context(), authInfo.CompanyID, id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "Deleted.", "id": id})
+	case len(segs) == 1 && segs[0] == "deliveries":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		c.handleListDeliveries(w, r, authInfo.CompanyID)
+	case len(segs) == 3 && segs[0] == "deliveries" && segs[2] == "replay":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		id, ok := parseID(w, segs[1])
+		if !ok {
+			return
+		}
+		if err := c.store.Replay(r.Context(), authInfo.CompanyID, id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "Queued for replay.", "id": id})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (c *WebhookController) handleListSubscriptions(w http.ResponseWriter, r *http.Request, companyID int64) {
+	subs, err := c.store.ListSubscriptions(r.Context(), companyID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "OK", "data": subs})
+}
+
+func (c *WebhookController) handleCreateSubscription(w http.ResponseWriter, r *http.Request, companyID int64) {
+	var req createSubscriptionRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"body": "invalid JSON"})
+		return
+	}
+	sub, err := c.store.CreateSubscription(r.Context(), webhook.Subscription{
+		CompanyID: companyID,
+		Table:     req.Table,
+		Events:    req.Events,
+		URL:       req.URL,
+		Secret:    req.Secret,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	// The secret is only returned on creation.
+	shared.WriteJSON(w, http.StatusOK, map[string]any{"ok": true, "message": "Created.", "data": sub})
+}
+
+func (c *WebhookController) handleListDeliveries(w http.ResponseWriter, r *http.Request, companyID int64) {
+	qs := r.URL.Query()
+	status := strings.ToLower(strings.TrimSpace(qs.Get("status")))
+	switch status {
+	case "", webhook.StatusPending, webhook.StatusDelivered, webhook.StatusDead:
+	default:
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"status": "allowed: pending, delivered, dead"})
+		return
+	}
+	page, _ := strconv.Atoi(strings.TrimSpace(qs.Get("page")))
+	perPage, _ := strconv.Atoi(strings.TrimSpace(qs.Get("per_page")))
+
+	rows, hasMore, err := c.store.ListDeliveries(r.Context(), companyID, status, page, perPage)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if page <= 0 {
+		page = 1
+	}
+	shared.WriteJSON(w, http.StatusOK, map[string]any{
+		"ok":      true,
+		"message": "OK",
+		"data":    rows,
+		"paging":  map[string]any{"page": page, "has_more": hasMore},
+	})
+}
+
+func parseID(w http.ResponseWriter, raw string) (int64, bool) {
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", map[string]string{"id": "must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var ve *eloquent.ValidationError
+	if errors.As(err, &ve) {
+		shared.WriteError(w, http.StatusUnprocessableEntity, "Validation failed.", ve.Errors)
+		return
+	}
+	var nf *eloquent.NotFoundError
+	if errors.As(err, &nf) {
+		shared.WriteError(w, http.StatusNotFound, "Not found.", map[string]string{"id": "not found"})
+		return
+	}
+	log.Printf(
+		`{"ts":%q,"level":"error","msg":"webhook admin failed","request_id":%q,"error":%q}`,
+		time.Now().UTC().Format(time.RFC3339Nano),
+		shared.RequestIDFromContext(r.Context()),
+		err.Error(),
+	)
+	shared.WriteError(w, http.StatusInternalServerError, "Internal server error.", nil)
+}
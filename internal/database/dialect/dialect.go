@@ -0,0 +1,103 @@
+package dialect
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Dialect hides the SQL differences between the databases the gateway can front.
+//
+// Only the pieces the query builders need are abstracted: bind parameters, case-insensitive
+// LIKE, INSERT ... RETURNING, NULL ordering and the default information_schema schema.
+type Dialect interface {
+	// Name is the dialect identifier ("postgres" or "mysql").
+	Name() string
+	// DriverName is the database/sql driver name passed to sql.Open.
+	DriverName() string
+	// Placeholder returns the bind parameter for the n-th argument (1-based).
+	Placeholder(n int) string
+	// ILike renders a case-insensitive LIKE of expr against the bind parameter ph.
+	ILike(expr, ph string) string
+	// SupportsReturning reports whether INSERT ... RETURNING is available.
+	SupportsReturning() bool
+	// NullsFirst reports whether NULLs sort before other values in ascending order.
+	NullsFirst() bool
+	// DefaultSchema is the information_schema.table_schema used when DB_SCHEMA is unset.
+	// Empty means "the connection's current database".
+	DefaultSchema() string
+}
+
+const (
+	PostgresName = "postgres"
+	MySQLName    = "mysql"
+)
+
+type postgres struct{}
+
+func (postgres) Name() string                 { return PostgresName }
+func (postgres) DriverName() string           { return "pgx" }
+func (postgres) Placeholder(n int) string     { return fmt.Sprintf("$%d", n) }
+func (postgres) ILike(expr, ph string) string { return expr + " ILIKE " + ph }
+func (postgres) SupportsReturning() bool      { return true }
+func (postgres) NullsFirst() bool             { return false }
+func (postgres) DefaultSchema() string        { return "public" }
+
+type mysql struct{}
+
+func (mysql) Name() string            { return MySQLName }
+func (mysql) DriverName() string      { return "mysql" }
+func (mysql) Placeholder(int) string  { return "?" }
+func (mysql) SupportsReturning() bool { return false }
+func (mysql) NullsFirst() bool        { return true }
+func (mysql) DefaultSchema() string   { return "" }
+
+// ILike lowers both sides so the match is case-insensitive regardless of the column collation.
+func (mysql) ILike(expr, ph string) string { return "LOWER(" + expr + ") LIKE LOWER(" + ph + ")" }
+
+var (
+	Postgres Dialect = postgres{}
+	MySQL    Dialect = mysql{}
+)
+
+// FromURL selects the dialect from a DATABASE_URL scheme.
+func FromURL(rawURL string) (Dialect, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "postgres", "postgresql":
+		return Postgres, nil
+	case "mysql":
+		return MySQL, nil
+	default:
+		return nil, fmt.Errorf("unsupported DATABASE_URL scheme %q (allowed: postgres, postgresql, mysql)", u.Scheme)
+	}
+}
+
+var (
+	mu      sync.RWMutex
+	current Dialect = Postgres
+)
+
+// Set sets the process-wide dialect (db.Open calls this). One gateway process talks to one database.
+func Set(d Dialect) {
+	if d == nil {
+		d = Postgres
+	}
+	mu.Lock()
+	current = d
+	mu.Unlock()
+}
+
+// Current returns the process-wide dialect (Postgres unless db.Open selected another).
+func Current() Dialect {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// IsPostgres reports whether the current dialect is Postgres.
+func IsPostgres() bool { return Current().Name() == PostgresName }
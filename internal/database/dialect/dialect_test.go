@@ -0,0 +1,38 @@
+package dialect
+
+import "testing"
+
+func TestFromURL(t *testing.T) {
+	cases := map[string]string{
+		"postgres://u:p@localhost:5432/mylab?sslmode=disable": PostgresName,
+		"postgresql://localhost/mylab":                        PostgresName,
+		"mysql://u:p@localhost:3306/mylab":                    MySQLName,
+	}
+	for raw, want := range cases {
+		d, err := FromURL(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", raw, err)
+		}
+		if d.Name() != want {
+			t.Fatalf("%s: got %s, want %s", raw, d.Name(), want)
+		}
+	}
+	if _, err := FromURL("sqlite:///tmp/x.db"); err == nil {
+		t.Fatalf("expected error for unsupported scheme")
+	}
+}
+
+func TestRendering(t *testing.T) {
+	if got := Postgres.Placeholder(3); got != "$3" {
+		t.Fatalf("postgres placeholder: %s", got)
+	}
+	if got := MySQL.Placeholder(3); got != "?" {
+		t.Fatalf("mysql placeholder: %s", got)
+	}
+	if got := Postgres.ILike("nama_ps", "$1"); got != "nama_ps ILIKE $1" {
+		t.Fatalf("postgres ilike: %s", got)
+	}
+	if got := MySQL.ILike("nama_ps", "?"); got != "LOWER(nama_ps) LIKE LOWER(?)" {
+		t.Fatalf("mysql ilike: %s", got)
+	}
+}
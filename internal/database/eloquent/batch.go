@@ -0,0 +1,176 @@
+package eloquent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"mylab-api-go/internal/database/dialect"
+)
+
+// InsertBatchSize caps the rows per multi-row INSERT statement in InsertMany.
+const InsertBatchSize = 200
+
+// maxBatchParams keeps a batch under Postgres' 65535 bind-parameter limit.
+const maxBatchParams = 60000
+
+// UniqueSeen records unique-rule values already accepted by PrepareInsert, so duplicates
+// inside one batch are reported before anything is written.
+type UniqueSeen map[string]bool
+
+// PrepareInsert validates a create payload the way Insert does (fillable filter, casts, rules,
+// column constraints, unique rules against the table) without writing anything. The returned
+// row is meant for InsertMany. seen may be nil for a single row.
+func PrepareInsert(ctx context.Context, q Querier, schema Schema, payload map[string]any, seen UniqueSeen) (map[string]any, error) {
+	schema = schema.withDefaults()
+	data, verr := schema.normalizePayload(payload, false)
+	if verr != nil {
+		return nil, verr
+	}
+	if len(data) == 0 {
+		return nil, &ValidationError{Errors: map[string]string{"payload": "no fillable fields provided"}}
+	}
+	tenantCol := schema.tenantColumn()
+	if err := checkUniqueRules(ctx, q, schema, data, tenantCol, data[tenantCol], nil); err != nil {
+		return nil, err
+	}
+	if seen == nil {
+		return data, nil
+	}
+
+	keys := map[string]string{}
+	errs := map[string]string{}
+	for _, col := range sortedRuleColumns(schema.Rules) {
+		v, ok := data[col]
+		if !ok || isEmptyValue(v) || !hasRule(schema.Rules[col], RuleUnique) {
+			continue
+		}
+		key := col + "\x00" + fmt.Sprint(v)
+		if seen[key] {
+			errs[col] = "duplicates an earlier row"
+			continue
+		}
+		keys[col] = key
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+	for _, key := range keys {
+		seen[key] = true
+	}
+	return data, nil
+}
+
+// InsertMany writes rows returned by PrepareInsert, filling generated codes and timestamps.
+// Consecutive rows with the same columns share one multi-row INSERT (up to InsertBatchSize).
+// It returns the primary keys in row order.
+//
+// MySQL has no RETURNING, and AUTO_INCREMENT ids of a multi-row INSERT are not guaranteed to
+// be consecutive (innodb_autoinc_lock_mode=2, replication): only rows that carry their
+// primary key are batched there; the others are inserted one at a time (LAST_INSERT_ID()).
+func InsertMany(ctx context.Context, q Querier, schema Schema, rows []map[string]any) ([]any, error) {
+	schema = schema.withDefaults()
+	returning := dialect.Current().SupportsReturning()
+	for _, data := range rows {
+		if err := schema.fillGenerated(ctx, q, data); err != nil {
+			return nil, err
+		}
+		schema.stampCreated(data)
+	}
+
+	pks := make([]any, 0, len(rows))
+	for start := 0; start < len(rows); {
+		cols, _ := toSortedColsAndArgs(rows[start])
+		limit := InsertBatchSize
+		if n := maxBatchParams / len(cols); n < limit {
+			limit = n
+		}
+		end := start + 1
+		for end < len(rows) && end-start < limit && sameColumns(cols, rows[end]) {
+			if !returning && (!hasPrimaryKey(schema, rows[start]) || !hasPrimaryKey(schema, rows[end])) {
+				break
+			}
+			end++
+		}
+		batch, err := insertBatch(ctx, q, schema, cols, rows[start:end])
+		if err != nil {
+			return nil, err
+		}
+		pks = append(pks, batch...)
+		start = end
+	}
+	return pks, nil
+}
+
+func insertBatch(ctx context.Context, q Querier, schema Schema, cols []string, rows []map[string]any) ([]any, error) {
+	b := newSQLBuilder()
+	tuples := make([]string, 0, len(rows))
+	for _, data := range rows {
+		placeholders := make([]string, 0, len(cols))
+		for _, c := range cols {
+			placeholders = append(placeholders, b.push(data[c]))
+		}
+		tuples = append(tuples, "("+strings.Join(placeholders, ",")+")")
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", schema.Table, strings.Join(cols, ","), strings.Join(tuples, ","))
+
+	if !b.d.SupportsReturning() {
+		res, err := q.ExecContext(ctx, query, b.args...)
+		if err != nil {
+			return nil, err
+		}
+		pks := make([]any, len(rows))
+		for i, data := range rows {
+			if hasPrimaryKey(schema, data) {
+				pks[i] = data[schema.PrimaryKey]
+				continue
+			}
+			if len(rows) != 1 {
+				return nil, fmt.Errorf("insert of %d rows without primary keys cannot return them", len(rows))
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return nil, fmt.Errorf("insert did not return primary key: %w", err)
+			}
+			pks[i] = id
+		}
+		return pks, nil
+	}
+
+	rs, err := q.QueryContext(ctx, query+" RETURNING "+schema.PrimaryKey, b.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	pks := make([]any, 0, len(rows))
+	for rs.Next() {
+		var pk any
+		if err := rs.Scan(&pk); err != nil {
+			return nil, err
+		}
+		pks = append(pks, pk)
+	}
+	if err := rs.Err(); err != nil {
+		return nil, err
+	}
+	if len(pks) != len(rows) {
+		return nil, fmt.Errorf("insert returned %d primary keys for %d rows", len(pks), len(rows))
+	}
+	return pks, nil
+}
+
+func hasPrimaryKey(schema Schema, data map[string]any) bool {
+	return data[schema.PrimaryKey] != nil
+}
+
+func sameColumns(cols []string, data map[string]any) bool {
+	if len(data) != len(cols) {
+		return false
+	}
+	for _, c := range cols {
+		if _, ok := data[c]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package eloquent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"mylab-api-go/internal/database/dialect"
+)
+
+func TestInsertManyMySQLKeys(t *testing.T) {
+	dialect.Set(dialect.MySQL)
+	defer dialect.Set(dialect.Postgres)
+
+	f := &fakeDB{lastID: 40}
+	db := f.open(t)
+	s := Schema{Table: "orders", PrimaryKey: "id", Columns: []string{"id", "company_id", "no_lab"}}
+
+	// AUTO_INCREMENT keys: one INSERT per row, each key from its own LAST_INSERT_ID().
+	rows := []map[string]any{
+		{"company_id": int64(7), "no_lab": "L1"},
+		{"company_id": int64(7), "no_lab": "L2"},
+		{"company_id": int64(7), "no_lab": "L3"},
+	}
+	pks, err := InsertMany(context.Background(), db, s, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.stmts) != 3 || fmt.Sprint(pks) != "[41 42 43]" {
+		t.Fatalf("pks = %v, statements = %v", pks, f.stmts)
+	}
+	if f.stmts[0].sql != "INSERT INTO orders (company_id,no_lab) VALUES (?,?)" {
+		t.Fatalf("sql = %s", f.stmts[0].sql)
+	}
+
+	// Client-assigned keys are still batched.
+	f.stmts = nil
+	rows = []map[string]any{
+		{"id": int64(5), "company_id": int64(7), "no_lab": "L4"},
+		{"id": int64(6), "company_id": int64(7), "no_lab": "L5"},
+		{"id": nil, "company_id": int64(7), "no_lab": "L6"},
+	}
+	if pks, err = InsertMany(context.Background(), db, s, rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.stmts) != 2 || f.stmts[0].sql != "INSERT INTO orders (company_id,id,no_lab) VALUES (?,?,?),(?,?,?)" || fmt.Sprint(pks) != "[5 6 45]" {
+		t.Fatalf("pks = %v, statements = %v", pks, f.stmts)
+	}
+}
@@ -1,10 +1,13 @@
 package eloquent
 
 import (
+	"encoding/hex"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 func castValue(casts map[string]CastType, key string, value any) (any, string) {
@@ -19,8 +22,14 @@ func castValue(casts map[string]CastType, key string, value any) (any, string) {
 	if !ok {
 		return value, ""
 	}
+	return castTo(ct, value)
+}
 
-	switch ct {
+func castTo(ct CastType, value any) (any, string) {
+	if value == nil {
+		return nil, ""
+	}
+	switch ct.Kind() {
 	case CastString:
 		switch v := value.(type) {
 		case string:
@@ -62,6 +71,12 @@ func castValue(casts map[string]CastType, key string, value any) (any, string) {
 			return float64(v), ""
 		case int64:
 			return float64(v), ""
+		case jsonNumber:
+			f, err := strconv.ParseFloat(v.String(), 64)
+			if err != nil {
+				return nil, "must be a number"
+			}
+			return f, ""
 		case string:
 			if strings.TrimSpace(v) == "" {
 				return nil, ""
@@ -110,6 +125,20 @@ func castValue(casts map[string]CastType, key string, value any) (any, string) {
 		default:
 			return nil, "must be a datetime"
 		}
+	case CastDecimal:
+		return castDecimal(value)
+	case CastDate:
+		return castDate(value)
+	case CastTime:
+		return castTime(value)
+	case CastJSON:
+		return castJSON(value)
+	case CastUUID:
+		return castUUID(value)
+	case CastArray:
+		return castArray(ct.Param(), value)
+	case CastEnum:
+		return castEnum(ct.EnumValues(), value)
 	default:
 		return value, ""
 	}
@@ -140,3 +169,389 @@ func parseDateTime(raw string) (time.Time, error) {
 	}
 	return time.Time{}, fmt.Errorf("invalid datetime")
 }
+
+// Kind returns the cast without its parameter ("enum:L|P" -> "enum", "array:int" -> "array").
+func (c CastType) Kind() CastType {
+	if i := strings.IndexByte(string(c), ':'); i >= 0 {
+		return c[:i]
+	}
+	return c
+}
+
+// Param returns the cast parameter ("array:int" -> "int"), or "".
+func (c CastType) Param() string {
+	if i := strings.IndexByte(string(c), ':'); i >= 0 {
+		return string(c[i+1:])
+	}
+	return ""
+}
+
+// EnumValues returns the allowed values of an enum:<v1|v2> cast.
+func (c CastType) EnumValues() []string {
+	if c.Kind() != CastEnum {
+		return nil
+	}
+	out := []string{}
+	for _, v := range strings.Split(c.Param(), "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// EnumCast builds an enum cast from its allowed values.
+func EnumCast(values []string) CastType {
+	return CastType(string(CastEnum) + ":" + strings.Join(values, "|"))
+}
+
+// ParseCastType parses a cast name from a schema file (case-insensitive kind, enum values kept as-is).
+func ParseCastType(raw string) (CastType, bool) {
+	raw = strings.TrimSpace(raw)
+	kind, param, hasParam := strings.Cut(raw, ":")
+	ct := CastType(strings.ToLower(strings.TrimSpace(kind)))
+	param = strings.TrimSpace(param)
+	switch ct {
+	case CastString, CastInt, CastFloat, CastBool, CastDateTime, CastDecimal, CastDate, CastTime, CastJSON, CastUUID:
+		if hasParam {
+			return "", false
+		}
+		return ct, true
+	case CastArray:
+		if !hasParam {
+			return CastArray, true
+		}
+		elem, ok := ParseCastType(param)
+		if !ok || !isArrayElementCast(elem) {
+			return "", false
+		}
+		return CastArray + ":" + elem, true
+	case CastEnum:
+		values := CastType(string(CastEnum) + ":" + param).EnumValues()
+		if len(values) == 0 {
+			return "", false
+		}
+		return EnumCast(values), true
+	default:
+		return "", false
+	}
+}
+
+func isArrayElementCast(ct CastType) bool {
+	switch ct {
+	case CastString, CastInt, CastFloat, CastBool, CastDateTime, CastDecimal, CastDate, CastTime, CastUUID:
+		return true
+	}
+	return false
+}
+
+var decimalRE = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// castDecimal validates an exact decimal and normalizes it to a plain decimal string
+// (never via float64, so 0.1 + 0.2 style rounding cannot creep into money columns).
+func castDecimal(value any) (any, string) {
+	var s string
+	switch v := value.(type) {
+	case jsonNumber:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+		if s == "" {
+			return nil, ""
+		}
+	case int:
+		return strconv.Itoa(v), ""
+	case int64:
+		return strconv.FormatInt(v, 10), ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), ""
+	default:
+		return nil, "must be a decimal number"
+	}
+	if strings.ContainsAny(s, "eE") {
+		// JSON exponent form (1e3): expand exactly when possible.
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, "must be a decimal number"
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if !decimalRE.MatchString(s) {
+		return nil, "must be a decimal number"
+	}
+	s = strings.TrimPrefix(s, "+")
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "-.") {
+		s = strings.Replace(s, ".", "0.", 1)
+	}
+	return strings.TrimSuffix(s, "."), ""
+}
+
+func castDate(value any) (any, string) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format("2006-01-02"), ""
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return nil, ""
+		}
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, "must be a date (YYYY-MM-DD)"
+		}
+		return t.Format("2006-01-02"), ""
+	default:
+		return nil, "must be a date (YYYY-MM-DD)"
+	}
+}
+
+func castTime(value any) (any, string) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, "must be a time (HH:MM:SS)"
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, ""
+	}
+	for _, layout := range []string{"15:04:05.999999999", "15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("15:04:05.999999"), ""
+		}
+	}
+	return nil, "must be a time (HH:MM:SS)"
+}
+
+var uuidRE = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+func castUUID(value any) (any, string) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, "must be a UUID"
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, ""
+	}
+	if !uuidRE.MatchString(s) {
+		return nil, "must be a UUID"
+	}
+	b, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil {
+		return nil, "must be a UUID"
+	}
+	return formatUUID(b), ""
+}
+
+func castEnum(values []string, value any) (any, string) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = strings.TrimSpace(v)
+		if s == "" {
+			return nil, ""
+		}
+	case jsonNumber:
+		s = v.String()
+	case int, int64, float64, bool:
+		s = fmt.Sprint(v)
+	default:
+		return nil, "must be one of: " + strings.Join(values, ", ")
+	}
+	for _, allowed := range values {
+		if s == allowed {
+			return s, ""
+		}
+	}
+	return nil, "must be one of: " + strings.Join(values, ", ")
+}
+
+// castJSON accepts any JSON value and stores it as JSON text (json/jsonb columns).
+// A string is taken as already-encoded JSON only if it parses as an object or array;
+// otherwise it is stored as a JSON string.
+func castJSON(value any) (any, string) {
+	if s, ok := value.(string); ok {
+		t := strings.TrimSpace(s)
+		if (strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}")) || (strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]")) {
+			return t, ""
+		}
+	}
+	var sb strings.Builder
+	if err := writeJSON(&sb, value, 0); err != "" {
+		return nil, err
+	}
+	return sb.String(), ""
+}
+
+const maxJSONDepth = 64
+
+// writeJSON encodes values produced by a JSON decoder (maps, slices, strings, numbers, bools).
+// eloquent stays free of encoding/json; see jsonNumber.
+func writeJSON(sb *strings.Builder, v any, depth int) string {
+	if depth > maxJSONDepth {
+		return "nesting too deep"
+	}
+	switch t := v.(type) {
+	case nil:
+		sb.WriteString("null")
+	case bool:
+		sb.WriteString(strconv.FormatBool(t))
+	case string:
+		writeJSONString(sb, t)
+	case jsonNumber:
+		sb.WriteString(t.String())
+	case float64:
+		sb.WriteString(strconv.FormatFloat(t, 'g', -1, 64))
+	case int:
+		sb.WriteString(strconv.Itoa(t))
+	case int64:
+		sb.WriteString(strconv.FormatInt(t, 10))
+	case []any:
+		sb.WriteByte('[')
+		for i, item := range t {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if err := writeJSON(sb, item, depth+1); err != "" {
+				return err
+			}
+		}
+		sb.WriteByte(']')
+	case map[string]any:
+		sb.WriteByte('{')
+		for i, k := range sortedKeys(t) {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeJSONString(sb, k)
+			sb.WriteByte(':')
+			if err := writeJSON(sb, t[k], depth+1); err != "" {
+				return err
+			}
+		}
+		sb.WriteByte('}')
+	default:
+		return "must be a JSON value"
+	}
+	return ""
+}
+
+func writeJSONString(sb *strings.Builder, s string) {
+	const hexDigits = "0123456789abcdef"
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			sb.WriteString(`\"`)
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r < 0x20:
+			sb.WriteString(`\u00`)
+			sb.WriteByte(hexDigits[r>>4])
+			sb.WriteByte(hexDigits[r&0xf])
+		case r == utf8.RuneError:
+			sb.WriteString(`\ufffd`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+}
+
+// castArray casts each element with elem (or infers one element type) and returns a typed
+// slice the Postgres driver encodes as an array.
+func castArray(elem string, value any) (any, string) {
+	items, ok := value.([]any)
+	if !ok {
+		return nil, "must be an array"
+	}
+	ct := CastType(elem)
+	if ct == "" {
+		ct = inferArrayElement(items)
+		if ct == "" {
+			return nil, "must be an array of one element type"
+		}
+	}
+	casted := make([]any, len(items))
+	for i, item := range items {
+		if item == nil {
+			return nil, fmt.Sprintf("element %d must not be null", i)
+		}
+		v, msg := castTo(ct, item)
+		if msg != "" {
+			return nil, fmt.Sprintf("element %d %s", i, msg)
+		}
+		casted[i] = v
+	}
+	switch ct {
+	case CastInt:
+		out := make([]int64, len(casted))
+		for i, v := range casted {
+			out[i] = v.(int64)
+		}
+		return out, ""
+	case CastFloat:
+		out := make([]float64, len(casted))
+		for i, v := range casted {
+			out[i] = v.(float64)
+		}
+		return out, ""
+	case CastBool:
+		out := make([]bool, len(casted))
+		for i, v := range casted {
+			out[i] = v.(bool)
+		}
+		return out, ""
+	case CastDateTime:
+		out := make([]time.Time, len(casted))
+		for i, v := range casted {
+			out[i] = v.(time.Time)
+		}
+		return out, ""
+	default:
+		out := make([]string, len(casted))
+		for i, v := range casted {
+			out[i] = fmt.Sprint(v)
+		}
+		return out, ""
+	}
+}
+
+func inferArrayElement(items []any) CastType {
+	var ct CastType
+	for _, item := range items {
+		var t CastType
+		switch v := item.(type) {
+		case string:
+			t = CastString
+		case bool:
+			t = CastBool
+		case jsonNumber:
+			t = CastInt
+			if _, err := v.Int64(); err != nil {
+				t = CastDecimal
+			}
+		case int, int64:
+			t = CastInt
+		case float64:
+			t = CastFloat
+		default:
+			return ""
+		}
+		switch {
+		case ct == "":
+			ct = t
+		case ct == t:
+		case (ct == CastInt && t == CastDecimal) || (ct == CastDecimal && t == CastInt):
+			ct = CastDecimal
+		case (ct == CastInt && t == CastFloat) || (ct == CastFloat && t == CastInt):
+			ct = CastFloat
+		default:
+			return ""
+		}
+	}
+	if ct == "" {
+		ct = CastString
+	}
+	return ct
+}
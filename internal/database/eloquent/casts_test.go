@@ -0,0 +1,92 @@
+package eloquent
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseCastType(t *testing.T) {
+	cases := map[string]CastType{
+		"decimal":      CastDecimal,
+		"DATE":         CastDate,
+		"enum:L|P":     "enum:L|P",
+		"array":        CastArray,
+		"array:int":    "array:int",
+		"array:uuid":   "array:uuid",
+		"enum: L | P ": "enum:L|P",
+	}
+	for raw, want := range cases {
+		got, ok := ParseCastType(raw)
+		if !ok || got != want {
+			t.Fatalf("ParseCastType(%q) = %q, %v; want %q", raw, got, ok, want)
+		}
+	}
+	for _, raw := range []string{"money", "enum:", "array:json", "array:array", "int:8"} {
+		if _, ok := ParseCastType(raw); ok {
+			t.Fatalf("ParseCastType(%q) should fail", raw)
+		}
+	}
+	if vals := CastType("enum:L|P").EnumValues(); !reflect.DeepEqual(vals, []string{"L", "P"}) {
+		t.Fatalf("enum values: %v", vals)
+	}
+}
+
+func TestCastExtended(t *testing.T) {
+	ok := []struct {
+		cast CastType
+		in   any
+		want any
+	}{
+		{CastDecimal, json.Number("1234.50"), "1234.50"},
+		{CastDecimal, "-.5", "-0.5"},
+		{CastDecimal, json.Number("1e3"), "1000"},
+		{CastDate, "2024-03-01", "2024-03-01"},
+		{CastTime, "07:30", "07:30:00"},
+		{CastTime, "07:30:15.250", "07:30:15.25"},
+		{CastUUID, "123E4567E89B12D3A456426614174000", "123e4567-e89b-12d3-a456-426614174000"},
+		{CastJSON, map[string]any{"b": []any{json.Number("1"), "x\"y"}, "a": nil}, `{"a":null,"b":[1,"x\"y"]}`},
+		{CastJSON, `{"raw":true}`, `{"raw":true}`},
+		{CastJSON, "plain", `"plain"`},
+		{"enum:L|P", "P", "P"},
+		{"array:int", []any{json.Number("1"), "2"}, []int64{1, 2}},
+		{CastArray, []any{"a", "b"}, []string{"a", "b"}},
+		{CastArray, []any{json.Number("1"), json.Number("2.5")}, []string{"1", "2.5"}},
+	}
+	for _, c := range ok {
+		got, msg := castTo(c.cast, c.in)
+		if msg != "" || !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("castTo(%s, %#v) = %#v, %q; want %#v", c.cast, c.in, got, msg, c.want)
+		}
+	}
+
+	bad := []struct {
+		cast CastType
+		in   any
+	}{
+		{CastDecimal, "12,5"},
+		{CastDecimal, 1.5 < 2},
+		{CastDate, "2024-03-01 10:00:00"},
+		{CastTime, "25:00"},
+		{CastUUID, "not-a-uuid"},
+		{"enum:L|P", "X"},
+		{CastArray, []any{"a", json.Number("1")}},
+		{"array:int", []any{"x"}},
+		{CastArray, "a,b"},
+	}
+	for _, c := range bad {
+		if got, msg := castTo(c.cast, c.in); msg == "" {
+			t.Fatalf("castTo(%s, %#v) = %#v; want error", c.cast, c.in, got)
+		}
+	}
+}
+
+func TestDecimalScaleConstraint(t *testing.T) {
+	info := ColumnInfo{DataType: "numeric", Nullable: true, Precision: 10, Scale: 2}
+	if msg := checkColumnInfo(info, "12.50"); msg != "" {
+		t.Fatalf("12.50: %s", msg)
+	}
+	if msg := checkColumnInfo(info, "12.345"); msg != "must have at most 2 decimal places" {
+		t.Fatalf("12.345: %q", msg)
+	}
+}
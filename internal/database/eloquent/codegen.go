@@ -0,0 +1,271 @@
+package eloquent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mylab-api-go/internal/database/dialect"
+)
+
+// Code generators fill string keys and document numbers (pasien.kd_ps, no_lab, ...) on insert
+// from a pattern such as "{company}-{yyyy}{mm}-{seq:6}". Counters are kept per sequence, tenant
+// and period in a gateway-owned table and incremented inside the caller's transaction, so a
+// rolled-back insert does not burn a number and concurrent inserts never get the same one.
+//
+// Pattern tokens:
+// - {company} (alias {tenant}): the tenant value of the row
+// - {yyyy} {yy} {mm} {dd}: the current date (OUTPUT_TIMEZONE, else server zone)
+// - {seq:N}: the counter, zero-padded to N digits (required, exactly once)
+//
+// The counter resets when the smallest date token changes: {dd} daily, {mm} monthly,
+// {yyyy}/{yy} yearly; without date tokens it never resets.
+
+// DefaultSequenceTable is the gateway-owned counter table (SEQUENCE_TABLE overrides).
+const DefaultSequenceTable = "gateway_sequences"
+
+var (
+	seqMu    sync.RWMutex
+	seqTable = DefaultSequenceTable
+
+	codeTokenRE = regexp.MustCompile(`\{([a-z]+)(?::([0-9]+))?\}`)
+	seqTableRE  = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+// SetSequenceTable sets the process-wide counter table (called once at startup).
+func SetSequenceTable(table string) error {
+	table = strings.ToLower(strings.TrimSpace(table))
+	if table == "" {
+		table = DefaultSequenceTable
+	}
+	if !seqTableRE.MatchString(table) {
+		return fmt.Errorf("invalid sequence table name: %q", table)
+	}
+	seqMu.Lock()
+	seqTable = table
+	seqMu.Unlock()
+	return nil
+}
+
+// SequenceTable returns the counter table name.
+func SequenceTable() string {
+	seqMu.RLock()
+	defer seqMu.RUnlock()
+	return seqTable
+}
+
+// EnsureSequenceTable creates the counter table if it does not exist. It must run outside
+// request transactions (MySQL DDL commits implicitly), so main calls it at startup.
+func EnsureSequenceTable(ctx context.Context, q Querier) error {
+	table := SequenceTable()
+	ddl := fmt.Sprintf(`
+create table if not exists %s (
+  name varchar(128) not null,
+  tenant varchar(64) not null,
+  period varchar(8) not null,
+  value bigint not null,
+  updated_at timestamp not null default current_timestamp,
+  primary key (name, tenant, period)
+)`, table)
+	_, err := q.ExecContext(ctx, ddl)
+	return err
+}
+
+// CodePattern is a parsed generator pattern.
+type CodePattern struct {
+	raw    string
+	period string // Go layout of the counter period: "", "2006", "200601" or "20060102"
+}
+
+// ParseCodePattern validates a pattern such as "{company}-{yyyy}{mm}-{seq:6}".
+func ParseCodePattern(raw string) (CodePattern, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return CodePattern{}, fmt.Errorf("empty pattern")
+	}
+	seqs := 0
+	p := CodePattern{raw: raw}
+	rank := 0 // 1 = year, 2 = month, 3 = day
+	for _, m := range codeTokenRE.FindAllStringSubmatch(raw, -1) {
+		switch m[1] {
+		case "company", "tenant":
+		case "yyyy", "yy":
+			rank = max(rank, 1)
+		case "mm":
+			rank = max(rank, 2)
+		case "dd":
+			rank = max(rank, 3)
+		case "seq":
+			n, err := strconv.Atoi(m[2])
+			if err != nil || n < 1 || n > 18 {
+				return CodePattern{}, fmt.Errorf("{seq:N} needs a width between 1 and 18")
+			}
+			seqs++
+		default:
+			return CodePattern{}, fmt.Errorf("unknown token {%s}", m[1])
+		}
+		if m[2] != "" && m[1] != "seq" {
+			return CodePattern{}, fmt.Errorf("token {%s} takes no width", m[1])
+		}
+	}
+	if seqs != 1 {
+		return CodePattern{}, fmt.Errorf("pattern must contain {seq:N} exactly once")
+	}
+	if strings.ContainsAny(codeTokenRE.ReplaceAllString(raw, ""), "{}") {
+		return CodePattern{}, fmt.Errorf("unbalanced or malformed token")
+	}
+	p.period = []string{"", "2006", "200601", "20060102"}[rank]
+	return p, nil
+}
+
+func (p CodePattern) String() string { return p.raw }
+
+// Period returns the counter period for t ("" when the pattern has no date token).
+func (p CodePattern) Period(t time.Time) string {
+	if p.period == "" {
+		return ""
+	}
+	return t.Format(p.period)
+}
+
+// Format renders the pattern for one counter value.
+func (p CodePattern) Format(tenant any, t time.Time, seq int64) string {
+	return codeTokenRE.ReplaceAllStringFunc(p.raw, func(tok string) string {
+		m := codeTokenRE.FindStringSubmatch(tok)
+		switch m[1] {
+		case "company", "tenant":
+			if tenant == nil {
+				return ""
+			}
+			return fmt.Sprint(tenant)
+		case "yyyy":
+			return t.Format("2006")
+		case "yy":
+			return t.Format("06")
+		case "mm":
+			return t.Format("01")
+		case "dd":
+			return t.Format("02")
+		case "seq":
+			width, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%0*d", width, seq)
+		}
+		return tok
+	})
+}
+
+// NextCode increments the counter of (sequence, tenant, period) in the caller's transaction and
+// returns the formatted code. sequence names the counter, e.g. "pasien.kd_ps" or "no_lab"; the
+// same name shares numbers across tables.
+func NextCode(ctx context.Context, q Querier, sequence string, p CodePattern, tenant any, now time.Time) (string, error) {
+	if p.raw == "" {
+		return "", fmt.Errorf("code pattern is not set")
+	}
+	loc := currentOutputOptions().Location
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+	tenantKey := ""
+	if tenant != nil {
+		tenantKey = fmt.Sprint(tenant)
+	}
+	period := p.Period(now)
+
+	seq, err := nextSequence(ctx, q, sequence, tenantKey, period)
+	if err != nil {
+		return "", fmt.Errorf("sequence %s: %w", sequence, err)
+	}
+	return p.Format(tenant, now, seq), nil
+}
+
+func nextSequence(ctx context.Context, q Querier, name, tenant, period string) (int64, error) {
+	table := SequenceTable()
+
+	query := fmt.Sprintf(
+		`INSERT INTO %[1]s (name, tenant, period, value) VALUES ($1, $2, $3, 1)
+		 ON CONFLICT (name, tenant, period) DO UPDATE SET value = %[1]s.value + 1, updated_at = now()
+		 RETURNING value`, table)
+	args := []any{name, tenant, period}
+	if dialect.Current().Name() == dialect.MySQLName {
+		// No RETURNING: LAST_INSERT_ID(expr) makes the new value readable on this connection
+		// (the transaction's) without a second lookup racing other writers.
+		if _, err := q.ExecContext(ctx, fmt.Sprintf(
+			`INSERT INTO %s (name, tenant, period, value) VALUES (?, ?, ?, LAST_INSERT_ID(1))
+			 ON DUPLICATE KEY UPDATE value = LAST_INSERT_ID(value + 1), updated_at = CURRENT_TIMESTAMP`, table),
+			args...); err != nil {
+			return 0, err
+		}
+		query, args = "SELECT LAST_INSERT_ID()", nil
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("no counter value returned")
+	}
+	var value int64
+	if err := rows.Scan(&value); err != nil {
+		return 0, err
+	}
+	return value, rows.Err()
+}
+
+// fillGenerated sets every generated column that the payload left empty. Sequences are named
+// <table>.<column> unless the generator names a shared one.
+func (s Schema) fillGenerated(ctx context.Context, q Querier, data map[string]any) error {
+	if len(s.Generators) == 0 {
+		return nil
+	}
+	s = s.withDefaults()
+	tenantCol := s.tenantColumn()
+	var tenant any
+	if tenantCol != "" {
+		tenant = data[tenantCol]
+	}
+	cols := make([]string, 0, len(s.Generators))
+	for col := range s.Generators {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	now := s.Now()
+	for _, col := range cols {
+		if !isEmptyValue(data[col]) {
+			continue
+		}
+		g := s.Generators[col]
+		name := g.Sequence
+		if name == "" {
+			name = s.Table + "." + col
+		}
+		code, err := NextCode(ctx, q, name, g.Pattern, tenant, now)
+		if err != nil {
+			return err
+		}
+		data[col] = code
+	}
+	return nil
+}
+
+// Generator is a column's code generator (schema file generate=).
+type Generator struct {
+	Pattern  CodePattern
+	Sequence string // counter name; "" = <table>.<column>
+}
+
+// isGenerated reports whether col is filled by a generator when absent on insert.
+func (s Schema) isGenerated(col string) bool {
+	_, ok := s.Generators[col]
+	return ok
+}
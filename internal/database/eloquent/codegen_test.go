@@ -0,0 +1,56 @@
+package eloquent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCodePattern(t *testing.T) {
+	now := time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		raw, period, code string
+	}{
+		{"{company}-{yyyy}{mm}-{seq:6}", "202603", "12-202603-000042"},
+		{"LAB{yy}{mm}{dd}{seq:4}", "20260307", "LAB2603070042"},
+		{"RM{yyyy}/{seq:3}", "2026", "RM2026/042"},
+		{"P{seq:2}", "", "P42"},
+	} {
+		p, err := ParseCodePattern(tc.raw)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.raw, err)
+		}
+		if got := p.Period(now); got != tc.period {
+			t.Errorf("%s: period %q, want %q", tc.raw, got, tc.period)
+		}
+		if got := p.Format(int64(12), now, 42); got != tc.code {
+			t.Errorf("%s: code %q, want %q", tc.raw, got, tc.code)
+		}
+	}
+
+	for _, raw := range []string{"", "{yyyy}", "{seq:0}", "{seq:3}{seq:3}", "{seq}", "{mm:2}{seq:3}", "{seq:3}{", "{yyy}{seq:3}"} {
+		if _, err := ParseCodePattern(raw); err == nil {
+			t.Errorf("%q: expected error", raw)
+		}
+	}
+}
+
+func TestGeneratedColumnsSkipRequired(t *testing.T) {
+	p, _ := ParseCodePattern("{company}-{seq:6}")
+	s := Schema{
+		Table:      "pasien",
+		PrimaryKey: "id",
+		Columns:    []string{"id", "kd_ps", "nama_ps", "company_id"},
+		Rules:      map[string][]Rule{"kd_ps": {{Name: RuleRequired}}},
+		ColumnInfo: map[string]ColumnInfo{"kd_ps": {}},
+		Generators: map[string]Generator{"kd_ps": {Pattern: p}},
+	}
+	if _, verr := s.normalizePayload(map[string]any{"nama_ps": "Budi", "company_id": 1}, false); verr != nil {
+		t.Fatalf("create without generated column: %v", verr.Errors)
+	}
+	if s.RequiredOnCreate("kd_ps") {
+		t.Fatalf("generated column reported as required")
+	}
+	if _, verr := s.normalizePayload(map[string]any{"kd_ps": ""}, true); verr == nil || verr.Errors["kd_ps"] != "required" {
+		t.Fatalf("update clearing generated column: %v", verr)
+	}
+}
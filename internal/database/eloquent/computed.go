@@ -0,0 +1,241 @@
+package eloquent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Computed (virtual) columns are read-only SQL expressions declared in schema files, e.g.
+//
+//	computed=usia:date_part('year', age(tgl_lahir))
+//	computed=nama_lengkap:concat_ws(' ', titel, nama_ps)
+//
+// Expressions are restricted to an allowlist: the table's own columns, allowlisted functions
+// and keywords, string/number literals, parentheses, commas and basic operators.
+
+var computedIdentRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var computedFunctions = map[string]bool{
+	"abs": true, "age": true, "cast": true, "ceil": true, "coalesce": true, "concat": true,
+	"concat_ws": true, "date_part": true, "date_trunc": true, "floor": true, "greatest": true,
+	"least": true, "left": true, "length": true, "lower": true, "ltrim": true, "now": true,
+	"nullif": true, "right": true, "round": true, "rtrim": true, "substring": true, "to_char": true,
+	"trim": true, "upper": true,
+}
+
+var computedKeywords = map[string]bool{
+	"and": true, "as": true, "case": true, "current_date": true, "current_timestamp": true,
+	"else": true, "end": true, "false": true, "is": true, "not": true, "null": true, "or": true,
+	"then": true, "true": true, "when": true,
+	// type names for CAST(x AS type)
+	"bigint": true, "date": true, "integer": true, "int": true, "numeric": true, "text": true,
+	"timestamp": true, "varchar": true,
+}
+
+var computedOperators = []string{"||", "<>", "<=", ">=", "!=", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ","}
+
+type computedToken struct {
+	kind string // ident|func|keyword|string|number|op
+	text string
+}
+
+// tokenizeComputed splits and validates an expression. isColumn decides which identifiers are
+// column references.
+func tokenizeComputed(expr string, isColumn func(string) bool) ([]computedToken, error) {
+	s := strings.TrimSpace(expr)
+	if s == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	if strings.Contains(s, ";") || strings.Contains(s, "--") || strings.Contains(s, "/*") || strings.Contains(s, `"`) || strings.Contains(s, "::") {
+		return nil, fmt.Errorf("forbidden characters")
+	}
+
+	out := []computedToken{}
+	depth := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'':
+			j := i + 1
+			for {
+				if j >= len(s) {
+					return nil, fmt.Errorf("unterminated string")
+				}
+				if s[j] == '\'' {
+					if j+1 < len(s) && s[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			out = append(out, computedToken{kind: "string", text: s[i : j+1]})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(s) && ((s[j] >= '0' && s[j] <= '9') || s[j] == '.') {
+				j++
+			}
+			out = append(out, computedToken{kind: "number", text: s[i:j]})
+			i = j
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			j := i
+			for j < len(s) && (s[j] == '_' || (s[j] >= 'a' && s[j] <= 'z') || (s[j] >= 'A' && s[j] <= 'Z') || (s[j] >= '0' && s[j] <= '9')) {
+				j++
+			}
+			word := s[i:j]
+			lower := strings.ToLower(word)
+			// function call?
+			k := j
+			for k < len(s) && s[k] == ' ' {
+				k++
+			}
+			isCall := k < len(s) && s[k] == '('
+			switch {
+			case isCall && computedFunctions[lower]:
+				out = append(out, computedToken{kind: "func", text: lower})
+			case isCall:
+				return nil, fmt.Errorf("function not allowed: %s", word)
+			case isColumn(word):
+				out = append(out, computedToken{kind: "ident", text: word})
+			case computedKeywords[lower]:
+				out = append(out, computedToken{kind: "keyword", text: strings.ToUpper(lower)})
+			default:
+				return nil, fmt.Errorf("unknown identifier: %s", word)
+			}
+			i = j
+		default:
+			matched := ""
+			for _, op := range computedOperators {
+				if strings.HasPrefix(s[i:], op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, fmt.Errorf("unexpected character: %q", c)
+			}
+			if matched == "(" {
+				depth++
+			}
+			if matched == ")" {
+				depth--
+				if depth < 0 {
+					return nil, fmt.Errorf("unbalanced parentheses")
+				}
+			}
+			out = append(out, computedToken{kind: "op", text: matched})
+			i += len(matched)
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	return out, nil
+}
+
+// IsValidComputedName reports whether name is usable as a computed column name.
+func IsValidComputedName(name string) bool {
+	return computedIdentRE.MatchString(name)
+}
+
+// ValidateComputedExpr checks that expr only uses allowlisted syntax and the given columns.
+func ValidateComputedExpr(expr string, isColumn func(string) bool) error {
+	_, err := tokenizeComputed(expr, isColumn)
+	return err
+}
+
+// RenderComputedExpr renders a validated expression, qualifying column references with
+// qualifier (table alias) when not empty. The result is wrapped in parentheses.
+func RenderComputedExpr(expr string, isColumn func(string) bool, qualifier string) (string, error) {
+	toks, err := tokenizeComputed(expr, isColumn)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("(")
+	for i, t := range toks {
+		if i > 0 && computedNeedsSpace(toks[i-1], t) {
+			b.WriteString(" ")
+		}
+		if t.kind == "ident" && qualifier != "" {
+			b.WriteString(qualifier + "." + t.text)
+			continue
+		}
+		b.WriteString(t.text)
+	}
+	b.WriteString(")")
+	return b.String(), nil
+}
+
+func computedNeedsSpace(prev, cur computedToken) bool {
+	if cur.text == ")" || cur.text == "," || prev.text == "(" {
+		return false
+	}
+	if cur.text == "(" && prev.kind == "func" {
+		return false
+	}
+	return true
+}
+
+// IsComputed reports whether name is a computed (virtual) column.
+func (s Schema) IsComputed(name string) bool {
+	_, ok := s.Computed[name]
+	return ok && !s.hasColumn(name)
+}
+
+// isSelectable reports whether name can be used in select/filter/sort: real or computed column.
+func (s Schema) isSelectable(name string) bool {
+	return s.hasColumn(name) || s.IsComputed(name)
+}
+
+// columnExpr returns the SQL for a real or computed column (unqualified).
+func (s Schema) columnExpr(name string) string {
+	if s.IsComputed(name) {
+		sql, err := RenderComputedExpr(s.Computed[name], s.hasColumn, "")
+		if err == nil {
+			return sql
+		}
+	}
+	return name
+}
+
+// selectExpr returns the SELECT list entry for a real or computed column.
+func (s Schema) selectExpr(name string) string {
+	if s.IsComputed(name) {
+		return s.columnExpr(name) + " AS " + name
+	}
+	return name
+}
+
+// computedNames returns computed column names in a stable order.
+func (s Schema) computedNames() []string {
+	m := make(map[string]any, len(s.Computed))
+	for k := range s.Computed {
+		if s.IsComputed(k) {
+			m[k] = nil
+		}
+	}
+	return sortedKeys(m)
+}
+
+// defaultSelectList returns the default SELECT list: visible real columns + computed columns.
+func (s Schema) defaultSelectList() []string {
+	cols := s.VisibleColumns()
+	if len(cols) == 0 {
+		cols = []string{s.PrimaryKey}
+	}
+	out := make([]string, 0, len(cols)+len(s.Computed))
+	out = append(out, cols...)
+	for _, name := range s.computedNames() {
+		if s.IsHidden(name) {
+			continue
+		}
+		out = append(out, s.selectExpr(name))
+	}
+	return out
+}
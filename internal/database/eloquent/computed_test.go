@@ -0,0 +1,37 @@
+package eloquent
+
+import "testing"
+
+func TestRenderComputedExpr(t *testing.T) {
+	cols := map[string]bool{"tgl_lahir": true, "titel": true, "nama_ps": true}
+	isCol := func(c string) bool { return cols[c] }
+
+	got, err := RenderComputedExpr("concat_ws(' ', titel, nama_ps)", isCol, "p")
+	if err != nil {
+		t.Fatalf("RenderComputedExpr err: %v", err)
+	}
+	if got != "(concat_ws(' ', p.titel, p.nama_ps))" {
+		t.Fatalf("unexpected render: %s", got)
+	}
+
+	got, err = RenderComputedExpr("date_part('year', age(tgl_lahir))", isCol, "")
+	if err != nil {
+		t.Fatalf("RenderComputedExpr err: %v", err)
+	}
+	if got != "(date_part('year', age(tgl_lahir)))" {
+		t.Fatalf("unexpected render: %s", got)
+	}
+
+	for _, bad := range []string{
+		"pg_sleep(10)",
+		"nama_ps; drop table pasien",
+		"password",
+		"(select 1)",
+		"nama_ps::text",
+		"upper(nama_ps",
+	} {
+		if err := ValidateComputedExpr(bad, isCol); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package eloquent
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ColumnInfo holds DB-level constraints for a column (loaded via information_schema).
+// Zero values mean "unknown / not constrained".
+type ColumnInfo struct {
+	DataType   string   `json:"data_type"`
+	Nullable   bool     `json:"nullable"`
+	MaxLength  int      `json:"max_length,omitempty"`
+	Precision  int      `json:"numeric_precision,omitempty"`
+	Scale      int      `json:"numeric_scale,omitempty"`
+	Default    string   `json:"default,omitempty"`
+	HasDefault bool     `json:"has_default"`
+	EnumValues []string `json:"enum_values,omitempty"`
+}
+
+// validateColumnConstraints mirrors DB constraints (NOT NULL, length, precision, enum)
+// so clients get field errors instead of an opaque database error.
+//
+// On create (partial=false), fillable NOT NULL columns without a default must be present.
+// Columns the server fills itself (tenant column, timestamps) are skipped for that check.
+func (s Schema) validateColumnConstraints(data map[string]any, partial bool, errs map[string]string) {
+	if len(s.ColumnInfo) == 0 {
+		return
+	}
+
+	if !partial {
+		tenantCol := s.tenantColumn()
+		for _, col := range s.Columns {
+			info, ok := s.ColumnInfo[col]
+			if !ok || info.Nullable || info.HasDefault {
+				continue
+			}
+			if col == s.PrimaryKey || col == tenantCol || s.isGenerated(col) {
+				continue
+			}
+			if s.Timestamps && (col == "created_at" || col == "updated_at") {
+				continue
+			}
+			if !s.fillableSet()[col] {
+				continue
+			}
+			if _, failed := errs[col]; failed {
+				continue
+			}
+			if _, present := data[col]; !present {
+				errs[col] = "required"
+			}
+		}
+	}
+
+	for _, col := range sortedKeys(data) {
+		if _, failed := errs[col]; failed {
+			continue
+		}
+		info, ok := s.ColumnInfo[col]
+		if !ok {
+			continue
+		}
+		if msg := checkColumnInfo(info, data[col]); msg != "" {
+			errs[col] = msg
+		}
+	}
+}
+
+func checkColumnInfo(info ColumnInfo, v any) string {
+	if v == nil {
+		if !info.Nullable {
+			return "must not be null"
+		}
+		return ""
+	}
+
+	if len(info.EnumValues) > 0 {
+		sv := fmt.Sprint(v)
+		for _, e := range info.EnumValues {
+			if sv == e {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(info.EnumValues, ", ")
+	}
+
+	if info.MaxLength > 0 {
+		if sv, ok := v.(string); ok && utf8.RuneCountInString(sv) > info.MaxLength {
+			return fmt.Sprintf("must not exceed %d characters", info.MaxLength)
+		}
+	}
+
+	if info.Precision > 0 && isExactNumericType(info.DataType) {
+		intDigits := info.Precision - info.Scale
+		if f, ok := numericValue(v); ok && intDigits >= 0 {
+			if math.Abs(f) >= math.Pow10(intDigits) {
+				return fmt.Sprintf("must have at most %d digits before the decimal point", intDigits)
+			}
+		}
+		// Exact decimals (decimal cast) arrive as strings; the database would silently round.
+		if sv, ok := v.(string); ok {
+			if i := strings.IndexByte(sv, '.'); i >= 0 && len(strings.TrimRight(sv[i+1:], "0")) > info.Scale {
+				return fmt.Sprintf("must have at most %d decimal places", info.Scale)
+			}
+		}
+	}
+	return ""
+}
+
+func isExactNumericType(dataType string) bool {
+	t := strings.ToLower(dataType)
+	return strings.Contains(t, "numeric") || strings.Contains(t, "decimal")
+}
+
+func numericValue(v any) (float64, bool) {
+	switch t := v.(type) {
+	case int64:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case float64:
+		return t, true
+	case jsonNumber:
+		f, err := strconv.ParseFloat(t.String(), 64)
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,78 @@
+package eloquent
+
+import "testing"
+
+func TestValidateColumnConstraints(t *testing.T) {
+	s := Schema{
+		Table:      "pasien",
+		PrimaryKey: "kd_ps",
+		Columns:    []string{"kd_ps", "company_id", "nama_ps", "jk", "alamat", "status", "tarif", "created_at"},
+		Guarded:    []string{"status"},
+		Timestamps: true,
+		ColumnInfo: map[string]ColumnInfo{
+			"kd_ps":      {DataType: "integer"},
+			"company_id": {DataType: "bigint"},
+			"nama_ps":    {DataType: "character varying", MaxLength: 5},
+			"jk":         {DataType: "USER-DEFINED", Nullable: true, EnumValues: []string{"L", "P"}},
+			"alamat":     {DataType: "text", HasDefault: true, Default: "''::text"},
+			"status":     {DataType: "text"},
+			"tarif":      {DataType: "numeric", Nullable: true, Precision: 5, Scale: 2},
+			"created_at": {DataType: "timestamp without time zone"},
+		},
+	}
+
+	cases := []struct {
+		name    string
+		data    map[string]any
+		partial bool
+		want    map[string]string
+	}{
+		{"valid create", map[string]any{"nama_ps": "Budi", "jk": "L", "tarif": "123.45"}, false, map[string]string{}},
+		// pk, tenant column, timestamps, columns with a default and guarded columns are not required.
+		{"not null without default", map[string]any{}, false, map[string]string{"nama_ps": "required"}},
+		{"partial skips required", map[string]any{"jk": "P"}, true, map[string]string{}},
+		{"explicit null", map[string]any{"nama_ps": nil, "jk": nil}, true, map[string]string{"nama_ps": "must not be null"}},
+		{"max length in runes", map[string]any{"nama_ps": "Ãñéóü"}, true, map[string]string{}},
+		{"too long", map[string]any{"nama_ps": "Budiman"}, true, map[string]string{"nama_ps": "must not exceed 5 characters"}},
+		{"enum label", map[string]any{"jk": "X"}, true, map[string]string{"jk": "must be one of: L, P"}},
+		{"precision", map[string]any{"tarif": 1000.0}, true, map[string]string{"tarif": "must have at most 3 digits before the decimal point"}},
+		{"precision from string", map[string]any{"tarif": "-999.99"}, true, map[string]string{}},
+		{"scale", map[string]any{"tarif": "1.234"}, true, map[string]string{"tarif": "must have at most 2 decimal places"}},
+		{"trailing zeros", map[string]any{"tarif": "1.2300"}, true, map[string]string{}},
+		{"unknown column ignored", map[string]any{"nama_ps": "Budi", "foo": 1}, false, map[string]string{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := map[string]string{}
+			s.validateColumnConstraints(tc.data, tc.partial, errs)
+			if len(errs) != len(tc.want) {
+				t.Fatalf("errors = %v, want %v", errs, tc.want)
+			}
+			for k, v := range tc.want {
+				if errs[k] != v {
+					t.Fatalf("errors = %v, want %v", errs, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateColumnConstraintsKeepsEarlierErrors(t *testing.T) {
+	s := Schema{
+		Table:      "pasien",
+		PrimaryKey: "kd_ps",
+		Columns:    []string{"kd_ps", "nama_ps"},
+		ColumnInfo: map[string]ColumnInfo{"nama_ps": {DataType: "text", MaxLength: 3}},
+	}
+	errs := map[string]string{"nama_ps": "invalid format"}
+	s.validateColumnConstraints(map[string]any{"nama_ps": "Budiman"}, false, errs)
+	if errs["nama_ps"] != "invalid format" {
+		t.Fatalf("rule error must win: %v", errs)
+	}
+	// Without introspected info nothing is checked.
+	errs = map[string]string{}
+	Schema{Table: "pasien", Columns: []string{"nama_ps"}}.validateColumnConstraints(map[string]any{}, false, errs)
+	if len(errs) != 0 {
+		t.Fatalf("errors = %v", errs)
+	}
+}
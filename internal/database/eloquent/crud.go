@@ -11,33 +11,51 @@ import (
 
 func Insert(ctx context.Context, q Querier, schema Schema, payload map[string]any) (any, error) {
 	schema = schema.withDefaults()
-	data, verr := schema.normalizePayload(payload)
+	data, verr := schema.normalizePayload(payload, false)
 	if verr != nil {
 		return nil, verr
 	}
-
-	if schema.Timestamps {
-		now := schema.Now().UTC()
-		if schema.hasColumn("created_at") {
-			if _, ok := data["created_at"]; !ok {
-				data["created_at"] = now
-			}
-		}
-		if schema.hasColumn("updated_at") {
-			if _, ok := data["updated_at"]; !ok {
-				data["updated_at"] = now
-			}
-		}
+	if err := schema.fillGenerated(ctx, q, data); err != nil {
+		return nil, err
+	}
+	tenantCol := schema.tenantColumn()
+	if err := checkUniqueRules(ctx, q, schema, data, tenantCol, data[tenantCol], nil); err != nil {
+		return nil, err
 	}
 
+	schema.stampCreated(data)
+
 	cols, args := toSortedColsAndArgs(data)
 	if len(cols) == 0 {
 		return nil, &ValidationError{Errors: map[string]string{"payload": "no fillable fields provided"}}
 	}
 
+	b := newSQLBuilder()
 	placeholders := make([]string, 0, len(cols))
-	for i := range cols {
-		placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+	for _, v := range args {
+		placeholders = append(placeholders, b.push(v))
+	}
+
+	if !b.d.SupportsReturning() {
+		// MySQL: no RETURNING; use the payload PK or the AUTO_INCREMENT id.
+		query := fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES (%s)",
+			schema.Table,
+			strings.Join(cols, ","),
+			strings.Join(placeholders, ","),
+		)
+		res, err := q.ExecContext(ctx, query, b.args...)
+		if err != nil {
+			return nil, err
+		}
+		if pk, ok := data[schema.PrimaryKey]; ok && pk != nil {
+			return pk, nil
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("insert did not return primary key: %w", err)
+		}
+		return id, nil
 	}
 
 	query := fmt.Sprintf(
@@ -48,7 +66,7 @@ func Insert(ctx context.Context, q Querier, schema Schema, payload map[string]an
 		schema.PrimaryKey,
 	)
 
-	rows, err := q.QueryContext(ctx, query, args...)
+	rows, err := q.QueryContext(ctx, query, b.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -64,20 +82,34 @@ func Insert(ctx context.Context, q Querier, schema Schema, payload map[string]an
 	return pk, nil
 }
 
-func FindByPK(ctx context.Context, q Querier, schema Schema, pk any) (map[string]any, error) {
-	cols := schema.Columns
-	if len(cols) == 0 {
-		cols = []string{schema.PrimaryKey}
+// stampCreated fills created_at/updated_at on insert unless the payload set them.
+func (s Schema) stampCreated(data map[string]any) {
+	if !s.Timestamps {
+		return
+	}
+	now := s.Now().UTC()
+	for _, col := range []string{"created_at", "updated_at"} {
+		if !s.hasColumn(col) {
+			continue
+		}
+		if _, ok := data[col]; !ok {
+			data[col] = now
+		}
 	}
+}
 
+func FindByPK(ctx context.Context, q Querier, schema Schema, pk any) (map[string]any, error) {
+	cols := schema.defaultSelectList()
+
+	b := newSQLBuilder()
 	query := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE %s = $1 LIMIT 1",
+		"SELECT %s FROM %s WHERE %s LIMIT 1",
 		strings.Join(cols, ","),
 		schema.Table,
-		schema.PrimaryKey,
+		b.eq(schema.PrimaryKey, pk),
 	)
 
-	rows, err := q.QueryContext(ctx, query, pk)
+	rows, err := q.QueryContext(ctx, query, b.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +119,7 @@ func FindByPK(ctx context.Context, q Querier, schema Schema, pk any) (map[string
 		return nil, &NotFoundError{Table: schema.Table, PK: pk}
 	}
 
-	m, err := scanCurrentRowToMap(rows)
+	m, err := scanOutputRow(rows, schema.Casts)
 	if err != nil {
 		return nil, err
 	}
@@ -95,19 +127,18 @@ func FindByPK(ctx context.Context, q Querier, schema Schema, pk any) (map[string
 }
 
 func FindByPKAndCompanyID(ctx context.Context, q Querier, schema Schema, pk any, companyID int64) (map[string]any, error) {
-	cols := schema.Columns
-	if len(cols) == 0 {
-		cols = []string{schema.PrimaryKey}
-	}
+	cols := schema.defaultSelectList()
 
+	b := newSQLBuilder()
 	query := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE %s = $1 AND company_id = $2 LIMIT 1",
+		"SELECT %s FROM %s WHERE %s AND %s LIMIT 1",
 		strings.Join(cols, ","),
 		schema.Table,
-		schema.PrimaryKey,
+		b.eq(schema.PrimaryKey, pk),
+		b.eq("company_id", companyID),
 	)
 
-	rows, err := q.QueryContext(ctx, query, pk, companyID)
+	rows, err := q.QueryContext(ctx, query, b.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +149,7 @@ func FindByPKAndCompanyID(ctx context.Context, q Querier, schema Schema, pk any,
 		return nil, &NotFoundError{Table: schema.Table, PK: pk}
 	}
 
-	m, err := scanCurrentRowToMap(rows)
+	m, err := scanOutputRow(rows, schema.Casts)
 	if err != nil {
 		return nil, err
 	}
@@ -126,27 +157,28 @@ func FindByPKAndCompanyID(ctx context.Context, q Querier, schema Schema, pk any,
 }
 
 // FindByPKAndTenant finds a record by primary key within a tenant boundary.
-// tenantCol is typically "company_id" (preferred) or "com_id" (legacy).
+// tenantCol is typically "company_id" (preferred) or "com_id" (legacy); it may be empty only
+// for global schemas, which are not tenant-scoped.
 func FindByPKAndTenant(ctx context.Context, q Querier, schema Schema, pk any, tenantCol string, tenantID int64) (map[string]any, error) {
-	tenantCol = strings.TrimSpace(tenantCol)
-	if tenantCol == "" {
-		return nil, &ValidationError{Errors: map[string]string{"tenant": "tenant column required"}}
+	b := newSQLBuilder()
+	where := []string{b.eq(schema.PrimaryKey, pk)}
+	tenantCond, verr := schema.tenantScope(b, tenantCol, tenantID)
+	if verr != nil {
+		return nil, verr
 	}
-
-	cols := schema.Columns
-	if len(cols) == 0 {
-		cols = []string{schema.PrimaryKey}
+	if tenantCond != "" {
+		where = append(where, tenantCond)
 	}
 
+	cols := schema.defaultSelectList()
 	query := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE %s = $1 AND %s = $2 LIMIT 1",
+		"SELECT %s FROM %s WHERE %s LIMIT 1",
 		strings.Join(cols, ","),
 		schema.Table,
-		schema.PrimaryKey,
-		tenantCol,
+		strings.Join(where, " AND "),
 	)
 
-	rows, err := q.QueryContext(ctx, query, pk, tenantID)
+	rows, err := q.QueryContext(ctx, query, b.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -157,7 +189,7 @@ func FindByPKAndTenant(ctx context.Context, q Querier, schema Schema, pk any, te
 		return nil, &NotFoundError{Table: schema.Table, PK: pk}
 	}
 
-	m, err := scanCurrentRowToMap(rows)
+	m, err := scanOutputRow(rows, schema.Casts)
 	if err != nil {
 		return nil, err
 	}
@@ -166,10 +198,13 @@ func FindByPKAndTenant(ctx context.Context, q Querier, schema Schema, pk any, te
 
 func UpdateByPK(ctx context.Context, q Querier, schema Schema, pk any, payload map[string]any) error {
 	schema = schema.withDefaults()
-	data, verr := schema.normalizePayload(payload)
+	data, verr := schema.normalizePayload(payload, true)
 	if verr != nil {
 		return verr
 	}
+	if err := checkUniqueRules(ctx, q, schema, data, "", nil, pk); err != nil {
+		return err
+	}
 
 	if schema.Timestamps && schema.hasColumn("updated_at") {
 		// Standard Eloquent behavior: updated_at is forced.
@@ -181,21 +216,20 @@ func UpdateByPK(ctx context.Context, q Querier, schema Schema, pk any, payload m
 		return &ValidationError{Errors: map[string]string{"payload": "no fillable fields provided"}}
 	}
 
+	b := newSQLBuilder()
 	setParts := make([]string, 0, len(cols))
 	for i, c := range cols {
-		setParts = append(setParts, fmt.Sprintf("%s = $%d", c, i+1))
+		setParts = append(setParts, b.eq(c, args[i]))
 	}
-	args = append(args, pk)
 
 	query := fmt.Sprintf(
-		"UPDATE %s SET %s WHERE %s = $%d",
+		"UPDATE %s SET %s WHERE %s",
 		schema.Table,
 		strings.Join(setParts, ","),
-		schema.PrimaryKey,
-		len(args),
+		b.eq(schema.PrimaryKey, pk),
 	)
 
-	res, err := q.ExecContext(ctx, query, args...)
+	res, err := q.ExecContext(ctx, query, b.args...)
 	if err != nil {
 		return err
 	}
@@ -208,10 +242,13 @@ func UpdateByPK(ctx context.Context, q Querier, schema Schema, pk any, payload m
 
 func UpdateByPKAndCompanyID(ctx context.Context, q Querier, schema Schema, pk any, companyID int64, payload map[string]any) error {
 	schema = schema.withDefaults()
-	data, verr := schema.normalizePayload(payload)
+	data, verr := schema.normalizePayload(payload, true)
 	if verr != nil {
 		return verr
 	}
+	if err := checkUniqueRules(ctx, q, schema, data, "company_id", companyID, pk); err != nil {
+		return err
+	}
 
 	if schema.Timestamps && schema.hasColumn("updated_at") {
 		// Standard Eloquent behavior: updated_at is forced.
@@ -223,22 +260,21 @@ func UpdateByPKAndCompanyID(ctx context.Context, q Querier, schema Schema, pk an
 		return &ValidationError{Errors: map[string]string{"payload": "no fillable fields provided"}}
 	}
 
+	b := newSQLBuilder()
 	setParts := make([]string, 0, len(cols))
 	for i, c := range cols {
-		setParts = append(setParts, fmt.Sprintf("%s = $%d", c, i+1))
+		setParts = append(setParts, b.eq(c, args[i]))
 	}
-	args = append(args, pk, companyID)
 
 	query := fmt.Sprintf(
-		"UPDATE %s SET %s WHERE %s = $%d AND company_id = $%d",
+		"UPDATE %s SET %s WHERE %s AND %s",
 		schema.Table,
 		strings.Join(setParts, ","),
-		schema.PrimaryKey,
-		len(args)-1,
-		len(args),
+		b.eq(schema.PrimaryKey, pk),
+		b.eq("company_id", companyID),
 	)
 
-	res, err := q.ExecContext(ctx, query, args...)
+	res, err := q.ExecContext(ctx, query, b.args...)
 	if err != nil {
 		return err
 	}
@@ -251,18 +287,26 @@ func UpdateByPKAndCompanyID(ctx context.Context, q Querier, schema Schema, pk an
 }
 
 // UpdateByPKAndTenant updates a record by primary key within a tenant boundary.
-// tenantCol is typically "company_id" (preferred) or "com_id" (legacy).
+// tenantCol is typically "company_id" (preferred) or "com_id" (legacy); it may be empty only
+// for global schemas.
 func UpdateByPKAndTenant(ctx context.Context, q Querier, schema Schema, pk any, tenantCol string, tenantID int64, payload map[string]any) error {
 	schema = schema.withDefaults()
 	tenantCol = strings.TrimSpace(tenantCol)
-	if tenantCol == "" {
-		return &ValidationError{Errors: map[string]string{"tenant": "tenant column required"}}
+	if _, verr := schema.tenantScope(newSQLBuilder(), tenantCol, tenantID); verr != nil {
+		return verr
+	}
+	var tenantArg any
+	if tenantCol != "" {
+		tenantArg = tenantID
 	}
 
-	data, verr := schema.normalizePayload(payload)
+	data, verr := schema.normalizePayload(payload, true)
 	if verr != nil {
 		return verr
 	}
+	if err := checkUniqueRules(ctx, q, schema, data, tenantCol, tenantArg, pk); err != nil {
+		return err
+	}
 
 	if schema.Timestamps && schema.hasColumn("updated_at") {
 		// Standard Eloquent behavior: updated_at is forced.
@@ -274,23 +318,24 @@ func UpdateByPKAndTenant(ctx context.Context, q Querier, schema Schema, pk any,
 		return &ValidationError{Errors: map[string]string{"payload": "no fillable fields provided"}}
 	}
 
+	b := newSQLBuilder()
 	setParts := make([]string, 0, len(cols))
 	for i, c := range cols {
-		setParts = append(setParts, fmt.Sprintf("%s = $%d", c, i+1))
+		setParts = append(setParts, b.eq(c, args[i]))
 	}
-	args = append(args, pk, tenantID)
 
+	where := []string{b.eq(schema.PrimaryKey, pk)}
+	if tenantCond, _ := schema.tenantScope(b, tenantCol, tenantID); tenantCond != "" {
+		where = append(where, tenantCond)
+	}
 	query := fmt.Sprintf(
-		"UPDATE %s SET %s WHERE %s = $%d AND %s = $%d",
+		"UPDATE %s SET %s WHERE %s",
 		schema.Table,
 		strings.Join(setParts, ","),
-		schema.PrimaryKey,
-		len(args)-1,
-		tenantCol,
-		len(args),
+		strings.Join(where, " AND "),
 	)
 
-	res, err := q.ExecContext(ctx, query, args...)
+	res, err := q.ExecContext(ctx, query, b.args...)
 	if err != nil {
 		return err
 	}
@@ -303,8 +348,9 @@ func UpdateByPKAndTenant(ctx context.Context, q Querier, schema Schema, pk any,
 }
 
 func DeleteByPK(ctx context.Context, q Querier, schema Schema, pk any) error {
-	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1", schema.Table, schema.PrimaryKey)
-	res, err := q.ExecContext(ctx, query, pk)
+	b := newSQLBuilder()
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", schema.Table, b.eq(schema.PrimaryKey, pk))
+	res, err := q.ExecContext(ctx, query, b.args...)
 	if err != nil {
 		return err
 	}
@@ -316,8 +362,9 @@ func DeleteByPK(ctx context.Context, q Querier, schema Schema, pk any) error {
 }
 
 func DeleteByPKAndCompanyID(ctx context.Context, q Querier, schema Schema, pk any, companyID int64) error {
-	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND company_id = $2", schema.Table, schema.PrimaryKey)
-	res, err := q.ExecContext(ctx, query, pk, companyID)
+	b := newSQLBuilder()
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s AND %s", schema.Table, b.eq(schema.PrimaryKey, pk), b.eq("company_id", companyID))
+	res, err := q.ExecContext(ctx, query, b.args...)
 	if err != nil {
 		return err
 	}
@@ -330,14 +377,20 @@ func DeleteByPKAndCompanyID(ctx context.Context, q Querier, schema Schema, pk an
 }
 
 // DeleteByPKAndTenant deletes a record by primary key within a tenant boundary.
-// tenantCol is typically "company_id" (preferred) or "com_id" (legacy).
+// tenantCol is typically "company_id" (preferred) or "com_id" (legacy); it may be empty only
+// for global schemas.
 func DeleteByPKAndTenant(ctx context.Context, q Querier, schema Schema, pk any, tenantCol string, tenantID int64) error {
-	tenantCol = strings.TrimSpace(tenantCol)
-	if tenantCol == "" {
-		return &ValidationError{Errors: map[string]string{"tenant": "tenant column required"}}
+	b := newSQLBuilder()
+	where := []string{b.eq(schema.PrimaryKey, pk)}
+	tenantCond, verr := schema.tenantScope(b, tenantCol, tenantID)
+	if verr != nil {
+		return verr
+	}
+	if tenantCond != "" {
+		where = append(where, tenantCond)
 	}
-	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND %s = $2", schema.Table, schema.PrimaryKey, tenantCol)
-	res, err := q.ExecContext(ctx, query, pk, tenantID)
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", schema.Table, strings.Join(where, " AND "))
+	res, err := q.ExecContext(ctx, query, b.args...)
 	if err != nil {
 		return err
 	}
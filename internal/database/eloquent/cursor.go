@@ -0,0 +1,333 @@
+package eloquent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mylab-api-go/internal/database/dialect"
+)
+
+// Pagination modes for SelectRequest.Pagination.
+const (
+	PaginationOffset = "offset"
+	PaginationCursor = "cursor"
+)
+
+// Count modes for SelectRequest.Count.
+//
+// - exact:    SELECT COUNT(*) (default for offset pagination)
+// - estimate: the planner's row estimate from EXPLAIN (cheap, approximate)
+// - none:     no count at all (default for cursor pagination)
+const (
+	CountExact    = "exact"
+	CountEstimate = "estimate"
+	CountNone     = "none"
+)
+
+// keysetColumn is one column of the cursor keyset: the order_by columns followed by the PK.
+type keysetColumn struct {
+	name string
+	expr string
+	desc bool
+}
+
+// keysetColumns validates order_by for cursor mode and appends the primary key as tie-breaker.
+func keysetColumns(schema Schema, orderBy []OrderBy) ([]keysetColumn, *ValidationError) {
+	errs := map[string]string{}
+	out := make([]keysetColumn, 0, len(orderBy)+1)
+	seen := map[string]bool{}
+	for i, ob := range orderBy {
+		field := strings.TrimSpace(resolveAlias(schema, ob.Field))
+		key := fmt.Sprintf("order_by[%d].field", i)
+		if field == "" {
+			errs[key] = "required"
+			continue
+		}
+		if !schema.isSelectable(field) {
+			errs[key] = "unknown field"
+			continue
+		}
+		// Hidden columns cannot be sort keys (and cursor values are readable by the client).
+		if schema.IsHidden(field) {
+			errs[key] = "hidden field"
+			continue
+		}
+		dir := strings.ToLower(strings.TrimSpace(ob.Dir))
+		if dir == "" {
+			dir = "asc"
+		}
+		if dir != "asc" && dir != "desc" {
+			errs[fmt.Sprintf("order_by[%d].dir", i)] = "must be asc or desc"
+			continue
+		}
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		out = append(out, keysetColumn{name: field, expr: schema.columnExpr(field), desc: dir == "desc"})
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+	if !seen[schema.PrimaryKey] {
+		out = append(out, keysetColumn{name: schema.PrimaryKey, expr: schema.PrimaryKey})
+	}
+	return out, nil
+}
+
+// keysetOrderBy renders ORDER BY for the keyset; reverse flips every direction (prev page).
+// Default NULL placement (Postgres: ASC NULLS LAST, MySQL: ASC NULLS FIRST) flips consistently
+// with the direction on both dialects.
+func keysetOrderBy(cols []keysetColumn, reverse bool) string {
+	parts := make([]string, 0, len(cols))
+	for _, c := range cols {
+		desc := c.desc != reverse
+		if desc {
+			parts = append(parts, c.expr+" DESC")
+		} else {
+			parts = append(parts, c.expr+" ASC")
+		}
+	}
+	return " ORDER BY " + strings.Join(parts, ",")
+}
+
+// keysetWhere renders "rows strictly after values" in the (possibly reversed) keyset order:
+//
+//	(c1 > v1) OR (c1 = v1 AND c2 > v2) OR ...
+//
+// with NULL handling matching the dialect's default NULL ordering.
+func keysetWhere(b *sqlBuilder, cols []keysetColumn, values []any, reverse bool) string {
+	ors := make([]string, 0, len(cols))
+	eqs := make([]string, 0, len(cols))
+	for i, c := range cols {
+		v := values[i]
+		desc := c.desc != reverse
+		nullsFirst := desc != b.d.NullsFirst()
+
+		cmp := ">"
+		if desc {
+			cmp = "<"
+		}
+		var gt string
+		switch {
+		case v == nil && nullsFirst:
+			gt = c.expr + " IS NOT NULL" // everything non-NULL comes after a leading NULL
+		case v == nil:
+			gt = "" // NULLs come last: nothing is after NULL
+		case nullsFirst:
+			gt = fmt.Sprintf("%s %s %s", c.expr, cmp, b.push(v))
+		default:
+			gt = fmt.Sprintf("(%s %s %s OR %s IS NULL)", c.expr, cmp, b.push(v), c.expr)
+		}
+		if gt != "" {
+			ors = append(ors, "("+strings.Join(append(append([]string{}, eqs...), gt), " AND ")+")")
+		}
+
+		if v == nil {
+			eqs = append(eqs, c.expr+" IS NULL")
+		} else {
+			eqs = append(eqs, fmt.Sprintf("%s = %s", c.expr, b.push(v)))
+		}
+	}
+	if len(ors) == 0 {
+		return "FALSE"
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+func keysetFingerprint(cols []keysetColumn) string {
+	h := fnv.New32a()
+	for _, c := range cols {
+		dir := "a"
+		if c.desc {
+			dir = "d"
+		}
+		_, _ = h.Write([]byte(c.name + ":" + dir + ","))
+	}
+	return strconv.FormatUint(uint64(h.Sum32()), 36)
+}
+
+// pageCursor is the decoded form of an opaque cursor.
+// dir is 'n' (rows after values) or 'p' (rows before values).
+type pageCursor struct {
+	dir         byte
+	fingerprint string
+	values      []any
+}
+
+const cursorVersion = "c1"
+
+// encodeCursor serializes typed keyset values as length-prefixed fields, base64url encoded.
+func encodeCursor(c pageCursor) string {
+	var sb strings.Builder
+	sb.WriteString(cursorVersion)
+	sb.WriteByte('|')
+	sb.WriteByte(c.dir)
+	sb.WriteByte('|')
+	sb.WriteString(c.fingerprint)
+	sb.WriteByte('|')
+	for _, v := range c.values {
+		tag, s := cursorField(v)
+		sb.WriteByte(tag)
+		sb.WriteString(strconv.Itoa(len(s)))
+		sb.WriteByte(':')
+		sb.WriteString(s)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(sb.String()))
+}
+
+func cursorField(v any) (byte, string) {
+	switch t := v.(type) {
+	case nil:
+		return 'n', ""
+	case int64:
+		return 'i', strconv.FormatInt(t, 10)
+	case int32:
+		return 'i', strconv.FormatInt(int64(t), 10)
+	case int:
+		return 'i', strconv.Itoa(t)
+	case float64:
+		return 'f', strconv.FormatFloat(t, 'g', -1, 64)
+	case float32:
+		return 'f', strconv.FormatFloat(float64(t), 'g', -1, 32)
+	case bool:
+		return 'b', strconv.FormatBool(t)
+	case time.Time:
+		return 't', t.Format(time.RFC3339Nano)
+	case []byte:
+		return 's', string(t)
+	case string:
+		return 's', t
+	default:
+		return 's', fmt.Sprint(v)
+	}
+}
+
+func decodeCursor(raw string) (pageCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return pageCursor{}, err
+	}
+	s := string(b)
+	parts := strings.SplitN(s, "|", 4)
+	if len(parts) != 4 || parts[0] != cursorVersion || len(parts[1]) != 1 || (parts[1][0] != 'n' && parts[1][0] != 'p') {
+		return pageCursor{}, fmt.Errorf("malformed cursor")
+	}
+	c := pageCursor{dir: parts[1][0], fingerprint: parts[2]}
+	rest := parts[3]
+	for rest != "" {
+		tag := rest[0]
+		colon := strings.IndexByte(rest, ':')
+		if colon < 2 {
+			return pageCursor{}, fmt.Errorf("malformed cursor")
+		}
+		n, err := strconv.Atoi(rest[1:colon])
+		if err != nil || n < 0 || colon+1+n > len(rest) {
+			return pageCursor{}, fmt.Errorf("malformed cursor")
+		}
+		field := rest[colon+1 : colon+1+n]
+		rest = rest[colon+1+n:]
+
+		var v any
+		switch tag {
+		case 'n':
+			v = nil
+		case 's':
+			v = field
+		case 'i':
+			v, err = strconv.ParseInt(field, 10, 64)
+		case 'f':
+			v, err = strconv.ParseFloat(field, 64)
+		case 'b':
+			v, err = strconv.ParseBool(field)
+		case 't':
+			v, err = time.Parse(time.RFC3339Nano, field)
+		default:
+			err = fmt.Errorf("unknown field type")
+		}
+		if err != nil {
+			return pageCursor{}, fmt.Errorf("malformed cursor")
+		}
+		c.values = append(c.values, v)
+	}
+	return c, nil
+}
+
+func cursorFromRow(dir byte, fingerprint string, cols []keysetColumn, row map[string]any) string {
+	values := make([]any, len(cols))
+	for i, c := range cols {
+		values[i] = row[c.name]
+	}
+	return encodeCursor(pageCursor{dir: dir, fingerprint: fingerprint, values: values})
+}
+
+// selectListName returns the output column name of a SELECT list entry ("expr AS name" or "name").
+func selectListName(entry string) string {
+	if i := strings.LastIndex(entry, " AS "); i >= 0 {
+		return strings.TrimSpace(entry[i+4:])
+	}
+	return strings.TrimSpace(entry)
+}
+
+var explainRowsRE = regexp.MustCompile(`rows=(\d+)`)
+
+// estimateCount returns the planner's row estimate for the filtered table (EXPLAIN).
+func estimateCount(ctx context.Context, q Querier, d dialect.Dialect, table, where string, args []any) (int, error) {
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("EXPLAIN SELECT 1 FROM %s WHERE %s", table, where), args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("explain returned no rows")
+	}
+	if d.Name() == dialect.MySQLName {
+		return mysqlExplainRows(rows)
+	}
+	var line string
+	if err := rows.Scan(&line); err != nil {
+		return 0, err
+	}
+	m := explainRowsRE.FindStringSubmatch(line)
+	if m == nil {
+		return 0, fmt.Errorf("explain: row estimate not found")
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// mysqlExplainRows reads the tabular MySQL EXPLAIN output: rows scanned times filtered%.
+func mysqlExplainRows(rows *sql.Rows) (int, error) {
+	m, err := scanCurrentRowToMap(rows)
+	if err != nil {
+		return 0, err
+	}
+	n, err := explainNumber(m["rows"])
+	if err != nil {
+		return 0, fmt.Errorf("explain: row estimate not found")
+	}
+	if f, err := explainNumber(m["filtered"]); err == nil && f > 0 && f < 100 {
+		n = n * f / 100
+	}
+	return int(n), nil
+}
+
+func explainNumber(v any) (float64, error) {
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	return strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(v)), 64)
+}
@@ -0,0 +1,72 @@
+package eloquent
+
+import (
+	"testing"
+	"time"
+
+	"mylab-api-go/internal/database/dialect"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	in := pageCursor{dir: 'n', fingerprint: "abc", values: []any{"a|b:c", int64(42), nil, 1.5, true, ts}}
+	out, err := decodeCursor(encodeCursor(in))
+	if err != nil {
+		t.Fatalf("decode err: %v", err)
+	}
+	if out.dir != 'n' || out.fingerprint != "abc" || len(out.values) != len(in.values) {
+		t.Fatalf("unexpected cursor: %#v", out)
+	}
+	if out.values[0] != "a|b:c" || out.values[1] != int64(42) || out.values[2] != nil || out.values[3] != 1.5 || out.values[4] != true {
+		t.Fatalf("unexpected values: %#v", out.values)
+	}
+	if got := out.values[5].(time.Time); !got.Equal(ts) {
+		t.Fatalf("unexpected time: %v", got)
+	}
+
+	if _, err := decodeCursor("not-a-cursor"); err == nil {
+		t.Fatalf("expected error for garbage cursor")
+	}
+}
+
+func TestKeysetWhere(t *testing.T) {
+	s := Schema{Table: "pasien", PrimaryKey: "kd_ps", Columns: []string{"kd_ps", "nama_ps", "tgl_daftar"}}
+	cols, verr := keysetColumns(s, []OrderBy{{Field: "tgl_daftar", Dir: "desc"}})
+	if verr != nil {
+		t.Fatalf("keysetColumns: %#v", verr)
+	}
+	if len(cols) != 2 || cols[1].name != "kd_ps" {
+		t.Fatalf("expected pk appended, got %#v", cols)
+	}
+
+	b := newSQLBuilder()
+	got := keysetWhere(b, cols, []any{"2024-01-01", "P9"}, false)
+	want := "((tgl_daftar < $1) OR (tgl_daftar = $2 AND (kd_ps > $3 OR kd_ps IS NULL)))"
+	if got != want {
+		t.Fatalf("keysetWhere:\n got %s\nwant %s", got, want)
+	}
+	if got := keysetOrderBy(cols, true); got != " ORDER BY tgl_daftar ASC,kd_ps DESC" {
+		t.Fatalf("reversed order: %s", got)
+	}
+
+	// NULL key in an ASC column: only ties on NULL can follow.
+	b = newSQLBuilder()
+	cols = []keysetColumn{{name: "nama_ps", expr: "nama_ps"}, {name: "kd_ps", expr: "kd_ps"}}
+	got = keysetWhere(b, cols, []any{nil, "P1"}, false)
+	want = "((nama_ps IS NULL AND (kd_ps > $1 OR kd_ps IS NULL)))"
+	if got != want {
+		t.Fatalf("keysetWhere null:\n got %s\nwant %s", got, want)
+	}
+
+	// MySQL sorts NULLs first in ASC: every non-NULL value follows a NULL key.
+	b = &sqlBuilder{d: dialect.MySQL}
+	got = keysetWhere(b, cols, []any{nil, "P1"}, false)
+	want = "((nama_ps IS NOT NULL) OR (nama_ps IS NULL AND kd_ps > ?))"
+	if got != want {
+		t.Fatalf("keysetWhere mysql:\n got %s\nwant %s", got, want)
+	}
+
+	if keysetFingerprint(cols) == keysetFingerprint([]keysetColumn{{name: "nama_ps", desc: true}, {name: "kd_ps"}}) {
+		t.Fatalf("fingerprint must depend on direction")
+	}
+}
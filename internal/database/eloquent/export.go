@@ -0,0 +1,182 @@
+package eloquent
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportKind is how an exported column should be rendered (matches spreadsheet.Kind).
+type ExportKind string
+
+const (
+	ExportText     ExportKind = "text"
+	ExportNumber   ExportKind = "number"
+	ExportBool     ExportKind = "bool"
+	ExportDate     ExportKind = "date"
+	ExportDateTime ExportKind = "datetime"
+	ExportTime     ExportKind = "time"
+)
+
+// ExportColumn is one column of a streamed result.
+type ExportColumn struct {
+	Name string
+	Kind ExportKind
+}
+
+// RowSink receives a streamed result: Columns once (before any row), then Row per row.
+// Values are nil, string, int64, float64, bool, Number (exact decimals) or time.Time
+// (datetimes already in the requested zone).
+type RowSink interface {
+	Columns(cols []ExportColumn) error
+	Row(values []any) error
+}
+
+// StreamRows renders rows like ScanRows but hands them to sink one at a time instead of
+// collecting them. Datetimes are converted to loc (nil = OUTPUT_TIMEZONE / server zone).
+func StreamRows(rows *sql.Rows, casts map[string]CastType, loc *time.Location, sink RowSink) error {
+	s, err := newRowScanner(rows, casts)
+	if err != nil {
+		return err
+	}
+	if loc == nil {
+		loc = s.opts.Location
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	cols := make([]ExportColumn, len(s.cols))
+	for i, c := range s.cols {
+		cols[i] = ExportColumn{Name: c, Kind: exportKind(s.kinds[i])}
+	}
+	if err := sink.Columns(cols); err != nil {
+		return err
+	}
+
+	values := make([]any, len(s.cols))
+	ptrs := make([]any, len(s.cols))
+	out := make([]any, len(s.cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			out[i] = exportValue(s.kinds[i], s.elems[i], v, s.opts, loc)
+		}
+		if err := sink.Row(out); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SelectStream runs req's filters, order_by and select list without paging and streams every
+// matching row to sink. More than maxRows matches (0 = no limit) is a validation error, reported
+// before anything reaches sink.
+func SelectStream(ctx context.Context, q Querier, schema Schema, companyID int64, req SelectRequest, maxRows int, loc *time.Location, sink RowSink) error {
+	schema = schema.withDefaults()
+	if companyID <= 0 {
+		return &ValidationError{Errors: map[string]string{"company_id": "invalid"}}
+	}
+	selectCols, verr := normalizeSelect(schema, req.Select)
+	if verr != nil {
+		return verr
+	}
+	builder := newSQLBuilder()
+	whereParts, verr := buildSelectWhere(schema, builder, companyID, req)
+	if verr != nil {
+		return verr
+	}
+	orderBySQL, verr := buildOrderBy(schema, req.OrderBy)
+	if verr != nil {
+		return verr
+	}
+	whereSQL := strings.Join(whereParts, " AND ")
+
+	if maxRows > 0 {
+		n, err := exactCount(ctx, q, schema.Table, whereSQL, builder.args)
+		if err != nil {
+			return err
+		}
+		if n > maxRows {
+			return &ValidationError{Errors: map[string]string{"export": fmt.Sprintf("%d rows match; exports are limited to %d (narrow the filters)", n, maxRows)}}
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s%s", strings.Join(selectCols, ","), schema.Table, whereSQL, orderBySQL)
+	rows, err := q.QueryContext(ctx, query, builder.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return StreamRows(rows, schema.Casts, loc, sink)
+}
+
+func exportKind(k outputKind) ExportKind {
+	switch k {
+	case outputInt, outputFloat, outputDecimal:
+		return ExportNumber
+	case outputBool:
+		return ExportBool
+	case outputDate:
+		return ExportDate
+	case outputTime:
+		return ExportTime
+	case outputDateTime, outputWallClock:
+		return ExportDateTime
+	default:
+		return ExportText
+	}
+}
+
+// exportValue is formatOutputElem for spreadsheets: typed numbers, booleans and times, text
+// for everything else (JSON as JSON text, arrays comma-separated).
+func exportValue(kind, elem outputKind, v any, opts OutputOptions, loc *time.Location) any {
+	if v == nil {
+		return nil
+	}
+	switch kind {
+	case outputDecimal:
+		return Number(outputString(v))
+	case outputDate:
+		if t, ok := v.(time.Time); ok {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		if t, err := time.Parse("2006-01-02", outputString(v)); err == nil {
+			return t
+		}
+	case outputDateTime:
+		if t, ok := v.(time.Time); ok {
+			return t.In(loc)
+		}
+	case outputWallClock:
+		// Stored without a zone: the wall clock already is local time.
+		if t, ok := v.(time.Time); ok {
+			return t
+		}
+	}
+	out := formatOutputElem(kind, elem, v, opts)
+	switch t := out.(type) {
+	case nil, string, int64, float64, bool, time.Time:
+		return t
+	case Number:
+		return t
+	case RawJSON:
+		return string(t)
+	case []any:
+		parts := make([]string, len(t))
+		for i, item := range t {
+			if item != nil {
+				parts[i] = fmt.Sprint(item)
+			}
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,103 @@
+package eloquent
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeDB is a database/sql backend for tests: it records every statement with its args and
+// answers queries with the first canned result whose match is a substring of the query.
+type fakeDB struct {
+	results []fakeResult
+	stmts   []fakeStmt
+	lastID  int64 // incremented by every Exec, returned as LastInsertId
+}
+
+type fakeResult struct {
+	match string
+	cols  []string
+	rows  [][]driver.Value
+}
+
+type fakeStmt struct {
+	sql  string
+	args []any
+}
+
+func (f *fakeDB) open(t *testing.T) *sql.DB {
+	db := sql.OpenDB(fakeConnector{f})
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// stmt returns the first recorded statement containing match.
+func (f *fakeDB) stmt(t *testing.T, match string) fakeStmt {
+	t.Helper()
+	for _, s := range f.stmts {
+		if strings.Contains(s.sql, match) {
+			return s
+		}
+	}
+	t.Fatalf("no statement contains %q; ran %v", match, f.stmts)
+	return fakeStmt{}
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) {
+	s := fakeStmt{sql: query}
+	for _, a := range args {
+		s.args = append(s.args, a.Value)
+	}
+	f.stmts = append(f.stmts, s)
+}
+
+type fakeConnector struct{ f *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ f *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.f.record(query, args)
+	for _, r := range c.f.results {
+		if strings.Contains(query, r.match) {
+			return &fakeRows{cols: r.cols, rows: r.rows}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.f.record(query, args)
+	c.f.lastID++
+	return fakeExecResult{id: c.f.lastID}, nil
+}
+
+type fakeExecResult struct{ id int64 }
+
+func (r fakeExecResult) LastInsertId() (int64, error) { return r.id, nil }
+func (r fakeExecResult) RowsAffected() (int64, error) { return 1, nil }
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,252 @@
+package eloquent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Filter is one node of SelectRequest.Filters: either a group (exactly one of And/Or) or a
+// condition (Field/Op/Value). Example:
+//
+//	{"and": [
+//	  {"field": "umur", "op": "gte", "value": 18},
+//	  {"or": [{"field": "jk", "op": "in", "value": ["L", "P"]}, {"field": "kd_dr", "op": "null"}]}
+//	]}
+type Filter struct {
+	And   []Filter `json:"and"`
+	Or    []Filter `json:"or"`
+	Field string   `json:"field"`
+	Op    string   `json:"op"`
+	Value any      `json:"value"`
+}
+
+const (
+	FilterEq         = "eq"
+	FilterNe         = "ne"
+	FilterGt         = "gt"
+	FilterGte        = "gte"
+	FilterLt         = "lt"
+	FilterLte        = "lte"
+	FilterBetween    = "between"
+	FilterIn         = "in"
+	FilterNotIn      = "not_in"
+	FilterNull       = "null"
+	FilterNotNull    = "not_null"
+	FilterLike       = "like"
+	FilterStartsWith = "starts_with"
+	FilterEndsWith   = "ends_with"
+)
+
+const (
+	maxFilterDepth      = 8
+	maxFilterConditions = 100
+	maxFilterInValues   = 1000
+)
+
+var filterComparisons = map[string]string{
+	FilterEq:  "=",
+	FilterNe:  "<>",
+	FilterGt:  ">",
+	FilterGte: ">=",
+	FilterLt:  "<",
+	FilterLte: "<=",
+}
+
+// buildFilters renders a filter tree into a parenthesized SQL condition using b for arguments.
+// Field names go through alias resolution; values are cast with the schema's Casts.
+func buildFilters(schema Schema, b *sqlBuilder, root Filter) (string, *ValidationError) {
+	fb := filterBuilder{schema: schema, b: b, errs: map[string]string{}}
+	sql := fb.node(root, "filters", 0)
+	if len(fb.errs) > 0 {
+		return "", &ValidationError{Errors: fb.errs}
+	}
+	return sql, nil
+}
+
+type filterBuilder struct {
+	schema     Schema
+	b          *sqlBuilder
+	errs       map[string]string
+	conditions int
+}
+
+func (fb *filterBuilder) node(f Filter, path string, depth int) string {
+	if depth > maxFilterDepth {
+		fb.errs[path] = fmt.Sprintf("nesting too deep (max %d)", maxFilterDepth)
+		return ""
+	}
+	isGroup := f.And != nil || f.Or != nil
+	isCond := strings.TrimSpace(f.Field) != "" || strings.TrimSpace(f.Op) != ""
+	switch {
+	case isGroup && isCond:
+		fb.errs[path] = "a node is either a group (and/or) or a condition (field/op)"
+		return ""
+	case f.And != nil && f.Or != nil:
+		fb.errs[path] = "use either and or or, not both"
+		return ""
+	case f.And != nil:
+		return fb.group(f.And, path+".and", " AND ", depth)
+	case f.Or != nil:
+		return fb.group(f.Or, path+".or", " OR ", depth)
+	case isCond:
+		return fb.condition(f, path)
+	default:
+		fb.errs[path] = "empty filter"
+		return ""
+	}
+}
+
+func (fb *filterBuilder) group(children []Filter, path, sep string, depth int) string {
+	if len(children) == 0 {
+		fb.errs[path] = "must not be empty"
+		return ""
+	}
+	parts := make([]string, 0, len(children))
+	for i, child := range children {
+		if sql := fb.node(child, fmt.Sprintf("%s[%d]", path, i), depth+1); sql != "" {
+			parts = append(parts, sql)
+		}
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+func (fb *filterBuilder) condition(f Filter, path string) string {
+	fb.conditions++
+	if fb.conditions > maxFilterConditions {
+		fb.errs["filters"] = fmt.Sprintf("too many conditions (max %d)", maxFilterConditions)
+		return ""
+	}
+
+	field := strings.TrimSpace(f.Field)
+	if field == "" {
+		fb.errs[path+".field"] = "required"
+		return ""
+	}
+	col := resolveAlias(fb.schema, field)
+	if !fb.schema.isSelectable(col) {
+		fb.errs[path+".field"] = "unknown field"
+		return ""
+	}
+	expr := fb.schema.columnExpr(col)
+	op := strings.ToLower(strings.TrimSpace(f.Op))
+
+	// Hidden columns only take exact matches: ranges and patterns reveal the value piecewise.
+	if fb.schema.IsHidden(col) {
+		switch op {
+		case FilterGt, FilterGte, FilterLt, FilterLte, FilterBetween, FilterLike, FilterStartsWith, FilterEndsWith:
+			fb.errs[path+".field"] = "hidden field"
+			return ""
+		}
+	}
+
+	if cmp, ok := filterComparisons[op]; ok {
+		v, ok := fb.scalar(col, f.Value, path+".value")
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("%s %s %s", expr, cmp, fb.b.push(v))
+	}
+
+	switch op {
+	case FilterNull:
+		return expr + " IS NULL"
+	case FilterNotNull:
+		return expr + " IS NOT NULL"
+	case FilterBetween:
+		vals, ok := fb.list(col, f.Value, path)
+		if !ok {
+			return ""
+		}
+		if len(vals) != 2 {
+			fb.errs[path+".value"] = "must be an array of 2 values"
+			return ""
+		}
+		return fmt.Sprintf("%s BETWEEN %s AND %s", expr, fb.b.push(vals[0]), fb.b.push(vals[1]))
+	case FilterIn, FilterNotIn:
+		vals, ok := fb.list(col, f.Value, path)
+		if !ok {
+			return ""
+		}
+		if len(vals) == 0 {
+			fb.errs[path+".value"] = "must not be empty"
+			return ""
+		}
+		if len(vals) > maxFilterInValues {
+			fb.errs[path+".value"] = fmt.Sprintf("too many values (max %d)", maxFilterInValues)
+			return ""
+		}
+		ph := make([]string, 0, len(vals))
+		for _, v := range vals {
+			ph = append(ph, fb.b.push(v))
+		}
+		kw := "IN"
+		if op == FilterNotIn {
+			kw = "NOT IN"
+		}
+		return fmt.Sprintf("%s %s (%s)", expr, kw, strings.Join(ph, ","))
+	case FilterLike, FilterStartsWith, FilterEndsWith:
+		s, ok := f.Value.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			fb.errs[path+".value"] = "must be a non-empty string"
+			return ""
+		}
+		pattern := normalizeLikePattern(s)
+		if op == FilterStartsWith {
+			pattern = escapeLike(s) + "%"
+		}
+		if op == FilterEndsWith {
+			pattern = "%" + escapeLike(s)
+		}
+		return fb.b.ilike(expr, pattern)
+	case "":
+		fb.errs[path+".op"] = "required"
+	default:
+		fb.errs[path+".op"] = "unknown operator"
+	}
+	return ""
+}
+
+// scalar casts a single filter value; errors are recorded under key.
+func (fb *filterBuilder) scalar(col string, v any, key string) (any, bool) {
+	if v == nil {
+		fb.errs[key] = "required (use op null / not_null for NULL checks)"
+		return nil, false
+	}
+	if _, isList := v.([]any); isList {
+		fb.errs[key] = "must be a single value"
+		return nil, false
+	}
+	casted, msg := castValue(fb.schema.Casts, col, v)
+	if msg != "" {
+		fb.errs[key] = msg
+		return nil, false
+	}
+	if casted == nil {
+		fb.errs[key] = "required"
+		return nil, false
+	}
+	return casted, true
+}
+
+func (fb *filterBuilder) list(col string, v any, path string) ([]any, bool) {
+	raw, ok := v.([]any)
+	if !ok {
+		fb.errs[path+".value"] = "must be an array"
+		return nil, false
+	}
+	out := make([]any, 0, len(raw))
+	for i, item := range raw {
+		casted, ok := fb.scalar(col, item, fmt.Sprintf("%s.value[%d]", path, i))
+		if !ok {
+			return nil, false
+		}
+		out = append(out, casted)
+	}
+	return out, true
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally (default escape is '\').
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
@@ -0,0 +1,85 @@
+package eloquent
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuildFilters(t *testing.T) {
+	s := Schema{
+		Table:      "pasien",
+		PrimaryKey: "kd_ps",
+		Columns:    []string{"kd_ps", "nama_ps", "jk", "umur", "kd_dr", "tgl_daftar"},
+		Casts:      map[string]CastType{"umur": CastInt, "tgl_daftar": CastDateTime},
+		Aliases:    map[string]string{"nama": "nama_ps"},
+	}
+
+	var f Filter
+	raw := `{"and": [
+		{"field": "umur", "op": "gte", "value": "18"},
+		{"field": "tgl_daftar", "op": "between", "value": ["2024-01-01", "2024-12-31"]},
+		{"or": [
+			{"field": "jk", "op": "in", "value": ["L", "P"]},
+			{"field": "kd_dr", "op": "null"}
+		]},
+		{"field": "nama", "op": "starts_with", "value": "Bu_di"}
+	]}`
+	if err := json.Unmarshal([]byte(raw), &f); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	b := newSQLBuilder()
+	sql, verr := buildFilters(s, b, f)
+	if verr != nil {
+		t.Fatalf("unexpected errors: %#v", verr.Errors)
+	}
+	want := `(umur >= $1 AND tgl_daftar BETWEEN $2 AND $3 AND (jk IN ($4,$5) OR kd_dr IS NULL) AND nama_ps ILIKE $6)`
+	if sql != want {
+		t.Fatalf("sql:\n got %s\nwant %s", sql, want)
+	}
+	if b.args[0] != int64(18) {
+		t.Fatalf("expected umur cast to int64, got %#v", b.args[0])
+	}
+	if b.args[5] != `Bu\_di%` {
+		t.Fatalf("expected escaped prefix pattern, got %#v", b.args[5])
+	}
+
+	bad := Filter{And: []Filter{
+		{Field: "nope", Op: "eq", Value: 1},
+		{Field: "umur", Op: "gt", Value: "x"},
+		{Field: "jk", Op: "in", Value: "L"},
+		{Field: "jk", Op: "regex", Value: "L"},
+		{Or: []Filter{}},
+	}}
+	_, verr = buildFilters(s, newSQLBuilder(), bad)
+	if verr == nil {
+		t.Fatalf("expected validation errors")
+	}
+	for _, k := range []string{"filters.and[0].field", "filters.and[1].value", "filters.and[2].value", "filters.and[3].op", "filters.and[4].or"} {
+		if verr.Errors[k] == "" {
+			t.Fatalf("expected error at %s, got %#v", k, verr.Errors)
+		}
+	}
+}
+
+func TestBuildFiltersHiddenColumns(t *testing.T) {
+	s := Schema{Table: "users", PrimaryKey: "id", Columns: []string{"id", "email", "remember_token"}, Hidden: []string{"remember_token"}}
+
+	// Exact matches are allowed.
+	ok := Filter{And: []Filter{
+		{Field: "remember_token", Op: "eq", Value: "abc"},
+		{Field: "remember_token", Op: "in", Value: []any{"abc", "def"}},
+		{Field: "remember_token", Op: "not_null"},
+	}}
+	if _, verr := buildFilters(s, newSQLBuilder(), ok); verr != nil {
+		t.Fatalf("unexpected errors: %#v", verr.Errors)
+	}
+
+	// Patterns and ranges would leak the value one character at a time.
+	for _, op := range []string{"gt", "gte", "lt", "lte", "between", "like", "starts_with", "ends_with"} {
+		_, verr := buildFilters(s, newSQLBuilder(), Filter{Field: "remember_token", Op: op, Value: []any{"a", "b"}})
+		if verr == nil || verr.Errors["filters.field"] != "hidden field" {
+			t.Fatalf("%s: expected hidden field, got %v", op, verr)
+		}
+	}
+}
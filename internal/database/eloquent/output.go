@@ -0,0 +1,449 @@
+package eloquent
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Output casting: scanned driver values are rendered as JSON-faithful values before they reach
+// a response. The kind of each column comes from the driver's type name (numeric, jsonb, uuid,
+// date, ...); Schema.Casts refine columns the driver reports as generic.
+
+// Decimal rendering modes for OutputOptions.Decimals.
+const (
+	DecimalsAsString = "string" // "12.50" (default; exact and safe for JS clients)
+	DecimalsAsNumber = "number" // 12.50 (exact digits, bare JSON number)
+)
+
+// OutputOptions controls how decimals and datetimes are rendered.
+type OutputOptions struct {
+	Decimals string
+	// Location is the zone datetimes are rendered in; nil means time.Local.
+	Location *time.Location
+}
+
+var (
+	outputMu   sync.RWMutex
+	outputOpts = OutputOptions{Decimals: DecimalsAsString}
+)
+
+// SetOutputOptions sets the process-wide output options (called once at startup).
+func SetOutputOptions(o OutputOptions) {
+	if o.Decimals != DecimalsAsNumber {
+		o.Decimals = DecimalsAsString
+	}
+	outputMu.Lock()
+	outputOpts = o
+	outputMu.Unlock()
+}
+
+func currentOutputOptions() OutputOptions {
+	outputMu.RLock()
+	defer outputMu.RUnlock()
+	return outputOpts
+}
+
+// DecimalsAsNumbers reports whether decimals are rendered as bare JSON numbers (OUTPUT_DECIMALS=number).
+func DecimalsAsNumbers() bool {
+	return currentOutputOptions().Decimals == DecimalsAsNumber
+}
+
+// Number is an exact decimal that marshals as a bare JSON number.
+type Number string
+
+var jsonNumberRE = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+func (n Number) MarshalJSON() ([]byte, error) {
+	if !jsonNumberRE.MatchString(string(n)) {
+		// NaN / Infinity have no JSON number form.
+		return []byte(strconv.Quote(string(n))), nil
+	}
+	return []byte(n), nil
+}
+
+func (n Number) String() string { return string(n) }
+
+// RawJSON is a json/jsonb column value embedded verbatim in the response.
+type RawJSON []byte
+
+func (r RawJSON) MarshalJSON() ([]byte, error) {
+	if len(bytes.TrimSpace(r)) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+type outputKind int
+
+const (
+	outputText outputKind = iota
+	outputInt
+	outputFloat
+	outputBool
+	outputDecimal
+	outputJSON
+	outputUUID
+	outputDate
+	outputTime
+	outputDateTime  // absolute instant (timestamptz): converted to the output zone
+	outputWallClock // timestamp without time zone / MySQL DATETIME: wall clock in the output zone
+	outputBinary
+	outputArray // Postgres array literal {a,b} -> JSON array
+)
+
+// outputKindForDBType maps a driver type name (sql.ColumnType.DatabaseTypeName) to an output kind.
+func outputKindForDBType(name string) outputKind {
+	t := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "UNSIGNED ")
+	if strings.HasPrefix(t, "_") {
+		return outputArray // pgx reports int4[] as _INT4
+	}
+	switch t {
+	case "NUMERIC", "DECIMAL":
+		return outputDecimal
+	case "JSON", "JSONB":
+		return outputJSON
+	case "UUID":
+		return outputUUID
+	case "DATE":
+		return outputDate
+	case "TIME", "TIMETZ":
+		return outputTime
+	case "TIMESTAMPTZ":
+		return outputDateTime
+	case "TIMESTAMP", "DATETIME":
+		return outputWallClock
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY":
+		return outputBinary
+	case "INT2", "INT4", "INT8", "SMALLINT", "INTEGER", "INT", "BIGINT", "MEDIUMINT", "TINYINT", "YEAR":
+		return outputInt
+	case "FLOAT4", "FLOAT8", "FLOAT", "DOUBLE", "REAL":
+		return outputFloat
+	case "BOOL", "BOOLEAN":
+		return outputBool
+	default:
+		return outputText
+	}
+}
+
+// outputKindForCast maps a schema cast to an output kind. The basic casts (int, float, bool,
+// datetime) only apply when the driver reports a generic type; the specific ones (decimal, date,
+// time, json, uuid, array) always win, e.g. JSON kept in a legacy text column.
+func outputKindForCast(ct CastType) (kind outputKind, specific bool) {
+	switch ct.Kind() {
+	case CastInt:
+		return outputInt, false
+	case CastFloat:
+		return outputFloat, false
+	case CastBool:
+		return outputBool, false
+	case CastDateTime:
+		return outputDateTime, false
+	case CastDecimal:
+		return outputDecimal, true
+	case CastDate:
+		return outputDate, true
+	case CastTime:
+		return outputTime, true
+	case CastJSON:
+		return outputJSON, true
+	case CastUUID:
+		return outputUUID, true
+	case CastArray:
+		return outputArray, true
+	default:
+		return outputText, false
+	}
+}
+
+// formatOutput renders one scanned value. Values it cannot interpret are returned unchanged.
+func formatOutput(kind outputKind, v any, opts OutputOptions) any {
+	return formatOutputElem(kind, outputText, v, opts)
+}
+
+// formatOutputElem is formatOutput with the element kind used for arrays.
+func formatOutputElem(kind, elem outputKind, v any, opts OutputOptions) any {
+	if v == nil {
+		return nil
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	switch kind {
+	case outputDecimal:
+		s := outputString(v)
+		if opts.Decimals == DecimalsAsNumber {
+			return Number(s)
+		}
+		return s
+	case outputJSON:
+		switch t := v.(type) {
+		case []byte:
+			return RawJSON(append([]byte(nil), t...))
+		case string:
+			return RawJSON(t)
+		}
+	case outputUUID:
+		switch t := v.(type) {
+		case [16]byte:
+			return formatUUID(t[:])
+		case []byte:
+			if len(t) == 16 {
+				return formatUUID(t)
+			}
+			return strings.ToLower(string(t))
+		case string:
+			return strings.ToLower(t)
+		}
+	case outputDate:
+		if t, ok := v.(time.Time); ok {
+			return t.Format("2006-01-02")
+		}
+		return outputString(v)
+	case outputTime:
+		if t, ok := v.(time.Time); ok {
+			return t.Format("15:04:05.999999")
+		}
+		return outputString(v)
+	case outputDateTime:
+		if t, ok := v.(time.Time); ok {
+			return t.In(loc).Format(time.RFC3339Nano)
+		}
+	case outputWallClock:
+		if t, ok := v.(time.Time); ok {
+			wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+			return wall.Format(time.RFC3339Nano)
+		}
+	case outputBinary:
+		if b, ok := v.([]byte); ok {
+			return base64.StdEncoding.EncodeToString(b)
+		}
+	case outputArray:
+		if s, ok := textValue(v); ok {
+			if items, ok := parsePGArray(s); ok {
+				out := make([]any, len(items))
+				for i, item := range items {
+					if item != nil {
+						out[i] = formatOutput(elem, *item, opts)
+					}
+				}
+				return out
+			}
+			return s
+		}
+	case outputInt:
+		if s, ok := textValue(v); ok {
+			if i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+				return i
+			}
+			return s
+		}
+	case outputFloat:
+		if s, ok := textValue(v); ok {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return f
+			}
+			return s
+		}
+	case outputBool:
+		if s, ok := textValue(v); ok {
+			switch strings.ToLower(strings.TrimSpace(s)) {
+			case "1", "t", "true":
+				return true
+			case "0", "f", "false":
+				return false
+			}
+			return s
+		}
+	}
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+// textValue returns v as text when the driver delivered it as bytes or a string (MySQL text protocol).
+func textValue(v any) (string, bool) {
+	switch t := v.(type) {
+	case []byte:
+		return string(t), true
+	case string:
+		return t, true
+	}
+	return "", false
+}
+
+func outputString(v any) string {
+	switch t := v.(type) {
+	case []byte:
+		return string(t)
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(t), 'f', -1, 32)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func formatUUID(b []byte) string {
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// parsePGArray parses a one-dimensional Postgres array literal ({1,"a b",NULL}).
+// NULL elements are returned as nil; nested arrays are not supported (ok=false).
+func parsePGArray(s string) ([]*string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, false
+	}
+	body := s[1 : len(s)-1]
+	out := []*string{}
+	if body == "" {
+		return out, true
+	}
+	i := 0
+	for {
+		var sb strings.Builder
+		quoted := false
+		if i < len(body) && body[i] == '"' {
+			quoted = true
+			i++
+			for i < len(body) && body[i] != '"' {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				sb.WriteByte(body[i])
+				i++
+			}
+			if i >= len(body) {
+				return nil, false
+			}
+			i++ // closing quote
+		} else {
+			for i < len(body) && body[i] != ',' {
+				if body[i] == '{' || body[i] == '"' {
+					return nil, false
+				}
+				sb.WriteByte(body[i])
+				i++
+			}
+		}
+		item := sb.String()
+		if !quoted && strings.EqualFold(item, "NULL") {
+			out = append(out, nil)
+		} else {
+			out = append(out, &item)
+		}
+		if i >= len(body) {
+			return out, true
+		}
+		if body[i] != ',' {
+			return nil, false
+		}
+		i++
+	}
+}
+
+// rowScanner scans rows into maps; format renders them for output (see formatOutput).
+type rowScanner struct {
+	cols  []string
+	kinds []outputKind
+	elems []outputKind // array element kinds
+	opts  OutputOptions
+}
+
+func newRowScanner(rows *sql.Rows, casts map[string]CastType) (*rowScanner, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	s := &rowScanner{cols: cols, kinds: make([]outputKind, len(cols)), elems: make([]outputKind, len(cols)), opts: currentOutputOptions()}
+	for i, c := range cols {
+		kind, elem := outputText, outputText
+		if i < len(types) {
+			name := types[i].DatabaseTypeName()
+			kind = outputKindForDBType(name)
+			if kind == outputArray {
+				elem = outputKindForDBType(strings.TrimPrefix(name, "_"))
+			}
+		}
+		if ct, ok := casts[c]; ok {
+			if ck, specific := outputKindForCast(ct); specific || kind == outputText {
+				kind = ck
+			}
+			if ct.Kind() == CastArray && ct.Param() != "" {
+				elem, _ = outputKindForCast(CastType(ct.Param()))
+			}
+		}
+		s.kinds[i] = kind
+		s.elems[i] = elem
+	}
+	return s, nil
+}
+
+// scan reads the current row without formatting (raw driver values).
+func (s *rowScanner) scan(rows *sql.Rows) (map[string]any, error) {
+	values := make([]any, len(s.cols))
+	ptrs := make([]any, len(s.cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	out := make(map[string]any, len(s.cols))
+	for i, c := range s.cols {
+		out[c] = values[i]
+	}
+	return out, nil
+}
+
+// format renders a scanned row in place.
+func (s *rowScanner) format(row map[string]any) {
+	for i, c := range s.cols {
+		if v, ok := row[c]; ok {
+			row[c] = formatOutputElem(s.kinds[i], s.elems[i], v, s.opts)
+		}
+	}
+}
+
+// ScanRows scans and formats all rows for output. casts may be nil (e.g. ad-hoc /v1/query results).
+func ScanRows(rows *sql.Rows, casts map[string]CastType) ([]map[string]any, error) {
+	s, err := newRowScanner(rows, casts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]any, 0, 32)
+	for rows.Next() {
+		m, err := s.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		s.format(m)
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
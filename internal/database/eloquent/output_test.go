@@ -0,0 +1,45 @@
+package eloquent
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFormatOutput(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+	opts := OutputOptions{Decimals: DecimalsAsString, Location: jakarta}
+	ts := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+
+	row := map[string]any{
+		"total":   formatOutput(outputKindForDBType("NUMERIC"), "1234.50", opts),
+		"data":    formatOutput(outputKindForDBType("JSONB"), []byte(`{"a":[1,2]}`), opts),
+		"id":      formatOutput(outputKindForDBType("UUID"), [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}, opts),
+		"tgl":     formatOutput(outputKindForDBType("DATE"), ts, opts),
+		"at":      formatOutput(outputKindForDBType("TIMESTAMPTZ"), ts, opts),
+		"local":   formatOutput(outputKindForDBType("TIMESTAMP"), ts, opts),
+		"blob":    formatOutput(outputKindForDBType("BYTEA"), []byte{0xff, 0x00}, opts),
+		"name":    formatOutput(outputKindForDBType("VARCHAR"), []byte("Budi"), opts),
+		"qty":     formatOutput(outputKindForDBType("UNSIGNED BIGINT"), []byte("42"), opts),
+		"missing": formatOutput(outputKindForDBType("NUMERIC"), nil, opts),
+	}
+	b, err := json.Marshal(row)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"at":"2024-03-01T17:30:00+07:00","blob":"/wA=","data":{"a":[1,2]},"id":"123e4567-e89b-12d3-a456-426614174000","local":"2024-03-01T10:30:00+07:00","missing":null,"name":"Budi","qty":42,"tgl":"2024-03-01","total":"1234.50"}`
+	if string(b) != want {
+		t.Fatalf("output:\n got %s\nwant %s", b, want)
+	}
+
+	opts.Decimals = DecimalsAsNumber
+	b, _ = json.Marshal([]any{formatOutput(outputDecimal, "1234.50", opts), formatOutput(outputDecimal, "NaN", opts)})
+	if string(b) != `[1234.50,"NaN"]` {
+		t.Fatalf("decimal as number: %s", b)
+	}
+
+	// Generic driver types fall back to Schema.Casts.
+	if k, _ := outputKindForCast(CastInt); formatOutput(k, "7", opts) != int64(7) {
+		t.Fatalf("cast int: %#v", formatOutput(k, "7", opts))
+	}
+}
@@ -0,0 +1,253 @@
+package eloquent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type RelationType string
+
+const (
+	BelongsTo RelationType = "belongsTo"
+	HasOne    RelationType = "hasOne"
+	HasMany   RelationType = "hasMany"
+)
+
+// Relation describes a schema-file relation, e.g. pasien.kd_dr -> dokter.kd_dr:
+//
+//	relation=dokter:belongsTo:kd_dr->dokter.kd_dr
+//	relation=orders:hasMany:kd_ps->orders.kd_ps
+type Relation struct {
+	Name       string
+	Type       RelationType
+	LocalKey   string // column on this table
+	Table      string // related table
+	ForeignKey string // column on the related table
+}
+
+// ParseRelationType accepts camelCase and snake_case spellings.
+func ParseRelationType(raw string) (RelationType, bool) {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(raw), "_", "")) {
+	case "belongsto":
+		return BelongsTo, true
+	case "hasone":
+		return HasOne, true
+	case "hasmany":
+		return HasMany, true
+	default:
+		return "", false
+	}
+}
+
+// WithSpec requests eager loading of one relation with optional per-relation options.
+type WithSpec struct {
+	Relation string   `json:"relation"`
+	Select   []string `json:"select"`
+	Limit    int      `json:"limit"` // hasMany only: max rows per parent
+}
+
+// SchemaResolver loads the schema of a related table (and applies any access policy).
+type SchemaResolver func(ctx context.Context, table string) (Schema, error)
+
+const (
+	DefaultRelationLimit = 20
+	MaxRelationLimit     = MaxPerPage
+)
+
+// EagerLoad attaches related rows to parent rows in place, one batched query per relation.
+// Every related query is tenant-filtered with tenantID on the related table's tenant column
+// (global related tables are not).
+//
+// belongsTo/hasOne embed an object (or null); hasMany embeds an array.
+func EagerLoad(ctx context.Context, q Querier, parent Schema, rows []map[string]any, with []WithSpec, tenantID int64, resolve SchemaResolver) error {
+	if len(with) == 0 {
+		return nil
+	}
+	if tenantID <= 0 {
+		return &ValidationError{Errors: map[string]string{"company_id": "invalid"}}
+	}
+
+	seen := map[string]bool{}
+	for i, w := range with {
+		name := strings.TrimSpace(w.Relation)
+		key := fmt.Sprintf("with[%d]", i)
+		rel, ok := parent.Relations[name]
+		if !ok {
+			return &ValidationError{Errors: map[string]string{key: "unknown relation"}}
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		related, err := resolve(ctx, rel.Table)
+		if err != nil {
+			return err
+		}
+		related = related.withDefaults()
+		tenantCol := related.tenantColumn()
+		if tenantCol == "" && !related.Global {
+			return &ValidationError{Errors: map[string]string{key: "related table does not support tenant filter"}}
+		}
+		if !related.hasColumn(rel.ForeignKey) {
+			return &ValidationError{Errors: map[string]string{key: "unknown foreign key"}}
+		}
+
+		if err := loadRelation(ctx, q, rel, related, tenantCol, tenantID, rows, w, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadRelation(ctx context.Context, q Querier, rel Relation, related Schema, tenantCol string, tenantID int64, rows []map[string]any, w WithSpec, key string) error {
+	// Collect distinct parent key values.
+	keys := []any{}
+	keySeen := map[string]bool{}
+	for _, row := range rows {
+		v, ok := row[rel.LocalKey]
+		if !ok {
+			return &ValidationError{Errors: map[string]string{key: fmt.Sprintf("requires %s in select", rel.LocalKey)}}
+		}
+		if v == nil {
+			continue
+		}
+		k := relationKey(v)
+		if keySeen[k] {
+			continue
+		}
+		keySeen[k] = true
+		keys = append(keys, relationArg(v))
+	}
+
+	// Default (empty) values so clients always see the relation key.
+	for _, row := range rows {
+		if rel.Type == HasMany {
+			row[rel.Name] = []map[string]any{}
+		} else {
+			row[rel.Name] = nil
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	selectCols, verr := normalizeSelect(related, w.Select)
+	if verr != nil {
+		errs := map[string]string{}
+		for k, v := range verr.Errors {
+			errs[key+".select."+k] = v
+		}
+		return &ValidationError{Errors: errs}
+	}
+	// The foreign key is needed to match rows back to parents.
+	dropFK := false
+	if !containsString(selectCols, rel.ForeignKey) {
+		selectCols = append(selectCols, rel.ForeignKey)
+		dropFK = related.IsHidden(rel.ForeignKey)
+	}
+
+	b := newSQLBuilder()
+	placeholders := make([]string, 0, len(keys))
+	for _, k := range keys {
+		placeholders = append(placeholders, b.push(k))
+	}
+	where := fmt.Sprintf("%s IN (%s)", rel.ForeignKey, strings.Join(placeholders, ","))
+	if tenantCol != "" {
+		where += " AND " + b.eq(tenantCol, tenantID)
+	}
+
+	var query string
+	if rel.Type == HasMany {
+		limit := w.Limit
+		if limit <= 0 {
+			limit = DefaultRelationLimit
+		}
+		if limit > MaxRelationLimit {
+			limit = MaxRelationLimit
+		}
+		// Per-parent limit in one batched query.
+		query = fmt.Sprintf(
+			"SELECT * FROM (SELECT %s, ROW_NUMBER() OVER (PARTITION BY %s ORDER BY %s) AS __rn FROM %s WHERE %s) __rel WHERE __rn <= %s",
+			strings.Join(selectCols, ","),
+			rel.ForeignKey,
+			related.PrimaryKey,
+			related.Table,
+			where,
+			b.push(limit),
+		)
+	} else {
+		query = fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(selectCols, ","), related.Table, where)
+	}
+
+	rs, err := q.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+
+	scanner, err := newRowScanner(rs, related.Casts)
+	if err != nil {
+		return err
+	}
+	byKey := map[string][]map[string]any{}
+	for rs.Next() {
+		m, err := scanner.scan(rs)
+		if err != nil {
+			return err
+		}
+		scanner.format(m)
+		delete(m, "__rn")
+		k := relationKey(m[rel.ForeignKey])
+		if dropFK {
+			delete(m, rel.ForeignKey)
+		}
+		byKey[k] = append(byKey[k], m)
+	}
+	if err := rs.Err(); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		v := row[rel.LocalKey]
+		if v == nil {
+			continue
+		}
+		matches := byKey[relationKey(v)]
+		if rel.Type == HasMany {
+			if matches != nil {
+				row[rel.Name] = matches
+			}
+			continue
+		}
+		if len(matches) > 0 {
+			row[rel.Name] = matches[0]
+		}
+	}
+	return nil
+}
+
+// relationKey normalizes driver values ([]byte vs string, int widths) for matching.
+func relationKey(v any) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+func relationArg(v any) any {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package eloquent
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+)
+
+func relationsFixture() (Schema, SchemaResolver) {
+	pasien := Schema{
+		Table:      "pasien",
+		PrimaryKey: "kd_ps",
+		Columns:    []string{"kd_ps", "company_id", "kd_dr"},
+		Relations: map[string]Relation{
+			"orders":  {Name: "orders", Type: HasMany, LocalKey: "kd_ps", Table: "orders", ForeignKey: "kd_ps"},
+			"profile": {Name: "profile", Type: HasOne, LocalKey: "kd_ps", Table: "profil", ForeignKey: "kd_ps"},
+			"dokter":  {Name: "dokter", Type: BelongsTo, LocalKey: "kd_dr", Table: "dokter", ForeignKey: "kd_dr"},
+			"kota":    {Name: "kota", Type: BelongsTo, LocalKey: "kd_dr", Table: "kota", ForeignKey: "kd_dr"},
+		},
+	}
+	schemas := map[string]Schema{
+		"orders": {Table: "orders", PrimaryKey: "no_lab", Columns: []string{"no_lab", "company_id", "kd_ps", "total"}},
+		"profil": {Table: "profil", PrimaryKey: "id", Columns: []string{"id", "com_id", "kd_ps", "alergi"}, Hidden: []string{"kd_ps"}},
+		"dokter": {Table: "dokter", PrimaryKey: "kd_dr", Columns: []string{"kd_dr", "nama"}, Global: true},
+		"kota":   {Table: "kota", PrimaryKey: "kd_dr", Columns: []string{"kd_dr", "nama"}},
+	}
+	resolve := func(_ context.Context, table string) (Schema, error) {
+		s, ok := schemas[table]
+		if !ok {
+			return Schema{}, fmt.Errorf("unknown table %s", table)
+		}
+		return s, nil
+	}
+	return pasien, resolve
+}
+
+func TestEagerLoadHasMany(t *testing.T) {
+	f := &fakeDB{results: []fakeResult{{match: "FROM orders", cols: []string{"no_lab", "kd_ps", "__rn"}, rows: [][]driver.Value{
+		{"L1", int64(1), int64(1)},
+		{"L2", int64(1), int64(2)},
+	}}}}
+	parent, resolve := relationsFixture()
+	rows := []map[string]any{{"kd_ps": int64(1)}, {"kd_ps": int64(2)}, {"kd_ps": int64(1)}, {"kd_ps": nil}}
+
+	with := []WithSpec{{Relation: "orders", Select: []string{"no_lab"}, Limit: 5}}
+	if err := EagerLoad(context.Background(), f.open(t), parent, rows, with, 7, resolve); err != nil {
+		t.Fatal(err)
+	}
+	// Distinct parent keys, tenant filter on the related table, per-parent ROW_NUMBER limit.
+	q := f.stmt(t, "FROM orders")
+	want := "SELECT * FROM (SELECT no_lab,kd_ps, ROW_NUMBER() OVER (PARTITION BY kd_ps ORDER BY no_lab) AS __rn FROM orders WHERE kd_ps IN ($1,$2) AND company_id = $3) __rel WHERE __rn <= $4"
+	if q.sql != want {
+		t.Fatalf("sql:\n got %s\nwant %s", q.sql, want)
+	}
+	if fmt.Sprint(q.args) != "[1 2 7 5]" {
+		t.Fatalf("args = %v", q.args)
+	}
+
+	got, ok := rows[0]["orders"].([]map[string]any)
+	if !ok || len(got) != 2 || got[0]["no_lab"] != "L1" || got[1]["no_lab"] != "L2" {
+		t.Fatalf("orders = %#v", rows[0]["orders"])
+	}
+	if _, ok := got[0]["__rn"]; ok {
+		t.Fatal("__rn must be dropped")
+	}
+	// Parents without children (or without a key) get an empty array.
+	for _, i := range []int{1, 3} {
+		if list, ok := rows[i]["orders"].([]map[string]any); !ok || len(list) != 0 {
+			t.Fatalf("row %d orders = %#v", i, rows[i]["orders"])
+		}
+	}
+}
+
+func TestEagerLoadLimitDefaults(t *testing.T) {
+	parent, resolve := relationsFixture()
+	for _, tc := range []struct{ limit, want int }{{0, DefaultRelationLimit}, {MaxRelationLimit + 1, MaxRelationLimit}} {
+		f := &fakeDB{}
+		rows := []map[string]any{{"kd_ps": int64(1)}}
+		if err := EagerLoad(context.Background(), f.open(t), parent, rows, []WithSpec{{Relation: "orders", Limit: tc.limit}}, 7, resolve); err != nil {
+			t.Fatal(err)
+		}
+		q := f.stmt(t, "FROM orders")
+		if q.args[len(q.args)-1] != int64(tc.want) {
+			t.Fatalf("limit %d: args = %v", tc.limit, q.args)
+		}
+	}
+}
+
+func TestEagerLoadHasOneAndBelongsTo(t *testing.T) {
+	f := &fakeDB{results: []fakeResult{
+		{match: "FROM profil", cols: []string{"alergi", "kd_ps"}, rows: [][]driver.Value{{"debu", int64(1)}}},
+		{match: "FROM dokter", cols: []string{"kd_dr", "nama"}, rows: [][]driver.Value{{"D1", "dr. Sari"}}},
+	}}
+	parent, resolve := relationsFixture()
+	rows := []map[string]any{{"kd_ps": int64(1), "kd_dr": "D1"}, {"kd_ps": int64(2), "kd_dr": nil}}
+
+	with := []WithSpec{{Relation: "profile", Select: []string{"alergi"}}, {Relation: "dokter"}}
+	if err := EagerLoad(context.Background(), f.open(t), parent, rows, with, 7, resolve); err != nil {
+		t.Fatal(err)
+	}
+	// hasOne: legacy com_id tenant column; the hidden foreign key is selected for matching only.
+	if q := f.stmt(t, "FROM profil"); q.sql != "SELECT alergi,kd_ps FROM profil WHERE kd_ps IN ($1,$2) AND com_id = $3" {
+		t.Fatalf("profil: %s", q.sql)
+	}
+	profile, ok := rows[0]["profile"].(map[string]any)
+	if !ok || profile["alergi"] != "debu" {
+		t.Fatalf("profile = %#v", rows[0]["profile"])
+	}
+	if _, ok := profile["kd_ps"]; ok {
+		t.Fatal("hidden foreign key must be dropped")
+	}
+	if rows[1]["profile"] != nil {
+		t.Fatalf("missing hasOne must be null: %#v", rows[1]["profile"])
+	}
+
+	// belongsTo a global table: no tenant filter.
+	if q := f.stmt(t, "FROM dokter"); q.sql != "SELECT kd_dr,nama FROM dokter WHERE kd_dr IN ($1)" {
+		t.Fatalf("dokter: %s", q.sql)
+	}
+	if d, ok := rows[0]["dokter"].(map[string]any); !ok || d["nama"] != "dr. Sari" {
+		t.Fatalf("dokter = %#v", rows[0]["dokter"])
+	}
+	if rows[1]["dokter"] != nil {
+		t.Fatalf("dokter = %#v", rows[1]["dokter"])
+	}
+}
+
+func TestEagerLoadErrors(t *testing.T) {
+	parent, resolve := relationsFixture()
+	cases := []struct {
+		name string
+		rows []map[string]any
+		with WithSpec
+		key  string
+		msg  string
+	}{
+		{"unknown relation", []map[string]any{{"kd_ps": 1}}, WithSpec{Relation: "nope"}, "with[0]", "unknown relation"},
+		{"no tenant column", []map[string]any{{"kd_dr": "D1"}}, WithSpec{Relation: "kota"}, "with[0]", "related table does not support tenant filter"},
+		{"local key not selected", []map[string]any{{"nama": "x"}}, WithSpec{Relation: "orders"}, "with[0]", "requires kd_ps in select"},
+		{"hidden field", []map[string]any{{"kd_ps": 1}}, WithSpec{Relation: "profile", Select: []string{"kd_ps"}}, "with[0].select.kd_ps", "hidden field"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &fakeDB{}
+			err := EagerLoad(context.Background(), f.open(t), parent, tc.rows, []WithSpec{tc.with}, 7, resolve)
+			ve, ok := err.(*ValidationError)
+			if !ok || ve.Errors[tc.key] != tc.msg {
+				t.Fatalf("err = %v", err)
+			}
+			if len(f.stmts) != 0 {
+				t.Fatalf("no query expected, ran %v", f.stmts)
+			}
+		})
+	}
+}
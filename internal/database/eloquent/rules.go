@@ -0,0 +1,329 @@
+package eloquent
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Rule is a single Laravel-style validation rule.
+// Example: "max:100" => Rule{Name: "max", Args: []string{"100"}}.
+type Rule struct {
+	Name string
+	Args []string
+
+	re *regexp.Regexp // compiled regex pattern, set by ParseRules
+}
+
+const (
+	RuleRequired   = "required"
+	RuleNullable   = "nullable"
+	RuleMax        = "max"
+	RuleMin        = "min"
+	RuleRegex      = "regex"
+	RuleIn         = "in"
+	RuleEmail      = "email"
+	RuleDateBefore = "date_before"
+	RuleUnique     = "unique"
+)
+
+// ParseRules parses a pipe-separated rule list such as "required|max:100|in:L,P".
+//
+// Notes:
+// - regex consumes the rest of the string (so it may contain '|'); put it last.
+// - Unknown rule names are rejected so typos don't silently disable validation.
+func ParseRules(raw string) ([]Rule, error) {
+	s := strings.TrimSpace(raw)
+	out := []Rule{}
+	for s != "" {
+		part := s
+		rest := ""
+		if strings.HasPrefix(strings.ToLower(s), RuleRegex+":") {
+			// keep the whole remainder as the pattern
+		} else if i := strings.IndexByte(s, '|'); i >= 0 {
+			part = s[:i]
+			rest = s[i+1:]
+		}
+		s = strings.TrimSpace(rest)
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name := part
+		argRaw := ""
+		if i := strings.IndexByte(part, ':'); i >= 0 {
+			name = part[:i]
+			argRaw = part[i+1:]
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		r := Rule{Name: name}
+		switch name {
+		case RuleRequired, RuleNullable, RuleEmail, RuleUnique:
+			// no args
+		case RuleMax, RuleMin:
+			n := strings.TrimSpace(argRaw)
+			if _, err := strconv.ParseFloat(n, 64); err != nil {
+				return nil, fmt.Errorf("rule %s: numeric argument required", name)
+			}
+			r.Args = []string{n}
+		case RuleRegex:
+			pattern := strings.TrimSpace(argRaw)
+			// Accept Laravel-style delimiters: /pattern/
+			if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+				pattern = pattern[1 : len(pattern)-1]
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule regex: invalid pattern")
+			}
+			r.Args = []string{pattern}
+			r.re = re
+		case RuleIn:
+			r.Args = splitRuleArgs(argRaw)
+			if len(r.Args) == 0 {
+				return nil, fmt.Errorf("rule in: values required")
+			}
+		case RuleDateBefore:
+			d := strings.TrimSpace(argRaw)
+			if d == "" {
+				return nil, fmt.Errorf("rule date_before: date required")
+			}
+			if _, err := resolveRuleDate(d, time.Now()); err != nil {
+				return nil, fmt.Errorf("rule date_before: invalid date")
+			}
+			r.Args = []string{d}
+		default:
+			return nil, fmt.Errorf("unknown rule: %s", name)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func splitRuleArgs(s string) []string {
+	out := []string{}
+	for _, p := range strings.Split(s, ",") {
+		v := strings.TrimSpace(p)
+		if v == "" {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func hasRule(rules []Rule, name string) bool {
+	for _, r := range rules {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	if s, ok := v.(string); ok {
+		return strings.TrimSpace(s) == ""
+	}
+	return false
+}
+
+// validateRules applies schema rules to an already filtered + casted payload.
+//
+// partial=true gives Laravel "sometimes" semantics (used by update): rules only apply
+// to fields present in the payload, so PATCH with a subset of fields still works.
+// The unique rule needs DB access and is checked separately by checkUniqueRules.
+func (s Schema) validateRules(data map[string]any, partial bool, errs map[string]string) {
+	if len(s.Rules) == 0 {
+		return
+	}
+	now := s.Now()
+	for _, col := range sortedRuleColumns(s.Rules) {
+		if _, failed := errs[col]; failed {
+			continue
+		}
+		rules := s.Rules[col]
+		v, present := data[col]
+		if !present && partial {
+			continue
+		}
+		if isEmptyValue(v) {
+			// Generated columns are filled after validation when a create leaves them empty.
+			if hasRule(rules, RuleRequired) && (partial || !s.isGenerated(col)) {
+				errs[col] = "required"
+			}
+			// nullable (or simply empty): remaining rules do not apply.
+			continue
+		}
+		for _, r := range rules {
+			if msg := checkRule(r, v, s.Casts[col], now); msg != "" {
+				errs[col] = msg
+				break
+			}
+		}
+	}
+}
+
+func checkRule(r Rule, v any, cast CastType, now time.Time) string {
+	switch r.Name {
+	case RuleMax, RuleMin:
+		limit, _ := strconv.ParseFloat(r.Args[0], 64)
+		size, isString := ruleSize(v, cast)
+		if r.Name == RuleMax && size > limit {
+			if isString {
+				return fmt.Sprintf("must not exceed %s characters", r.Args[0])
+			}
+			return fmt.Sprintf("must not be greater than %s", r.Args[0])
+		}
+		if r.Name == RuleMin && size < limit {
+			if isString {
+				return fmt.Sprintf("must be at least %s characters", r.Args[0])
+			}
+			return fmt.Sprintf("must be at least %s", r.Args[0])
+		}
+	case RuleRegex:
+		re := r.re
+		if re == nil {
+			// Rule built without ParseRules.
+			var err error
+			if re, err = regexp.Compile(r.Args[0]); err != nil {
+				return "invalid format"
+			}
+		}
+		if !re.MatchString(fmt.Sprint(v)) {
+			return "invalid format"
+		}
+	case RuleIn:
+		sv := strings.TrimSpace(fmt.Sprint(v))
+		for _, a := range r.Args {
+			if sv == a {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(r.Args, ", ")
+	case RuleEmail:
+		sv := strings.TrimSpace(fmt.Sprint(v))
+		addr, err := mail.ParseAddress(sv)
+		if err != nil || addr.Address != sv {
+			return "must be a valid email address"
+		}
+	case RuleDateBefore:
+		limit, err := resolveRuleDate(r.Args[0], now)
+		if err != nil {
+			return "invalid rule"
+		}
+		var t time.Time
+		switch tv := v.(type) {
+		case time.Time:
+			t = tv
+		default:
+			parsed, err := parseDateTime(fmt.Sprint(v))
+			if err != nil {
+				return "must be a date"
+			}
+			t = parsed
+		}
+		if !t.Before(limit) {
+			return "must be a date before " + r.Args[0]
+		}
+	}
+	return ""
+}
+
+// ruleSize returns the comparable size for min/max: rune length for strings, value for numbers.
+// Decimal columns hold normalised strings (see castDecimal) and compare by value.
+func ruleSize(v any, cast CastType) (float64, bool) {
+	if s, ok := v.(string); ok && cast == CastDecimal {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, false
+		}
+	}
+	switch t := v.(type) {
+	case int64:
+		return float64(t), false
+	case int:
+		return float64(t), false
+	case float64:
+		return t, false
+	case jsonNumber:
+		if f, err := strconv.ParseFloat(t.String(), 64); err == nil {
+			return f, false
+		}
+		return float64(utf8.RuneCountInString(t.String())), true
+	default:
+		return float64(utf8.RuneCountInString(fmt.Sprint(v))), true
+	}
+}
+
+func resolveRuleDate(raw string, now time.Time) (time.Time, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "today":
+		return day, nil
+	case "tomorrow":
+		return day.AddDate(0, 0, 1), nil
+	case "now":
+		return now, nil
+	}
+	return parseDateTime(raw)
+}
+
+func sortedRuleColumns(rules map[string][]Rule) []string {
+	m := make(map[string]any, len(rules))
+	for k := range rules {
+		m[k] = nil
+	}
+	return sortedKeys(m)
+}
+
+// checkUniqueRules enforces the tenant-scoped "unique" rule.
+// tenantCol/tenantID scope the lookup (skipped when empty); excludePK skips the row being updated.
+func checkUniqueRules(ctx context.Context, q Querier, s Schema, data map[string]any, tenantCol string, tenantID any, excludePK any) error {
+	if len(s.Rules) == 0 {
+		return nil
+	}
+	errs := map[string]string{}
+	for _, col := range sortedRuleColumns(s.Rules) {
+		if !hasRule(s.Rules[col], RuleUnique) {
+			continue
+		}
+		v, ok := data[col]
+		if !ok || isEmptyValue(v) {
+			continue
+		}
+
+		b := newSQLBuilder()
+		where := []string{b.eq(col, v)}
+		if tenantCol != "" && tenantID != nil {
+			where = append(where, b.eq(tenantCol, tenantID))
+		}
+		if excludePK != nil {
+			where = append(where, fmt.Sprintf("%s <> %s", s.PrimaryKey, b.push(excludePK)))
+		}
+		query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s LIMIT 1", s.Table, strings.Join(where, " AND "))
+
+		rows, err := q.QueryContext(ctx, query, b.args...)
+		if err != nil {
+			return err
+		}
+		exists := rows.Next()
+		_ = rows.Close()
+		if exists {
+			errs[col] = "has already been taken"
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package eloquent
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(`required|max:16|in:L,P|regex:/^[0-9]{16}|x$/`)
+	if err != nil {
+		t.Fatalf("ParseRules err: %v", err)
+	}
+	if len(rules) != 4 {
+		t.Fatalf("expected 4 rules, got %d: %#v", len(rules), rules)
+	}
+	if rules[2].Name != RuleIn || len(rules[2].Args) != 2 {
+		t.Fatalf("unexpected in rule: %#v", rules[2])
+	}
+	if rules[3].Name != RuleRegex || rules[3].Args[0] != `^[0-9]{16}|x$` {
+		t.Fatalf("unexpected regex rule: %#v", rules[3])
+	}
+
+	if _, err := ParseRules("requird"); err == nil {
+		t.Fatalf("expected error for unknown rule")
+	}
+}
+
+func TestNormalizePayload_Rules(t *testing.T) {
+	mustRules := func(raw string) []Rule {
+		r, err := ParseRules(raw)
+		if err != nil {
+			t.Fatalf("ParseRules(%q): %v", raw, err)
+		}
+		return r
+	}
+	s := Schema{
+		Table:      "pasien",
+		PrimaryKey: "kd_ps",
+		Columns:    []string{"kd_ps", "nama_ps", "jk", "email", "nik", "tgl_lahir", "company_id"},
+		Casts:      map[string]CastType{"tgl_lahir": CastDateTime},
+		Rules: map[string][]Rule{
+			"nama_ps":   mustRules("required|max:5"),
+			"jk":        mustRules("nullable|in:L,P"),
+			"email":     mustRules("nullable|email"),
+			"nik":       mustRules("nullable|regex:^[0-9]{16}$"),
+			"tgl_lahir": mustRules("nullable|date_before:today"),
+		},
+		Now: func() time.Time { return time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC) },
+	}
+
+	// Create: required applies to absent fields.
+	_, verr := s.normalizePayload(map[string]any{"jk": "L"}, false)
+	if verr == nil || verr.Errors["nama_ps"] != "required" {
+		t.Fatalf("expected nama_ps required, got %#v", verr)
+	}
+
+	_, verr = s.normalizePayload(map[string]any{
+		"nama_ps":   "Budi Santoso",
+		"jk":        "X",
+		"email":     "not-an-email",
+		"nik":       "123",
+		"tgl_lahir": "2024-02-01",
+	}, false)
+	if verr == nil {
+		t.Fatalf("expected validation errors")
+	}
+	for _, k := range []string{"nama_ps", "jk", "email", "nik", "tgl_lahir"} {
+		if verr.Errors[k] == "" {
+			t.Fatalf("expected error for %s, got %#v", k, verr.Errors)
+		}
+	}
+
+	// Update ("sometimes"): absent required fields are fine.
+	out, verr := s.normalizePayload(map[string]any{"jk": "P", "email": ""}, true)
+	if verr != nil {
+		t.Fatalf("unexpected errors: %#v", verr.Errors)
+	}
+	if out["jk"] != "P" {
+		t.Fatalf("unexpected payload: %#v", out)
+	}
+
+	// Update: present but empty required field still fails.
+	_, verr = s.normalizePayload(map[string]any{"nama_ps": " "}, true)
+	if verr == nil || verr.Errors["nama_ps"] != "required" {
+		t.Fatalf("expected nama_ps required on update, got %#v", verr)
+	}
+}
+
+func TestNormalizePayload_DecimalMinMax(t *testing.T) {
+	rules, err := ParseRules("min:0|max:1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Schema{
+		Table:      "tarif",
+		PrimaryKey: "id",
+		Columns:    []string{"id", "amt"},
+		Casts:      map[string]CastType{"amt": CastDecimal},
+		Rules:      map[string][]Rule{"amt": rules},
+	}
+
+	// Decimals compare by value, not by digit count.
+	for _, v := range []any{"5000", "-3", "1000.01", json.Number("-0.5")} {
+		if _, verr := s.normalizePayload(map[string]any{"amt": v}, false); verr == nil || verr.Errors["amt"] == "" {
+			t.Fatalf("%v: expected min/max error, got %#v", v, verr)
+		}
+	}
+	for _, v := range []any{"0", "999.99", "1000", int64(12)} {
+		if _, verr := s.normalizePayload(map[string]any{"amt": v}, false); verr != nil {
+			t.Fatalf("%v: unexpected errors: %#v", v, verr.Errors)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 )
 
+// scanCurrentRowToMap returns raw driver values (internal queries such as EXPLAIN).
+// Rows that reach a response go through rowScanner instead (see output.go).
 func scanCurrentRowToMap(rows *sql.Rows) (map[string]any, error) {
 	cols, err := rows.Columns()
 	if err != nil {
@@ -26,3 +28,17 @@ func scanCurrentRowToMap(rows *sql.Rows) (map[string]any, error) {
 	}
 	return out, nil
 }
+
+// scanOutputRow scans and formats the current row (single-row lookups).
+func scanOutputRow(rows *sql.Rows, casts map[string]CastType) (map[string]any, error) {
+	s, err := newRowScanner(rows, casts)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.scan(rows)
+	if err != nil {
+		return nil, err
+	}
+	s.format(m)
+	return m, nil
+}
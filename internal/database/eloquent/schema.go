@@ -13,17 +13,34 @@ const (
 	CastFloat    CastType = "float"
 	CastBool     CastType = "bool"
 	CastDateTime CastType = "datetime"
+	CastDecimal  CastType = "decimal" // exact; stored and rendered as a decimal string
+	CastDate     CastType = "date"    // YYYY-MM-DD, no time component
+	CastTime     CastType = "time"    // HH:MM[:SS[.ffffff]]
+	CastJSON     CastType = "json"    // any JSON value, stored as JSON text
+	CastUUID     CastType = "uuid"
+	CastArray    CastType = "array" // array or array:<element cast>, e.g. array:int (Postgres arrays)
+	CastEnum     CastType = "enum"  // enum:<v1|v2|...>
 )
 
 type Schema struct {
-	Table      string
-	PrimaryKey string
-	Columns    []string
-	Casts      map[string]CastType
-	Fillable   []string
-	Aliases    map[string]string
-	Timestamps bool
-	Now        func() time.Time
+	Table        string
+	PrimaryKey   string
+	Columns      []string
+	Casts        map[string]CastType
+	Fillable     []string
+	Aliases      map[string]string
+	Hidden       []string              // never serialised in responses (still filterable)
+	Guarded      []string              // never writable, overrides Fillable / fillable defaults
+	Computed     map[string]string     // read-only virtual columns: name -> allowlisted SQL expression
+	Relations    map[string]Relation   // belongsTo/hasOne/hasMany, used by EagerLoad
+	Rules        map[string][]Rule     // Laravel-style validation rules per column (see ParseRules)
+	ColumnInfo   map[string]ColumnInfo // DB constraints per column (filled by schema introspection)
+	TenantColumn string                // tenant_column=: overrides the company_id/com_id detection
+	Global       bool                  // tenant=global: shared table, no tenant filter (writes are gated by the caller)
+	Generators   map[string]Generator  // generate=: codes filled on insert when the payload leaves them empty
+	Versioned    bool                  // versioned=true: full row snapshots per change (history, as_of, revert; kept by the caller)
+	Timestamps   bool
+	Now          func() time.Time
 }
 
 func (s Schema) withDefaults() Schema {
@@ -49,27 +66,139 @@ func (s Schema) HasColumn(col string) bool {
 	return s.hasColumn(col)
 }
 
+// tenantColumn returns the tenant column used for scoping: TenantColumn when declared,
+// otherwise company_id (preferred) or com_id (legacy). Global tables have none.
+func (s Schema) tenantColumn() string {
+	if s.Global {
+		return ""
+	}
+	if s.TenantColumn != "" {
+		if s.hasColumn(s.TenantColumn) {
+			return s.TenantColumn
+		}
+		return ""
+	}
+	if s.hasColumn("company_id") {
+		return "company_id"
+	}
+	if s.hasColumn("com_id") {
+		return "com_id"
+	}
+	return ""
+}
+
+// TenantColumnName returns the column used for tenant scoping ("" for global tables or
+// when the table has no tenant column).
+func (s Schema) TenantColumnName() string {
+	return s.tenantColumn()
+}
+
+// tenantScope renders the tenant condition for WHERE clauses. Global schemas have none
+// (returns ""); any other schema without a tenant column is rejected.
+func (s Schema) tenantScope(b *sqlBuilder, tenantCol string, tenantID any) (string, *ValidationError) {
+	tenantCol = strings.TrimSpace(tenantCol)
+	if tenantCol == "" {
+		if s.Global {
+			return "", nil
+		}
+		return "", &ValidationError{Errors: map[string]string{"tenant": "tenant column required"}}
+	}
+	return b.eq(tenantCol, tenantID), nil
+}
+
 func (s Schema) fillableSet() map[string]bool {
 	set := map[string]bool{}
 	if len(s.Fillable) > 0 {
 		for _, f := range s.Fillable {
 			set[f] = true
 		}
-		return set
+	} else {
+		// Default behavior aligned with mylab-core BaseModel comment:
+		// if fillable is empty, allow all columns except PK.
+		for _, c := range s.Columns {
+			if c == s.PrimaryKey {
+				continue
+			}
+			set[c] = true
+		}
 	}
 
-	// Default behavior aligned with mylab-core BaseModel comment:
-	// if fillable is empty, allow all columns except PK.
-	for _, c := range s.Columns {
-		if c == s.PrimaryKey {
+	// Guarded always wins. The tenant column is exempt because the server forces it.
+	tenantCol := s.tenantColumn()
+	for _, g := range s.Guarded {
+		if g == tenantCol {
 			continue
 		}
-		set[c] = true
+		delete(set, g)
 	}
 	return set
 }
 
-func (s Schema) normalizePayload(payload map[string]any) (map[string]any, *ValidationError) {
+// FillableColumns returns the columns a create/update payload may set, in Columns order.
+func (s Schema) FillableColumns() []string {
+	set := s.fillableSet()
+	out := []string{}
+	for _, c := range s.Columns {
+		if set[c] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// RequiredOnCreate reports whether a create payload must include col: a required rule, or a
+// NOT NULL column without default that the server does not fill itself (same checks as create).
+func (s Schema) RequiredOnCreate(col string) bool {
+	if s.isGenerated(col) {
+		return false
+	}
+	for _, r := range s.Rules[col] {
+		if r.Name == RuleRequired {
+			return true
+		}
+	}
+	info, ok := s.ColumnInfo[col]
+	if !ok || info.Nullable || info.HasDefault {
+		return false
+	}
+	if col == s.PrimaryKey || col == s.tenantColumn() {
+		return false
+	}
+	if s.Timestamps && (col == "created_at" || col == "updated_at") {
+		return false
+	}
+	return s.fillableSet()[col]
+}
+
+// IsHidden reports whether a column must never be serialised in responses.
+func (s Schema) IsHidden(col string) bool {
+	for _, h := range s.Hidden {
+		if h == col {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibleColumns returns Columns without hidden ones (the default response projection).
+// Computed columns are not included; see defaultSelectList.
+func (s Schema) VisibleColumns() []string {
+	if len(s.Hidden) == 0 {
+		return s.Columns
+	}
+	out := make([]string, 0, len(s.Columns))
+	for _, c := range s.Columns {
+		if s.IsHidden(c) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// normalizePayload applies aliases, fillable filtering, casts and validation rules.
+// partial=true is used by updates: rules only apply to fields present in the payload.
+func (s Schema) normalizePayload(payload map[string]any, partial bool) (map[string]any, *ValidationError) {
 	s = s.withDefaults()
 	data := map[string]any{}
 	allowed := s.fillableSet()
@@ -92,6 +221,10 @@ func (s Schema) normalizePayload(payload map[string]any) (map[string]any, *Valid
 	out := map[string]any{}
 	errs := map[string]string{}
 	for k, v := range data {
+		if s.IsComputed(k) {
+			errs[k] = "read-only (computed column)"
+			continue
+		}
 		if !allowed[k] {
 			continue
 		}
@@ -103,6 +236,9 @@ func (s Schema) normalizePayload(payload map[string]any) (map[string]any, *Valid
 		out[k] = casted
 	}
 
+	s.validateRules(out, partial, errs)
+	s.validateColumnConstraints(out, partial, errs)
+
 	if len(errs) > 0 {
 		return nil, &ValidationError{Errors: errs}
 	}
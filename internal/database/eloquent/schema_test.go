@@ -0,0 +1,116 @@
+package eloquent
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func guardedSchema() Schema {
+	return Schema{
+		Table:        "cabang",
+		PrimaryKey:   "id",
+		Columns:      []string{"id", "lab_id", "nama", "status", "password"},
+		TenantColumn: "lab_id",
+		Guarded:      []string{"lab_id", "status"},
+		Hidden:       []string{"password"},
+	}
+}
+
+func TestGuardedColumnsDroppedOnWrite(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeDB{results: []fakeResult{{match: "INSERT INTO", cols: []string{"id"}, rows: [][]driver.Value{{int64(9)}}}}}
+	db := f.open(t)
+	s := guardedSchema()
+
+	payload := map[string]any{"nama": "Pusat", "status": "closed", "lab_id": int64(7)}
+	if _, err := Insert(ctx, db, s, payload); err != nil {
+		t.Fatal(err)
+	}
+	// Guarded status is dropped silently; the guarded tenant column is kept (the server sets it).
+	ins := f.stmt(t, "INSERT INTO")
+	if want := "INSERT INTO cabang (lab_id,nama) VALUES ($1,$2) RETURNING id"; ins.sql != want {
+		t.Fatalf("insert:\n got %s\nwant %s", ins.sql, want)
+	}
+	if len(ins.args) != 2 || ins.args[0] != int64(7) || ins.args[1] != "Pusat" {
+		t.Fatalf("insert args = %v", ins.args)
+	}
+
+	if err := UpdateByPKAndTenant(ctx, db, s, 9, "lab_id", 7, map[string]any{"nama": "Cabang", "status": "closed"}); err != nil {
+		t.Fatal(err)
+	}
+	if upd := f.stmt(t, "UPDATE"); upd.sql != "UPDATE cabang SET nama = $1 WHERE id = $2 AND lab_id = $3" {
+		t.Fatalf("update: %s", upd.sql)
+	}
+
+	// A payload of guarded columns only sets nothing.
+	err := UpdateByPKAndTenant(ctx, db, s, 9, "lab_id", 7, map[string]any{"status": "closed"})
+	if ve, ok := err.(*ValidationError); !ok || ve.Errors["payload"] == "" {
+		t.Fatalf("err = %v", err)
+	}
+	if set := s.fillableSet(); set["status"] || !set["lab_id"] || !set["nama"] {
+		t.Fatalf("fillable = %v", set)
+	}
+}
+
+func TestHiddenColumnsNotReturned(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeDB{results: []fakeResult{{match: "FROM cabang", cols: []string{"id", "lab_id", "nama", "status"}, rows: [][]driver.Value{{int64(9), int64(7), "Pusat", "open"}}}}}
+	db := f.open(t)
+	s := guardedSchema()
+
+	row, err := FindByPKAndTenant(ctx, db, s, 9, "lab_id", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := f.stmt(t, "LIMIT 1"); q.sql != "SELECT id,lab_id,nama,status FROM cabang WHERE id = $1 AND lab_id = $2 LIMIT 1" {
+		t.Fatalf("find: %s", q.sql)
+	}
+	if _, ok := row["password"]; ok || row["nama"] != "Pusat" {
+		t.Fatalf("row = %v", row)
+	}
+
+	// Hidden columns can still be matched exactly, but not selected.
+	f.stmts = nil
+	res, err := SelectPage(ctx, db, s, 7, SelectRequest{Where: map[string]any{"password": "rahasia"}, Count: CountNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := f.stmt(t, "OFFSET")
+	if !strings.HasPrefix(q.sql, "SELECT id,lab_id,nama,status FROM cabang WHERE ") || !strings.Contains(q.sql, "password = $") || !strings.Contains(q.sql, "lab_id = $") {
+		t.Fatalf("select: %s", q.sql)
+	}
+	if len(res.Rows) != 1 {
+		t.Fatalf("rows = %v", res.Rows)
+	}
+	if _, ok := res.Rows[0]["password"]; ok {
+		t.Fatalf("hidden column returned: %v", res.Rows[0])
+	}
+
+	_, err = SelectPage(ctx, db, s, 7, SelectRequest{Select: []string{"id", "password"}})
+	if ve, ok := err.(*ValidationError); !ok || ve.Errors["password"] != "hidden field" {
+		t.Fatalf("err = %v", err)
+	}
+
+	// Patterns and sorting would reveal the value one character at a time.
+	for _, tc := range []struct {
+		req SelectRequest
+		key string
+	}{
+		{SelectRequest{Like: map[string]any{"password": "a%"}}, "password"},
+		{SelectRequest{OrLike: map[string]any{"password": "a%"}}, "password"},
+		{SelectRequest{OrderBy: []OrderBy{{Field: "password"}}}, "order_by[0].field"},
+		{SelectRequest{OrderBy: []OrderBy{{Field: "password"}}, Pagination: PaginationCursor}, "order_by[0].field"},
+	} {
+		f.stmts = nil
+		tc.req.Count = CountNone
+		_, err = SelectPage(ctx, db, s, 7, tc.req)
+		if ve, ok := err.(*ValidationError); !ok || ve.Errors[tc.key] != "hidden field" {
+			t.Fatalf("%+v: err = %v", tc.req, err)
+		}
+		if len(f.stmts) != 0 {
+			t.Fatalf("no query expected, ran %v", f.stmts)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"mylab-api-go/internal/database/dialect"
 )
 
 type OrderBy struct {
@@ -19,8 +21,19 @@ type SelectRequest struct {
 	Like    map[string]any `json:"like"`
 	OrLike  map[string]any `json:"or_like"`
 	OrderBy []OrderBy      `json:"order_by"`
-	Page    int            `json:"page"`
-	PerPage int            `json:"per_page"`
+	// Filters is an optional and/or tree of conditions (see Filter); it is AND-ed with the maps above.
+	Filters *Filter `json:"filters"`
+	Page    int     `json:"page"`
+	PerPage int     `json:"per_page"`
+	// Pagination is offset (default) or cursor. A non-empty Cursor implies cursor mode.
+	// Cursor mode keysets on the order_by columns + PK and ignores Page.
+	Pagination string `json:"pagination"`
+	Cursor     string `json:"cursor"`
+	// Count is exact|estimate|none. Default: exact (offset), none (cursor).
+	Count string `json:"count"`
+	// With lists relations to eager-load. SelectPage ignores it; callers pass it to EagerLoad
+	// (which needs a SchemaResolver for related tables).
+	With []WithSpec `json:"with"`
 }
 
 type PageResult struct {
@@ -28,8 +41,12 @@ type PageResult struct {
 	Page       int
 	PerPage    int
 	HasMore    bool
-	TotalRows  int
+	TotalRows  int // 0 when Count == CountNone
 	TotalPages int
+	Count      string // count mode applied
+	Pagination string // pagination mode applied
+	NextCursor string // cursor mode only
+	PrevCursor string // cursor mode only
 }
 
 const (
@@ -51,6 +68,27 @@ func SelectPage(ctx context.Context, q Querier, schema Schema, companyID int64,
 		return nil, verr
 	}
 
+	mode := strings.ToLower(strings.TrimSpace(req.Pagination))
+	if mode == "" {
+		mode = PaginationOffset
+		if strings.TrimSpace(req.Cursor) != "" {
+			mode = PaginationCursor
+		}
+	}
+	if mode != PaginationOffset && mode != PaginationCursor {
+		return nil, &ValidationError{Errors: map[string]string{"pagination": "must be offset or cursor"}}
+	}
+	countMode := strings.ToLower(strings.TrimSpace(req.Count))
+	if countMode == "" {
+		countMode = CountExact
+		if mode == PaginationCursor {
+			countMode = CountNone
+		}
+	}
+	if countMode != CountExact && countMode != CountEstimate && countMode != CountNone {
+		return nil, &ValidationError{Errors: map[string]string{"count": "must be none, exact or estimate"}}
+	}
+
 	page := req.Page
 	perPage := req.PerPage
 	if page <= 0 {
@@ -62,34 +100,188 @@ func SelectPage(ctx context.Context, q Querier, schema Schema, companyID int64,
 	if perPage > MaxPerPage {
 		perPage = MaxPerPage
 	}
+
+	builder := newSQLBuilder()
+	whereParts, verr := buildSelectWhere(schema, builder, companyID, req)
+	if verr != nil {
+		return nil, verr
+	}
+
+	// Count runs BEFORE adding keyset/limit/offset args.
+	res := &PageResult{PerPage: perPage, Count: countMode, Pagination: mode}
+	whereSQL := strings.Join(whereParts, " AND ")
+	switch countMode {
+	case CountExact:
+		n, err := exactCount(ctx, q, schema.Table, whereSQL, builder.args)
+		if err != nil {
+			return nil, err
+		}
+		res.TotalRows = n
+	case CountEstimate:
+		n, err := estimateCount(ctx, q, builder.d, schema.Table, whereSQL, builder.args)
+		if err != nil {
+			return nil, err
+		}
+		res.TotalRows = n
+	}
+	if countMode != CountNone && perPage > 0 {
+		res.TotalPages = (res.TotalRows + perPage - 1) / perPage
+	}
+
+	if mode == PaginationCursor {
+		if err := selectCursorPage(ctx, q, schema, builder, whereParts, selectCols, req, res); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	orderBySQL, verr := buildOrderBy(schema, req.OrderBy)
+	if verr != nil {
+		return nil, verr
+	}
+
 	offset := (page - 1) * perPage
 	limit := perPage + 1 // fetch one extra to detect has_more
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s%s LIMIT %s OFFSET %s",
+		strings.Join(selectCols, ","),
+		schema.Table,
+		whereSQL,
+		orderBySQL,
+		builder.arg(limit),
+		builder.arg(offset),
+	)
 
-	builder := newSQLBuilder()
-	whereParts := make([]string, 0, 8)
+	out, scanner, err := queryRows(ctx, q, schema.Casts, query, builder.args, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := false
+	if len(out) > perPage {
+		hasMore = true
+		out = out[:perPage]
+	}
+	for _, row := range out {
+		scanner.format(row)
+	}
+
+	res.Rows = out
+	res.Page = page
+	res.HasMore = hasMore
+	return res, nil
+}
+
+// selectCursorPage fetches one keyset page. Rows before the cursor are fetched in reverse
+// order and flipped back, so both directions use the same index.
+func selectCursorPage(ctx context.Context, q Querier, schema Schema, builder *sqlBuilder, whereParts, selectCols []string, req SelectRequest, res *PageResult) error {
+	cols, verr := keysetColumns(schema, req.OrderBy)
+	if verr != nil {
+		return verr
+	}
+	fingerprint := keysetFingerprint(cols)
+
+	var cur *pageCursor
+	if raw := strings.TrimSpace(req.Cursor); raw != "" {
+		c, err := decodeCursor(raw)
+		if err != nil || len(c.values) != len(cols) {
+			return &ValidationError{Errors: map[string]string{"cursor": "invalid"}}
+		}
+		if c.fingerprint != fingerprint {
+			return &ValidationError{Errors: map[string]string{"cursor": "does not match order_by"}}
+		}
+		cur = &c
+	}
+	backward := cur != nil && cur.dir == 'p'
+
+	// Keyset values must be in the result; add missing columns and drop them afterwards.
+	selected := map[string]bool{}
+	for _, e := range selectCols {
+		selected[selectListName(e)] = true
+	}
+	extra := []string{}
+	queryCols := append([]string{}, selectCols...)
+	for _, c := range cols {
+		if !selected[c.name] {
+			queryCols = append(queryCols, schema.selectExpr(c.name))
+			extra = append(extra, c.name)
+			selected[c.name] = true
+		}
+	}
+
+	where := append([]string{}, whereParts...)
+	if cur != nil {
+		where = append(where, keysetWhere(builder, cols, cur.values, backward))
+	}
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s%s LIMIT %s",
+		strings.Join(queryCols, ","),
+		schema.Table,
+		strings.Join(where, " AND "),
+		keysetOrderBy(cols, backward),
+		builder.arg(res.PerPage+1),
+	)
 
-	// Always apply tenant filter as company_id.
-	// Fallback: com_id (legacy).
-	tenantCol := ""
-	if schema.hasColumn("company_id") {
-		tenantCol = "company_id"
-	} else if schema.hasColumn("com_id") {
-		tenantCol = "com_id"
+	out, scanner, err := queryRows(ctx, q, schema.Casts, query, builder.args, res.PerPage)
+	if err != nil {
+		return err
 	}
-	if tenantCol == "" {
+	more := len(out) > res.PerPage
+	if more {
+		out = out[:res.PerPage]
+	}
+	if backward {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+
+	if len(out) > 0 {
+		first, last := out[0], out[len(out)-1]
+		// Forward: a next page exists if we over-fetched; a previous one if we came from a cursor.
+		// Backward: the page we came from is always "next"; a previous one exists if we over-fetched.
+		if more || backward {
+			res.NextCursor = cursorFromRow('n', fingerprint, cols, last)
+		}
+		if (backward && more) || (!backward && cur != nil) {
+			res.PrevCursor = cursorFromRow('p', fingerprint, cols, first)
+		}
+	}
+	for _, row := range out {
+		for _, name := range extra {
+			delete(row, name)
+		}
+		scanner.format(row)
+	}
+
+	res.Rows = out
+	res.HasMore = res.NextCursor != ""
+	return nil
+}
+
+// buildSelectWhere renders the tenant filter plus where/or_where/like/or_like.
+func buildSelectWhere(schema Schema, builder *sqlBuilder, companyID int64, req SelectRequest) ([]string, *ValidationError) {
+	whereParts := make([]string, 0, 8)
+
+	// Always apply the tenant filter (tenant_column, else company_id, else com_id).
+	// Global tables are shared by every tenant and have none.
+	tenantCol := schema.tenantColumn()
+	if tenantCol == "" && !schema.Global {
 		return nil, &ValidationError{Errors: map[string]string{"tenant": "schema does not support tenant filter (company_id/com_id missing)"}}
 	}
-	whereParts = append(whereParts, builder.eq(tenantCol, companyID))
+	if tenantCol != "" {
+		whereParts = append(whereParts, builder.eq(tenantCol, companyID))
+	}
 
 	// WHERE equals
 	if req.Where != nil {
 		keys := sortedKeys(req.Where)
 		for _, k := range keys {
 			col := resolveAlias(schema, k)
-			if !schema.hasColumn(col) {
+			if !schema.isSelectable(col) {
 				return nil, &ValidationError{Errors: map[string]string{k: "unknown field"}}
 			}
-			whereParts = append(whereParts, builder.eq(col, req.Where[k]))
+			whereParts = append(whereParts, builder.eq(schema.columnExpr(col), req.Where[k]))
 		}
 	}
 
@@ -99,26 +291,30 @@ func SelectPage(ctx context.Context, q Querier, schema Schema, companyID int64,
 		orParts := make([]string, 0, len(keys))
 		for _, k := range keys {
 			col := resolveAlias(schema, k)
-			if !schema.hasColumn(col) {
+			if !schema.isSelectable(col) {
 				return nil, &ValidationError{Errors: map[string]string{k: "unknown field"}}
 			}
-			orParts = append(orParts, builder.eq(col, req.OrWhere[k]))
+			orParts = append(orParts, builder.eq(schema.columnExpr(col), req.OrWhere[k]))
 		}
 		if len(orParts) > 0 {
 			whereParts = append(whereParts, "("+strings.Join(orParts, " OR ")+")")
 		}
 	}
 
-	// LIKE (case-insensitive on Postgres via ILIKE)
+	// LIKE (case-insensitive; ILIKE on Postgres, LOWER() LIKE LOWER() on MySQL)
 	if req.Like != nil {
 		keys := sortedKeys(req.Like)
 		for _, k := range keys {
 			col := resolveAlias(schema, k)
-			if !schema.hasColumn(col) {
+			if !schema.isSelectable(col) {
 				return nil, &ValidationError{Errors: map[string]string{k: "unknown field"}}
 			}
+			// A pattern on a hidden column reveals its value one character at a time.
+			if schema.IsHidden(col) {
+				return nil, &ValidationError{Errors: map[string]string{k: "hidden field"}}
+			}
 			pattern := normalizeLikePattern(req.Like[k])
-			whereParts = append(whereParts, builder.ilike(col, pattern))
+			whereParts = append(whereParts, builder.ilike(schema.columnExpr(col), pattern))
 		}
 	}
 
@@ -128,88 +324,81 @@ func SelectPage(ctx context.Context, q Querier, schema Schema, companyID int64,
 		orParts := make([]string, 0, len(keys))
 		for _, k := range keys {
 			col := resolveAlias(schema, k)
-			if !schema.hasColumn(col) {
+			if !schema.isSelectable(col) {
 				return nil, &ValidationError{Errors: map[string]string{k: "unknown field"}}
 			}
+			// A pattern on a hidden column reveals its value one character at a time.
+			if schema.IsHidden(col) {
+				return nil, &ValidationError{Errors: map[string]string{k: "hidden field"}}
+			}
 			pattern := normalizeLikePattern(req.OrLike[k])
-			orParts = append(orParts, builder.ilike(col, pattern))
+			orParts = append(orParts, builder.ilike(schema.columnExpr(col), pattern))
 		}
 		if len(orParts) > 0 {
 			whereParts = append(whereParts, "("+strings.Join(orParts, " OR ")+")")
 		}
 	}
 
-	// Count total rows for accurate paging metadata.
-	// Must be executed BEFORE adding limit/offset args.
-	countQuery := fmt.Sprintf(
-		"SELECT COUNT(*) FROM %s WHERE %s",
-		schema.Table,
-		strings.Join(whereParts, " AND "),
-	)
-	var totalRows64 int64
-	countRows, err := q.QueryContext(ctx, countQuery, builder.args...)
+	// Filters tree (and/or groups with operators)
+	if req.Filters != nil {
+		sql, verr := buildFilters(schema, builder, *req.Filters)
+		if verr != nil {
+			return nil, verr
+		}
+		whereParts = append(whereParts, sql)
+	}
+	if len(whereParts) == 0 {
+		// Unfiltered read of a global table.
+		whereParts = append(whereParts, "TRUE")
+	}
+	return whereParts, nil
+}
+
+func exactCount(ctx context.Context, q Querier, table, where string, args []any) (int, error) {
+	countRows, err := q.QueryContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table, where), args...)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	// Important: close rows before issuing another query on the same tx/connection.
+	defer countRows.Close()
 	if !countRows.Next() {
-		_ = countRows.Close()
 		if err := countRows.Err(); err != nil {
-			return nil, err
+			return 0, err
 		}
-		return nil, fmt.Errorf("count query returned no rows")
-	}
-	if err := countRows.Scan(&totalRows64); err != nil {
-		_ = countRows.Close()
-		return nil, err
+		return 0, fmt.Errorf("count query returned no rows")
 	}
-	// Important: close rows before issuing another query on the same tx/connection.
-	_ = countRows.Close()
-	totalRows := int(totalRows64)
-	totalPages := 0
-	if perPage > 0 {
-		totalPages = (totalRows + perPage - 1) / perPage
+	var n int64
+	if err := countRows.Scan(&n); err != nil {
+		return 0, err
 	}
+	return int(n), nil
+}
 
-	orderBySQL, verr := buildOrderBy(schema, req.OrderBy)
-	if verr != nil {
-		return nil, verr
-	}
-
-	query := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE %s%s LIMIT %s OFFSET %s",
-		strings.Join(selectCols, ","),
-		schema.Table,
-		strings.Join(whereParts, " AND "),
-		orderBySQL,
-		builder.arg(limit),
-		builder.arg(offset),
-	)
-
-	rows, err := q.QueryContext(ctx, query, builder.args...)
+// queryRows returns raw rows plus the scanner that formats them for output; callers format
+// after anything that needs the typed driver values (keyset cursors).
+func queryRows(ctx context.Context, q Querier, casts map[string]CastType, query string, args []any, capHint int) ([]map[string]any, *rowScanner, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	out := make([]map[string]any, 0, perPage)
+	s, err := newRowScanner(rows, casts)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make([]map[string]any, 0, capHint)
 	for rows.Next() {
-		m, err := scanCurrentRowToMap(rows)
+		m, err := s.scan(rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		out = append(out, m)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	hasMore := false
-	if len(out) > perPage {
-		hasMore = true
-		out = out[:perPage]
+		return nil, nil, err
 	}
-
-	return &PageResult{Rows: out, Page: page, PerPage: perPage, HasMore: hasMore, TotalRows: totalRows, TotalPages: totalPages}, nil
+	return out, s, nil
 }
 
 func normalizeLikePattern(v any) string {
@@ -227,8 +416,8 @@ func normalizeLikePattern(v any) string {
 
 func normalizeSelect(schema Schema, selectCols []string) ([]string, *ValidationError) {
 	if len(selectCols) == 0 {
-		// default: all columns
-		return schema.Columns, nil
+		// default: all visible columns (+ computed columns)
+		return schema.defaultSelectList(), nil
 	}
 
 	cols := make([]string, 0, len(selectCols))
@@ -244,11 +433,15 @@ func normalizeSelect(schema Schema, selectCols []string) ([]string, *ValidationE
 			continue
 		}
 		seen[col] = true
-		if !schema.hasColumn(col) {
+		if !schema.isSelectable(col) {
 			errs[raw] = "unknown field"
 			continue
 		}
-		cols = append(cols, col)
+		if schema.IsHidden(col) {
+			errs[raw] = "hidden field"
+			continue
+		}
+		cols = append(cols, schema.selectExpr(col))
 	}
 	if len(errs) > 0 {
 		return nil, &ValidationError{Errors: errs}
@@ -273,10 +466,15 @@ func buildOrderBy(schema Schema, orderBy []OrderBy) (string, *ValidationError) {
 			errs[fmt.Sprintf("order_by[%d].field", i)] = "required"
 			continue
 		}
-		if !schema.hasColumn(field) {
+		if !schema.isSelectable(field) {
 			errs[fmt.Sprintf("order_by[%d].field", i)] = "unknown field"
 			continue
 		}
+		// Sorting on a hidden column leaks its order (and, with filters, its value).
+		if schema.IsHidden(field) {
+			errs[fmt.Sprintf("order_by[%d].field", i)] = "hidden field"
+			continue
+		}
 		dir := strings.ToLower(strings.TrimSpace(ob.Dir))
 		if dir == "" {
 			dir = "asc"
@@ -285,7 +483,7 @@ func buildOrderBy(schema Schema, orderBy []OrderBy) (string, *ValidationError) {
 			errs[fmt.Sprintf("order_by[%d].dir", i)] = "must be asc or desc"
 			continue
 		}
-		parts = append(parts, fmt.Sprintf("%s %s", field, strings.ToUpper(dir)))
+		parts = append(parts, fmt.Sprintf("%s %s", schema.columnExpr(field), strings.ToUpper(dir)))
 	}
 	if len(errs) > 0 {
 		return "", &ValidationError{Errors: errs}
@@ -313,16 +511,18 @@ func sortedKeys(m map[string]any) []string {
 }
 
 type sqlBuilder struct {
+	d    dialect.Dialect
 	args []any
 }
 
+// newSQLBuilder numbers placeholders for the process-wide dialect (see dialect.Current).
 func newSQLBuilder() *sqlBuilder {
-	return &sqlBuilder{args: make([]any, 0, 16)}
+	return &sqlBuilder{d: dialect.Current(), args: make([]any, 0, 16)}
 }
 
 func (b *sqlBuilder) push(v any) string {
 	b.args = append(b.args, v)
-	return fmt.Sprintf("$%d", len(b.args))
+	return b.d.Placeholder(len(b.args))
 }
 
 func (b *sqlBuilder) arg(v any) string {
@@ -334,6 +534,5 @@ func (b *sqlBuilder) eq(col string, v any) string {
 }
 
 func (b *sqlBuilder) ilike(col string, v any) string {
-	// Postgres-only operator; good enough for current docker env.
-	return fmt.Sprintf("%s ILIKE %s", col, b.push(v))
+	return b.d.ILike(col, b.push(v))
 }
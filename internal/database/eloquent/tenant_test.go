@@ -0,0 +1,54 @@
+package eloquent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildSelectWhereTenant(t *testing.T) {
+	cases := []struct {
+		name    string
+		schema  Schema
+		want    string
+		wantErr bool
+	}{
+		{"company_id", Schema{Table: "pasien", Columns: []string{"kd_ps", "company_id", "com_id"}}, "company_id = $1", false},
+		{"legacy com_id", Schema{Table: "dokter", Columns: []string{"kd_dr", "com_id"}}, "com_id = $1", false},
+		{"tenant_column", Schema{Table: "cabang", Columns: []string{"id", "company_id", "lab_id"}, TenantColumn: "lab_id"}, "lab_id = $1", false},
+		{"unknown tenant_column", Schema{Table: "cabang", Columns: []string{"id", "company_id"}, TenantColumn: "lab_id"}, "", true},
+		{"global", Schema{Table: "provinsi", Columns: []string{"kd_prov", "nama"}, Global: true}, "TRUE", false},
+		{"no tenant column", Schema{Table: "provinsi", Columns: []string{"kd_prov", "nama"}}, "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parts, verr := buildSelectWhere(tc.schema, newSQLBuilder(), 7, SelectRequest{})
+			if tc.wantErr {
+				if verr == nil {
+					t.Fatalf("expected error, got %v", parts)
+				}
+				return
+			}
+			if verr != nil {
+				t.Fatalf("unexpected errors: %#v", verr.Errors)
+			}
+			if got := strings.Join(parts, " AND "); got != tc.want {
+				t.Fatalf("got %q want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTenantScopeGlobalOnly(t *testing.T) {
+	b := newSQLBuilder()
+	if _, verr := (Schema{Table: "pasien"}).tenantScope(b, "", 7); verr == nil {
+		t.Fatal("empty tenant column must be rejected for tenant tables")
+	}
+	if cond, verr := (Schema{Table: "provinsi", Global: true}).tenantScope(b, "", 7); verr != nil || cond != "" {
+		t.Fatalf("global: cond=%q err=%v", cond, verr)
+	}
+	// Guarded never strips a declared tenant column; the server forces it.
+	s := Schema{Table: "cabang", PrimaryKey: "id", Columns: []string{"id", "lab_id", "nama"}, TenantColumn: "lab_id", Guarded: []string{"lab_id"}}
+	if !s.fillableSet()["lab_id"] {
+		t.Fatal("tenant column must stay fillable")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build mysql
+
+package db
+
+// The MySQL driver is opt-in so Postgres-only builds do not carry the dependency:
+//
+//	go get github.com/go-sql-driver/mysql
+//	go build -tags mysql ./...
+import _ "github.com/go-sql-driver/mysql"
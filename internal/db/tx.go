@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"sync"
 )
 
 type TxFunc[T any] func(tx *sql.Tx) (T, error)
@@ -14,6 +16,12 @@ func WithTx[T any](ctx context.Context, db *sql.DB, fn TxFunc[T]) (T, error) {
 		return zero, err
 	}
 
+	if err := applyTxScope(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		var zero T
+		return zero, err
+	}
+
 	out, err := fn(tx)
 	if err != nil {
 		_ = tx.Rollback()
@@ -28,3 +36,41 @@ func WithTx[T any](ctx context.Context, db *sql.DB, fn TxFunc[T]) (T, error) {
 
 	return out, nil
 }
+
+// TxScopeFunc returns the tenant and user behind ctx; ok=false outside an authenticated request.
+type TxScopeFunc func(ctx context.Context) (companyID, userID int64, ok bool)
+
+var (
+	scopeMu sync.RWMutex
+	txScope TxScopeFunc
+)
+
+// SetTxScope enables row-level security mode (DB_RLS=on): every WithTx transaction whose context
+// has a scope sets app.company_id and app.user_id for its own duration, so the policies generated
+// by internal/rls filter rows even when a query forgets the tenant condition. nil disables it.
+func SetTxScope(fn TxScopeFunc) {
+	scopeMu.Lock()
+	txScope = fn
+	scopeMu.Unlock()
+}
+
+func applyTxScope(ctx context.Context, tx *sql.Tx) error {
+	scopeMu.RLock()
+	fn := txScope
+	scopeMu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	companyID, userID, ok := fn(ctx)
+	if !ok {
+		return nil
+	}
+	// set_config(..., true) is SET LOCAL with bind parameters: the values end with the transaction,
+	// so pooled connections never carry a previous request's tenant.
+	_, err := tx.ExecContext(ctx,
+		"SELECT set_config('app.company_id', $1, true), set_config('app.user_id', $2, true)",
+		strconv.FormatInt(companyID, 10),
+		strconv.FormatInt(userID, 10),
+	)
+	return err
+}
@@ -0,0 +1,167 @@
+// Package export turns select and /v1/query results into CSV or XLSX downloads.
+//
+// Rows are streamed from the database cursor straight into the response (eloquent.RowSink),
+// so an export never holds more than one row in memory. Validation errors are reported as
+// JSON before the first byte is written; a failure after that aborts the connection so the
+// client sees a broken download instead of a silently truncated file.
+package export
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/schema"
+	"mylab-api-go/internal/spreadsheet"
+)
+
+// DefaultMaxRows caps one export unless EXPORT_MAX_ROWS says otherwise.
+const DefaultMaxRows = 100000
+
+// Options is the "export" object of POST /v1/crud/{table}/select and POST /v1/query.
+type Options struct {
+	Format    string            `json:"format"`    // csv | xlsx
+	Columns   []string          `json:"columns"`   // columns to export, in order (default: the select list)
+	Labels    map[string]string `json:"labels"`    // column -> header, overrides schema ui labels
+	Lang      string            `json:"lang"`      // ui label language (default: Accept-Language, else id)
+	Timezone  string            `json:"timezone"`  // IANA zone for datetimes (default: tenant zone)
+	Filename  string            `json:"filename"`  // download name without extension
+	Delimiter string            `json:"delimiter"` // CSV only: "," (default), ";" or "tab"
+}
+
+var filenameRE = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Validate checks the options and returns the file format.
+func (o Options) Validate() (spreadsheet.Format, *eloquent.ValidationError) {
+	errs := map[string]string{}
+	format := spreadsheet.Format(strings.ToLower(strings.TrimSpace(o.Format)))
+	if format != spreadsheet.CSV && format != spreadsheet.XLSX {
+		errs["export.format"] = "must be csv or xlsx"
+	}
+	if _, ok := delimiter(o.Delimiter); !ok {
+		errs["export.delimiter"] = `must be ",", ";" or "tab"`
+	}
+	if strings.TrimSpace(o.Timezone) != "" {
+		if _, err := time.LoadLocation(strings.TrimSpace(o.Timezone)); err != nil {
+			errs["export.timezone"] = "unknown time zone"
+		}
+	}
+	if len(errs) > 0 {
+		return "", &eloquent.ValidationError{Errors: errs}
+	}
+	return format, nil
+}
+
+func delimiter(s string) (rune, bool) {
+	switch strings.ToLower(s) {
+	case "", ",":
+		return ',', true
+	case ";":
+		return ';', true
+	case "tab", "\t":
+		return '\t', true
+	}
+	return 0, false
+}
+
+// MaxRows is EXPORT_MAX_ROWS, else DefaultMaxRows.
+func MaxRows() int {
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("EXPORT_MAX_ROWS"))); err == nil && n > 0 {
+		return n
+	}
+	return DefaultMaxRows
+}
+
+var (
+	zonesMu     sync.RWMutex
+	tenantZones = map[int64]*time.Location{}
+)
+
+// ParseTenantTimezones parses TENANT_TIMEZONES: "12:Asia/Makassar,15:Asia/Jayapura".
+func ParseTenantTimezones(raw string) (map[int64]*time.Location, error) {
+	out := map[int64]*time.Location{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, zone, ok := strings.Cut(part, ":")
+		n, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+		if !ok || err != nil || n <= 0 {
+			return nil, fmt.Errorf("%q: expected company_id:Zone/Name", part)
+		}
+		loc, err := time.LoadLocation(strings.TrimSpace(zone))
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", part, err)
+		}
+		out[n] = loc
+	}
+	return out, nil
+}
+
+// SetTenantTimezones sets the per-tenant export zones (called once at startup).
+func SetTenantTimezones(m map[int64]*time.Location) {
+	zonesMu.Lock()
+	tenantZones = m
+	zonesMu.Unlock()
+}
+
+// Location picks the zone datetimes are exported in: the request's timezone, else the
+// tenant's TENANT_TIMEZONES entry, else nil (OUTPUT_TIMEZONE / server zone).
+func Location(companyID int64, override string) *time.Location {
+	if tz := strings.TrimSpace(override); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc
+		}
+	}
+	zonesMu.RLock()
+	defer zonesMu.RUnlock()
+	return tenantZones[companyID]
+}
+
+// Lang is the label language: the request's lang, else the first Accept-Language tag, else "id".
+func Lang(r *http.Request, lang string) string {
+	if lang = strings.ToLower(strings.TrimSpace(lang)); lang != "" {
+		return lang
+	}
+	first := strings.Split(r.Header.Get("Accept-Language"), ",")[0]
+	first = strings.ToLower(strings.TrimSpace(strings.Split(first, ";")[0]))
+	if tag, _, _ := strings.Cut(first, "-"); tag != "" && tag != "*" {
+		return tag
+	}
+	return "id"
+}
+
+// Title returns a column header: the explicit label, else the schema ui label in lang, else
+// the column name.
+func Title(col string, labels map[string]string, ui map[string]schema.ColumnUI, lang string) string {
+	if l := strings.TrimSpace(labels[col]); l != "" {
+		return l
+	}
+	if l := strings.TrimSpace(ui[col].Label[lang]); l != "" {
+		return l
+	}
+	return col
+}
+
+// Filename returns a safe download name with the format's extension, defaulting to
+// {base}-{yyyymmdd-hhmm} in loc.
+func Filename(name, base string, format spreadsheet.Format, loc *time.Location) string {
+	name = strings.Trim(filenameRE.ReplaceAllString(strings.TrimSpace(name), "-"), "-.")
+	if name == "" {
+		if loc == nil {
+			loc = time.Local
+		}
+		name = base + "-" + time.Now().In(loc).Format("20060102-1504")
+	}
+	if ext := "." + string(format); !strings.HasSuffix(strings.ToLower(name), ext) {
+		name += ext
+	}
+	return name
+}
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/schema"
+	"mylab-api-go/internal/spreadsheet"
+)
+
+func TestParseTenantTimezones(t *testing.T) {
+	got, err := ParseTenantTimezones(" 12:Asia/Makassar, 15:Asia/Jayapura ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[12].String() != "Asia/Makassar" || got[15].String() != "Asia/Jayapura" {
+		t.Fatalf("zones = %v", got)
+	}
+	for _, bad := range []string{"12", "x:Asia/Jakarta", "0:UTC", "12:Mars/Olympus"} {
+		if _, err := ParseTenantTimezones(bad); err == nil {
+			t.Errorf("ParseTenantTimezones(%q) should fail", bad)
+		}
+	}
+}
+
+func TestTitleAndLang(t *testing.T) {
+	ui := map[string]schema.ColumnUI{"nama_ps": {Label: map[string]string{"id": "Nama Pasien", "en": "Patient name"}}}
+	r := httptest.NewRequest("POST", "/v1/query", nil)
+	r.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	lang := Lang(r, "")
+	if lang != "en" {
+		t.Fatalf("Lang = %q", lang)
+	}
+	if got := Title("nama_ps", nil, ui, lang); got != "Patient name" {
+		t.Errorf("Title = %q", got)
+	}
+	if got := Title("nama_ps", map[string]string{"nama_ps": "Nama"}, ui, lang); got != "Nama" {
+		t.Errorf("Title with label = %q", got)
+	}
+	if got := Title("no_rm", nil, ui, lang); got != "no_rm" {
+		t.Errorf("Title fallback = %q", got)
+	}
+	if got := Lang(httptest.NewRequest("POST", "/", nil), ""); got != "id" {
+		t.Errorf("default Lang = %q", got)
+	}
+}
+
+func TestFilename(t *testing.T) {
+	if got := Filename("Laporan Maret/2026", "pasien", spreadsheet.XLSX, nil); got != "Laporan-Maret-2026.xlsx" {
+		t.Errorf("Filename = %q", got)
+	}
+	if got := Filename("data.csv", "pasien", spreadsheet.CSV, nil); got != "data.csv" {
+		t.Errorf("Filename = %q", got)
+	}
+	loc := time.FixedZone("WITA", 8*3600)
+	if got := Filename("", "pasien", spreadsheet.CSV, loc); len(got) != len("pasien-20260318-1030.csv") {
+		t.Errorf("default Filename = %q", got)
+	}
+}
+
+func TestStreamProjection(t *testing.T) {
+	rec := httptest.NewRecorder()
+	st := NewStream(rec, spreadsheet.CSV, "pasien.csv", Options{Delimiter: ";"})
+	st.Project = []string{"nama_ps", "id"}
+	st.Title = func(col string) string { return "[" + col + "]" }
+
+	cols := []eloquent.ExportColumn{{Name: "id", Kind: eloquent.ExportNumber}, {Name: "nama_ps", Kind: eloquent.ExportText}, {Name: "alamat"}}
+	if err := st.Columns(cols); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Row([]any{int64(7), "Budi", "Jl. Merdeka"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="pasien.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	want := "\xef\xbb\xbf[nama_ps];[id]\r\nBudi;7\r\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+
+	st = NewStream(httptest.NewRecorder(), spreadsheet.CSV, "x.csv", Options{})
+	st.Project = []string{"nope"}
+	var ve *eloquent.ValidationError
+	if err := st.Columns(cols); !errors.As(err, &ve) || st.Started() {
+		t.Fatalf("unknown column: err = %v, started = %v", err, st.Started())
+	}
+	if !reflect.DeepEqual(ve.Errors, map[string]string{"export.columns": `"nope" is not a result column`}) {
+		t.Errorf("errors = %v", ve.Errors)
+	}
+}
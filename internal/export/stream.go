@@ -0,0 +1,112 @@
+package export
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/spreadsheet"
+)
+
+// Stream is an eloquent.RowSink that writes the result as a file download. Nothing is sent
+// until Columns succeeds, so errors before that can still be answered with JSON.
+type Stream struct {
+	// Title maps a result column to its header (see export.Title); nil uses the column name.
+	Title func(col string) string
+	// Project restricts and orders the result columns (e.g. Options.Columns on /v1/query);
+	// empty exports every column as returned.
+	Project []string
+
+	w        http.ResponseWriter
+	format   spreadsheet.Format
+	filename string
+	opt      spreadsheet.WriteOptions
+	out      spreadsheet.Writer
+	index    []int
+	row      []any
+	rows     int
+}
+
+// writeWindow is how long the client gets to accept each chunk of rows. Exports outlive the
+// server's WriteTimeout, so the deadline is pushed forward as rows go out.
+const (
+	writeWindow     = 30 * time.Second
+	rowsPerDeadline = 1000
+)
+
+// NewStream prepares a download of format named filename (see export.Filename).
+func NewStream(w http.ResponseWriter, format spreadsheet.Format, filename string, o Options) *Stream {
+	delim, _ := delimiter(o.Delimiter)
+	return &Stream{w: w, format: format, filename: filename, opt: spreadsheet.WriteOptions{Sheet: "Export", Delimiter: delim}}
+}
+
+// Started reports whether response bytes may have been written (errors can no longer be JSON).
+func (s *Stream) Started() bool { return s.out != nil }
+
+func (s *Stream) Columns(cols []eloquent.ExportColumn) error {
+	s.index = make([]int, 0, len(cols))
+	if len(s.Project) == 0 {
+		for i := range cols {
+			s.index = append(s.index, i)
+		}
+	} else {
+		pos := map[string]int{}
+		for i, c := range cols {
+			pos[c.Name] = i
+		}
+		for _, name := range s.Project {
+			i, ok := pos[name]
+			if !ok {
+				return &eloquent.ValidationError{Errors: map[string]string{"export.columns": fmt.Sprintf("%q is not a result column", name)}}
+			}
+			s.index = append(s.index, i)
+		}
+	}
+
+	header := make([]spreadsheet.Column, len(s.index))
+	for j, i := range s.index {
+		title := cols[i].Name
+		if s.Title != nil {
+			title = s.Title(cols[i].Name)
+		}
+		header[j] = spreadsheet.Column{Title: title, Kind: spreadsheet.Kind(cols[i].Kind)}
+	}
+	s.row = make([]any, len(s.index))
+
+	h := s.w.Header()
+	h.Set("Content-Type", spreadsheet.ContentType(s.format))
+	h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, s.filename))
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Content-Type-Options", "nosniff")
+	s.extendDeadline()
+	out, err := spreadsheet.NewWriter(s.w, s.format, s.opt)
+	if err != nil {
+		return err
+	}
+	s.out = out
+	return out.Header(header)
+}
+
+func (s *Stream) Row(values []any) error {
+	for j, i := range s.index {
+		s.row[j] = values[i]
+	}
+	if s.rows++; s.rows%rowsPerDeadline == 0 {
+		s.extendDeadline()
+	}
+	return s.out.Row(s.row)
+}
+
+func (s *Stream) extendDeadline() {
+	// Not every ResponseWriter supports deadlines (tests); the server timeout then applies.
+	_ = http.NewResponseController(s.w).SetWriteDeadline(time.Now().Add(writeWindow))
+}
+
+// Close finishes the file. It is a no-op when nothing was started.
+func (s *Stream) Close() error {
+	if s.out == nil {
+		return nil
+	}
+	return s.out.Close()
+}
@@ -0,0 +1,323 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mylab-api-go/internal/database/dialect"
+	"mylab-api-go/internal/database/eloquent"
+)
+
+// Row version history for tables declared versioned=true in their schema file.
+//
+// Every create/update/delete of such a row stores a full snapshot of the row as it is after
+// the change (for a delete: the last state before it). Snapshots are written with the
+// caller's transaction, numbered per row and never updated, so "the row as of T" is the
+// newest version valid at T. Rows that existed before versioning was switched on get a
+// "baseline" version (valid since the beginning) the first time they change.
+
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionRevert   = "revert"
+	ActionBaseline = "baseline"
+)
+
+// Table is the gateway-owned version table (created on first use).
+const Table = "gateway_row_versions"
+
+// ErrUnsupportedDialect is returned by NewStore on non-Postgres databases (jsonb storage).
+var ErrUnsupportedDialect = errors.New("row history requires postgres")
+
+// Entry is one change to record. Old is nil for create, New is nil for delete.
+// Scope is the tenant id the row belongs to (0 for global tables).
+type Entry struct {
+	Table        string
+	PK           string
+	Scope        int64
+	Action       string
+	UserID       int64
+	RequestID    string
+	RevertedFrom int64 // version restored by a revert
+	Old          map[string]any
+	New          map[string]any
+}
+
+// Version is one stored snapshot. ValidFrom is nil for a baseline (state before versioning).
+type Version struct {
+	Version      int64           `json:"version"`
+	Action       string          `json:"action"`
+	ValidFrom    *time.Time      `json:"valid_from"`
+	UserID       int64           `json:"user_id"`
+	RequestID    string          `json:"request_id"`
+	RevertedFrom *int64          `json:"reverted_from,omitempty"`
+	Data         json.RawMessage `json:"data"`
+}
+
+// Store writes and reads row versions.
+type Store struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	ready bool
+}
+
+func NewStore(db *sql.DB) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	if !dialect.IsPostgres() {
+		return nil, ErrUnsupportedDialect
+	}
+	return &Store{db: db}, nil
+}
+
+// ensureTable creates the version table once per process, outside the caller's transaction
+// (same approach as the audit recorder).
+func (s *Store) ensureTable(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+
+	stmts := []string{
+		fmt.Sprintf(`
+create table if not exists %s (
+  id bigserial primary key,
+  scope bigint not null,
+  table_name text not null,
+  pk text not null,
+  version bigint not null,
+  action text not null,
+  valid_from timestamptz null,
+  user_id bigint not null default 0,
+  request_id text not null default '',
+  reverted_from bigint null,
+  row_data jsonb not null
+)
+`, Table),
+		fmt.Sprintf(`create unique index if not exists %s_row_version_idx on %s (scope, table_name, pk, version)`, Table, Table),
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	s.ready = true
+	return nil
+}
+
+// Record stores the snapshot for e using q (normally the mutation's *sql.Tx) and returns its
+// version number. The caller holds the row lock (the UPDATE/DELETE ran first), so concurrent
+// changes of one row are numbered in commit order.
+func (s *Store) Record(ctx context.Context, q eloquent.Querier, e Entry) (int64, error) {
+	if strings.TrimSpace(e.Table) == "" || strings.TrimSpace(e.PK) == "" {
+		return 0, fmt.Errorf("history: table and pk are required")
+	}
+	row := e.New
+	switch e.Action {
+	case ActionCreate, ActionUpdate, ActionRevert:
+	case ActionDelete:
+		row = e.Old
+	default:
+		return 0, fmt.Errorf("history: invalid action %q", e.Action)
+	}
+	if row == nil {
+		return 0, fmt.Errorf("history: %s needs a row snapshot", e.Action)
+	}
+	if err := s.ensureTable(ctx); err != nil {
+		return 0, err
+	}
+
+	last, err := s.lastVersion(ctx, q, e.Scope, e.Table, e.PK)
+	if err != nil {
+		return 0, err
+	}
+	if last == 0 && e.Old != nil {
+		// First change since versioning was enabled: keep the prior state as well.
+		if err := s.insert(ctx, q, e, 1, ActionBaseline, e.Old, false); err != nil {
+			return 0, err
+		}
+		last = 1
+	}
+	if err := s.insert(ctx, q, e, last+1, e.Action, row, true); err != nil {
+		return 0, err
+	}
+	return last + 1, nil
+}
+
+func (s *Store) lastVersion(ctx context.Context, q eloquent.Querier, scope int64, table, pk string) (int64, error) {
+	rows, err := q.QueryContext(ctx,
+		fmt.Sprintf(`select coalesce(max(version), 0) from %s where scope = $1 and table_name = $2 and pk = $3`, Table),
+		scope, table, pk,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return 0, err
+		}
+	}
+	return n, rows.Err()
+}
+
+func (s *Store) insert(ctx context.Context, q eloquent.Querier, e Entry, version int64, action string, row map[string]any, dated bool) error {
+	b, err := json.Marshal(normalizeRow(row))
+	if err != nil {
+		return err
+	}
+	var revertedFrom any
+	if action == ActionRevert && e.RevertedFrom > 0 {
+		revertedFrom = e.RevertedFrom
+	}
+	// clock_timestamp, not now(): versions must be ordered by when the row lock was held,
+	// not by when the transaction started.
+	validFrom := "null"
+	if dated {
+		validFrom = "clock_timestamp()"
+	}
+	_, err = q.ExecContext(ctx, fmt.Sprintf(`
+insert into %s (scope, table_name, pk, version, action, valid_from, user_id, request_id, reverted_from, row_data)
+values ($1,$2,$3,$4,$5,%s,$6,$7,$8,$9)
+`, Table, validFrom),
+		e.Scope, e.Table, e.PK, version, action, e.UserID, e.RequestID, revertedFrom, string(b),
+	)
+	return err
+}
+
+// normalizeRow converts driver values into JSON-friendly ones ([]byte → string, time → UTC).
+func normalizeRow(row map[string]any) map[string]any {
+	out := make(map[string]any, len(row))
+	for k, v := range row {
+		switch t := v.(type) {
+		case []byte:
+			out[k] = string(t)
+		case time.Time:
+			out[k] = t.UTC()
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+const versionColumns = `version, action, valid_from, user_id, request_id, reverted_from, row_data::text`
+
+// ListResult is a page of versions, newest first.
+type ListResult struct {
+	Rows    []Version
+	Page    int
+	PerPage int
+	HasMore bool
+}
+
+// List returns the versions of one row, newest first.
+func (s *Store) List(ctx context.Context, q eloquent.Querier, scope int64, table, pk string, page, perPage int) (*ListResult, error) {
+	if err := s.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if perPage <= 0 {
+		perPage = eloquent.DefaultPerPage
+	}
+	if perPage > eloquent.MaxPerPage {
+		perPage = eloquent.MaxPerPage
+	}
+	out, err := s.query(ctx, q, fmt.Sprintf(`
+select %s from %s
+where scope = $1 and table_name = $2 and pk = $3
+order by version desc
+limit %d offset %d
+`, versionColumns, Table, perPage+1, (page-1)*perPage), scope, table, pk)
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(out) > perPage
+	if hasMore {
+		out = out[:perPage]
+	}
+	return &ListResult{Rows: out, Page: page, PerPage: perPage, HasMore: hasMore}, nil
+}
+
+// Get returns one version of a row, or nil when it does not exist.
+func (s *Store) Get(ctx context.Context, q eloquent.Querier, scope int64, table, pk string, version int64) (*Version, error) {
+	if err := s.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	out, err := s.query(ctx, q, fmt.Sprintf(`
+select %s from %s
+where scope = $1 and table_name = $2 and pk = $3 and version = $4
+`, versionColumns, Table), scope, table, pk, version)
+	if err != nil || len(out) == 0 {
+		return nil, err
+	}
+	return &out[0], nil
+}
+
+// AsOf returns the version of a row that was current at t. v is nil when no version was
+// valid yet; versioned reports whether the row has any version at all (when it has none it
+// never changed since versioning was enabled, so the live row is the answer).
+func (s *Store) AsOf(ctx context.Context, q eloquent.Querier, scope int64, table, pk string, t time.Time) (v *Version, versioned bool, err error) {
+	if err := s.ensureTable(ctx); err != nil {
+		return nil, false, err
+	}
+	last, err := s.lastVersion(ctx, q, scope, table, pk)
+	if err != nil || last == 0 {
+		return nil, false, err
+	}
+	out, err := s.query(ctx, q, fmt.Sprintf(`
+select %s from %s
+where scope = $1 and table_name = $2 and pk = $3 and (valid_from is null or valid_from <= $4)
+order by version desc
+limit 1
+`, versionColumns, Table), scope, table, pk, t)
+	if err != nil || len(out) == 0 {
+		return nil, true, err
+	}
+	return &out[0], true, nil
+}
+
+func (s *Store) query(ctx context.Context, q eloquent.Querier, query string, args ...any) ([]Version, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Version{}
+	for rows.Next() {
+		var (
+			v            Version
+			validFrom    sql.NullTime
+			revertedFrom sql.NullInt64
+			raw          string
+		)
+		if err := rows.Scan(&v.Version, &v.Action, &validFrom, &v.UserID, &v.RequestID, &revertedFrom, &raw); err != nil {
+			return nil, err
+		}
+		if validFrom.Valid {
+			t := validFrom.Time
+			v.ValidFrom = &t
+		}
+		if revertedFrom.Valid {
+			n := revertedFrom.Int64
+			v.RevertedFrom = &n
+		}
+		v.Data = json.RawMessage(raw)
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
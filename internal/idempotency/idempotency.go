@@ -0,0 +1,264 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mylab-api-go/internal/database/dialect"
+	"mylab-api-go/internal/routes/auth"
+	"mylab-api-go/internal/routes/shared"
+)
+
+// Idempotency-Key support for POST endpoints (create, import, batch, plugins).
+//
+// A POST carrying an Idempotency-Key header runs once per tenant, user and key for
+// IDEMPOTENCY_TTL: the response is stored with a hash of the request, and a retry with the
+// same key gets the stored response back (Idempotent-Replayed: true). The same key with a
+// different request is rejected with 422; a retry while the first request is still running
+// waits up to IDEMPOTENCY_WAIT for its response, then gets 409.
+//
+// Server errors (5xx) and responses larger than MaxResponseBytes are not stored: the key is
+// released and a retry runs again.
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	DefaultTTL  = 24 * time.Hour
+	DefaultWait = 10 * time.Second
+
+	MaxKeyLength     = 255
+	MaxRequestBytes  = 64 << 20 // bodies are hashed in memory
+	MaxResponseBytes = 1 << 20
+)
+
+// lockTimeout is how long a running request owns its key; after it (e.g. the instance died
+// mid-request) a retry may take the key over. Longer than the server's write timeout.
+const lockTimeout = 2 * time.Minute
+
+// replayHeaders are the response headers kept with a stored response.
+var replayHeaders = []string{"Content-Type", "Content-Disposition", "Location"}
+
+// Middleware applies idempotency keys to the handlers it wraps.
+type Middleware struct {
+	store Store
+	ttl   time.Duration
+	wait  time.Duration
+}
+
+func New(store Store, ttl, wait time.Duration) *Middleware {
+	return &Middleware{store: store, ttl: ttl, wait: wait}
+}
+
+// FromEnv builds the middleware from IDEMPOTENCY_TTL / IDEMPOTENCY_WAIT (seconds). Keys are
+// kept in Postgres when sqlDB is a Postgres database, in memory otherwise. TTL 0 disables it.
+func FromEnv(sqlDB *sql.DB) *Middleware {
+	ttl := envSeconds("IDEMPOTENCY_TTL", DefaultTTL)
+	wait := envSeconds("IDEMPOTENCY_WAIT", DefaultWait)
+	if ttl <= 0 {
+		return New(nil, 0, 0)
+	}
+	if sqlDB != nil && dialect.IsPostgres() {
+		if store, err := NewPostgresStore(sqlDB); err == nil {
+			return New(store, ttl, wait)
+		}
+	}
+	log.Printf("idempotency: keys kept in memory (not shared between instances)")
+	return New(NewMemoryStore(), ttl, wait)
+}
+
+func envSeconds(key string, def time.Duration) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return def
+	}
+	return time.Duration(n) * time.Second
+}
+
+// Wrap honours Idempotency-Key on POST requests to next. CRUD /select (a read, possibly a
+// streamed export) and requests without the header pass through untouched.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	if m == nil || m.store == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" || r.Method != http.MethodPost || isSelect(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authInfo, ok := auth.AuthInfoFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > MaxKeyLength || !printable(key) {
+			writeError(w, r, http.StatusUnprocessableEntity, "Validation failed.", "invalid (1-255 printable ASCII characters)", "validation_error")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBytes+1))
+		if err != nil {
+			writeError(w, r, http.StatusUnprocessableEntity, "Validation failed.", "request body could not be read", "validation_error")
+			return
+		}
+		if len(body) > MaxRequestBytes {
+			writeError(w, r, http.StatusRequestEntityTooLarge, "Request too large.", fmt.Sprintf("requests with a key must not exceed %d bytes", MaxRequestBytes), "request_too_large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := Scope{CompanyID: authInfo.CompanyID, UserID: authInfo.UserID, Key: key}
+		hash := requestHash(r, body)
+		entry, err := m.claim(r.Context(), scope, hash)
+		if err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+			log.Printf("idempotency: %v", err)
+			writeError(w, r, http.StatusInternalServerError, "Internal server error.", "store unavailable", "")
+			return
+		}
+		if entry != nil {
+			switch {
+			case entry.Hash != hash:
+				writeError(w, r, http.StatusUnprocessableEntity, "Validation failed.", "already used for a different request", "idempotency_key_reused")
+			case entry.Response == nil:
+				w.Header().Set("Retry-After", "1")
+				writeError(w, r, http.StatusConflict, "Conflict.", "a request with this key is still in progress", "idempotency_in_progress")
+			default:
+				replay(w, *entry.Response)
+			}
+			return
+		}
+
+		rec := &recorder{w: w}
+		// Deferred so a panicking handler (or an aborted stream) gives the key up too.
+		defer func() {
+			ctx := context.WithoutCancel(r.Context())
+			if rec.status < http.StatusInternalServerError && rec.status != 0 && !rec.overflow {
+				res := Response{Status: rec.status, Header: map[string]string{}, Body: rec.buf.Bytes()}
+				for _, h := range replayHeaders {
+					if v := w.Header().Get(h); v != "" {
+						res.Header[h] = v
+					}
+				}
+				if err := m.store.Complete(ctx, scope, res); err != nil {
+					log.Printf("idempotency: %v", err)
+				}
+				return
+			}
+			if err := m.store.Release(ctx, scope); err != nil {
+				log.Printf("idempotency: %v", err)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// claim takes the key, waiting (up to m.wait) while another request with the same hash runs.
+func (m *Middleware) claim(ctx context.Context, scope Scope, hash string) (*Entry, error) {
+	deadline := time.Now().Add(m.wait)
+	for {
+		entry, err := m.store.Claim(ctx, scope, hash, m.ttl, lockTimeout)
+		if err != nil || entry == nil || entry.Response != nil || entry.Hash != hash || !time.Now().Before(deadline) {
+			return entry, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// requestHash covers what makes two requests "the same": method, path, query and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s?%s\n", r.Method, r.URL.Path, r.URL.RawQuery)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func isSelect(path string) bool {
+	return strings.HasPrefix(path, "/v1/crud/") && strings.HasSuffix(strings.TrimRight(path, "/"), "/select")
+}
+
+func printable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func replay(w http.ResponseWriter, res Response) {
+	for k, v := range res.Header {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(res.Status)
+	_, _ = w.Write(res.Body)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, message, detail, code string) {
+	errs := map[string]string{"idempotency_key": detail}
+	if code != "" {
+		errs["code"] = code
+	}
+	if rid := shared.RequestIDFromContext(r.Context()); rid != "" {
+		errs["request_id"] = rid
+	}
+	shared.WriteError(w, status, message, errs)
+}
+
+// recorder passes the response through and keeps a copy (up to MaxResponseBytes).
+type recorder struct {
+	w        http.ResponseWriter
+	status   int
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (rec *recorder) Header() http.Header { return rec.w.Header() }
+
+func (rec *recorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.w.WriteHeader(code)
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if !rec.overflow {
+		if rec.buf.Len()+len(p) > MaxResponseBytes {
+			rec.overflow = true
+			rec.buf.Reset()
+		} else {
+			rec.buf.Write(p)
+		}
+	}
+	return rec.w.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (flush, write deadlines).
+func (rec *recorder) Unwrap() http.ResponseWriter { return rec.w }
@@ -0,0 +1,103 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mylab-api-go/internal/routes/auth"
+)
+
+func post(h http.Handler, key, body string, user int64) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/v1/crud/pasien", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(HeaderKey, key)
+	}
+	r = r.WithContext(auth.WithAuthInfoInContext(r.Context(), auth.AuthInfo{CompanyID: 7, UserID: user}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestWrapReplaysAndRejectsReuse(t *testing.T) {
+	var calls int32
+	h := New(NewMemoryStore(), time.Hour, 0).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok":true,"pk":` + string(rune('0'+n)) + `}`))
+	}))
+
+	first := post(h, "k1", `{"nama_ps":"Budi"}`, 1)
+	again := post(h, "k1", `{"nama_ps":"Budi"}`, 1)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if again.Code != http.StatusOK || again.Body.String() != first.Body.String() || again.Header().Get(HeaderReplayed) != "true" || again.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("replay = %d %q %v", again.Code, again.Body.String(), again.Header())
+	}
+
+	if w := post(h, "k1", `{"nama_ps":"Sari"}`, 1); w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "idempotency_key_reused") {
+		t.Fatalf("reuse = %d %s", w.Code, w.Body.String())
+	}
+	// Keys are per user; no key means no idempotency.
+	post(h, "k1", `{"nama_ps":"Budi"}`, 2)
+	post(h, "", `{"nama_ps":"Budi"}`, 1)
+	post(h, "", `{"nama_ps":"Budi"}`, 1)
+	if calls != 4 {
+		t.Fatalf("handler ran %d times, want 4", calls)
+	}
+}
+
+func TestWrapReleasesServerErrors(t *testing.T) {
+	status := http.StatusInternalServerError
+	h := New(NewMemoryStore(), time.Hour, 0).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	if w := post(h, "k", `{}`, 1); w.Code != http.StatusInternalServerError {
+		t.Fatalf("code = %d", w.Code)
+	}
+	status = http.StatusOK
+	if w := post(h, "k", `{}`, 1); w.Code != http.StatusOK || w.Header().Get(HeaderReplayed) != "" {
+		t.Fatalf("retry after 500 must run again: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestWrapInProgress(t *testing.T) {
+	store := NewMemoryStore()
+	h := New(store, time.Hour, 0).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// A first request holding the key.
+	if e, _ := store.Claim(context.Background(), Scope{CompanyID: 7, UserID: 1, Key: "k"}, requestHash(httptest.NewRequest(http.MethodPost, "/v1/crud/pasien", nil), []byte(`{}`)), time.Hour, time.Minute); e != nil {
+		t.Fatal("key already taken")
+	}
+	if w := post(h, "k", `{}`, 1); w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("in progress = %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 16, 8, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.Now = func() time.Time { return now }
+	sc := Scope{CompanyID: 1, UserID: 1, Key: "k"}
+
+	if e, _ := s.Claim(context.Background(), sc, "h1", time.Hour, time.Minute); e != nil {
+		t.Fatal("new key must be claimed")
+	}
+	now = now.Add(2 * time.Minute) // lock ran out, no response: abandoned
+	if e, _ := s.Claim(context.Background(), sc, "h1", time.Hour, time.Minute); e != nil {
+		t.Fatal("abandoned key must be taken over")
+	}
+	_ = s.Complete(context.Background(), sc, Response{Status: 200})
+	if e, _ := s.Claim(context.Background(), sc, "h2", time.Hour, time.Minute); e == nil || e.Hash != "h1" || e.Response == nil {
+		t.Fatalf("stored entry = %#v", e)
+	}
+	now = now.Add(2 * time.Hour)
+	if e, _ := s.Claim(context.Background(), sc, "h2", time.Hour, time.Minute); e != nil {
+		t.Fatal("expired key must be claimed again")
+	}
+}
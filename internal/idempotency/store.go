@@ -0,0 +1,238 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Table is the gateway-owned key table (Postgres store, created on first use).
+const Table = "gateway_idempotency_keys"
+
+// Scope identifies one key: the same key sent by another tenant or user is a different key.
+type Scope struct {
+	CompanyID int64
+	UserID    int64
+	Key       string
+}
+
+// Response is a stored response, replayed for retries of the same request.
+type Response struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+}
+
+// Entry is the state of a key that is already in use. Response is nil while the first
+// request is still running.
+type Entry struct {
+	Hash     string
+	Response *Response
+}
+
+// Store keeps keys with their request hash and response.
+//
+// Claim takes the key for a request with the given hash: it returns nil when the caller owns
+// the key (new, expired, or abandoned by a request whose lock ran out), otherwise the entry
+// already stored. Complete stores the response of the owner; Release gives the key up so a
+// retry runs again (server errors, responses too large to keep).
+type Store interface {
+	Claim(ctx context.Context, s Scope, hash string, ttl, lock time.Duration) (*Entry, error)
+	Complete(ctx context.Context, s Scope, res Response) error
+	Release(ctx context.Context, s Scope) error
+}
+
+// sweepInterval is how often expired keys are deleted (on the next Claim).
+const sweepInterval = time.Minute
+
+// MemoryStore keeps keys in process memory (single instance, lost on restart).
+type MemoryStore struct {
+	Now func() time.Time
+
+	mu        sync.Mutex
+	m         map[Scope]*memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	hash        string
+	res         *Response
+	expiresAt   time.Time
+	lockedUntil time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{Now: time.Now, m: map[Scope]*memoryEntry{}}
+}
+
+func (s *MemoryStore) Claim(_ context.Context, sc Scope, hash string, ttl, lock time.Duration) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for k, e := range s.m {
+			if now.After(e.expiresAt) {
+				delete(s.m, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	if e, ok := s.m[sc]; ok && now.Before(e.expiresAt) && (e.res != nil || now.Before(e.lockedUntil)) {
+		return &Entry{Hash: e.hash, Response: e.res}, nil
+	}
+	s.m[sc] = &memoryEntry{hash: hash, expiresAt: now.Add(ttl), lockedUntil: now.Add(lock)}
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, sc Scope, res Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.m[sc]; ok {
+		e.res = &res
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, sc Scope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.m[sc]; ok && e.res == nil {
+		delete(s.m, sc)
+	}
+	return nil
+}
+
+// PostgresStore keeps keys in Table, shared by every gateway instance.
+type PostgresStore struct {
+	db  *sql.DB
+	Now func() time.Time
+
+	mu        sync.Mutex
+	ready     bool
+	lastSweep time.Time
+}
+
+func NewPostgresStore(db *sql.DB) (*PostgresStore, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	return &PostgresStore{db: db, Now: time.Now}, nil
+}
+
+// ensureTable creates the key table once per process (same approach as the audit recorder).
+// It also deletes expired keys, at most once per sweepInterval.
+func (s *PostgresStore) ensureTable(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ready {
+		stmt := fmt.Sprintf(`
+create table if not exists %s (
+  company_id bigint not null,
+  user_id bigint not null,
+  idem_key text not null,
+  request_hash text not null,
+  status int null,
+  headers text null,
+  body bytea null,
+  created_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  locked_until timestamptz not null,
+  primary key (company_id, user_id, idem_key)
+)
+`, Table)
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+		s.ready = true
+	}
+	if now.Sub(s.lastSweep) > sweepInterval {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`delete from %s where expires_at < $1`, Table), now); err != nil {
+			return err
+		}
+		s.lastSweep = now
+	}
+	return nil
+}
+
+func (s *PostgresStore) Claim(ctx context.Context, sc Scope, hash string, ttl, lock time.Duration) (*Entry, error) {
+	now := s.Now()
+	if err := s.ensureTable(ctx, now); err != nil {
+		return nil, err
+	}
+	// One statement: insert a new key, or take over an expired/abandoned one. The conflict
+	// row lock makes concurrent claims of one key wait for each other.
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+insert into %[1]s (company_id, user_id, idem_key, request_hash, expires_at, locked_until)
+values ($1,$2,$3,$4,$5,$6)
+on conflict (company_id, user_id, idem_key) do update
+set request_hash = excluded.request_hash, status = null, headers = null, body = null,
+    created_at = now(), expires_at = excluded.expires_at, locked_until = excluded.locked_until
+where %[1]s.expires_at < $7 or (%[1]s.status is null and %[1]s.locked_until < $7)
+returning 1
+`, Table), sc.CompanyID, sc.UserID, sc.Key, hash, now.Add(ttl), now.Add(lock), now)
+	if err != nil {
+		return nil, err
+	}
+	claimed := rows.Next()
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	var (
+		e       Entry
+		status  sql.NullInt64
+		headers sql.NullString
+		body    []byte
+	)
+	err = s.db.QueryRowContext(ctx, fmt.Sprintf(`
+select request_hash, status, headers, body from %s
+where company_id = $1 and user_id = $2 and idem_key = $3
+`, Table), sc.CompanyID, sc.UserID, sc.Key).Scan(&e.Hash, &status, &headers, &body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released between the two statements: try again.
+		return s.Claim(ctx, sc, hash, ttl, lock)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status.Valid {
+		res := &Response{Status: int(status.Int64), Body: body}
+		if headers.Valid && headers.String != "" {
+			if err := json.Unmarshal([]byte(headers.String), &res.Header); err != nil {
+				return nil, fmt.Errorf("idempotency: stored headers: %w", err)
+			}
+		}
+		e.Response = res
+	}
+	return &e, nil
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, sc Scope, res Response) error {
+	headers, err := json.Marshal(res.Header)
+	if err != nil {
+		return err
+	}
+	body := res.Body
+	if body == nil {
+		body = []byte{}
+	}
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`
+update %s set status = $4, headers = $5, body = $6
+where company_id = $1 and user_id = $2 and idem_key = $3
+`, Table), sc.CompanyID, sc.UserID, sc.Key, res.Status, string(headers), body)
+	return err
+}
+
+func (s *PostgresStore) Release(ctx context.Context, sc Scope) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+delete from %s where company_id = $1 and user_id = $2 and idem_key = $3 and status is null
+`, Table), sc.CompanyID, sc.UserID, sc.Key)
+	return err
+}
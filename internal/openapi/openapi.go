@@ -0,0 +1,433 @@
+// Package openapi builds the runtime OpenAPI document: the static contract
+// (Docs/openapi/openapi.yaml) plus concrete /v1/crud/<table> paths and component schemas
+// generated from each table's schema, so client generators get typed models per deployment.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/yaml"
+)
+
+// DefaultFile is the static contract merged into the generated document (OPENAPI_FILE overrides).
+const DefaultFile = "Docs/openapi/openapi.yaml"
+
+// genericPaths are the table-agnostic CRUD paths replaced by the per-table ones.
+var genericPaths = []string{"/v1/crud/{table}", "/v1/crud/{table}/{pk}", "/v1/crud/{table}/select"}
+
+// Table is a CRUD table to document, with its schema as loaded by schema.LoadSchema.
+type Table struct {
+	Name   string
+	Schema eloquent.Schema
+}
+
+// LoadBase reads the static contract; .json files are JSON, anything else YAML.
+func LoadBase(path string) (map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var doc map[string]any
+		dec := json.NewDecoder(strings.NewReader(string(b)))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return doc, nil
+	}
+	doc, err := yaml.Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// Skeleton is the document used when the static contract cannot be loaded.
+func Skeleton() map[string]any {
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info":    map[string]any{"title": "MyLab API (Go)", "version": "0.1.0"},
+		"security": []any{
+			map[string]any{"BearerAuth": []any{}},
+		},
+		"paths": map[string]any{},
+		"components": map[string]any{
+			"securitySchemes": map[string]any{
+				"BearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+// Build returns a copy of base with the generic CRUD paths replaced by one set of paths per
+// table, and a <Table>, <Table>Create and <Table>Update component schema per table.
+func Build(base map[string]any, tables []Table) map[string]any {
+	doc := deepCopy(base).(map[string]any)
+	paths := childMap(doc, "paths")
+	components := childMap(doc, "components")
+	schemas := childMap(components, "schemas")
+	for _, p := range genericPaths {
+		delete(paths, p)
+	}
+
+	names := map[string]string{}
+	for _, t := range tables {
+		names[t.Name] = componentName(t.Name, schemas)
+	}
+	g := generator{schemas: schemas, names: names}
+	for _, t := range tables {
+		g.addTable(paths, t)
+	}
+	return doc
+}
+
+type generator struct {
+	schemas map[string]any
+	names   map[string]string // table -> component name
+}
+
+func (g generator) addTable(paths map[string]any, t Table) {
+	s := t.Schema
+	name := g.names[t.Name]
+	g.schemas[name] = g.rowSchema(s)
+	g.schemas[name+"Create"] = writeSchema(s, true)
+	g.schemas[name+"Update"] = writeSchema(s, false)
+
+	tags := []any{t.Name}
+	desc := fmt.Sprintf("Tenant-scoped by %s.", s.TenantColumnName())
+	if s.Global {
+		desc = "Global table: readable by every tenant; writes require a super-admin role."
+	}
+	rowRef := ref(name)
+	pkParam := map[string]any{"in": "path", "name": "pk", "required": true, "schema": columnSchema(s, s.PrimaryKey, false)}
+	withParam := map[string]any{
+		"in": "query", "name": "with", "required": false,
+		"description": "Comma-separated relation names to eager-load.",
+		"schema":      map[string]any{"type": "string"},
+	}
+	idempotencyParam := map[string]any{
+		"in": "header", "name": "Idempotency-Key", "required": false,
+		"description": "Client key making retries safe: a retry returns the first response instead of creating again.",
+		"schema":      map[string]any{"type": "string", "maxLength": 255},
+	}
+	getParams := []any{withParam}
+	if s.Versioned {
+		getParams = append(getParams, map[string]any{
+			"in": "query", "name": "as_of", "required": false,
+			"description": "Return the row as it was at this time (RFC3339 or YYYY-MM-DD). See /v1/crud/{table}/{pk}/history.",
+			"schema":      map[string]any{"type": "string"},
+		})
+	}
+	writeResp := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"ok":      map[string]any{"type": "boolean"},
+			"message": map[string]any{"type": "string"},
+			"table":   map[string]any{"type": "string", "const": t.Name},
+			"pk":      columnSchema(s, s.PrimaryKey, false),
+		},
+	}
+
+	getResp := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"ok":      map[string]any{"type": "boolean"},
+			"message": map[string]any{"type": "string"},
+			"data":    rowRef,
+		},
+	}
+	selectResp := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"ok":      map[string]any{"type": "boolean"},
+			"message": map[string]any{"type": "string"},
+			"data":    map[string]any{"type": "array", "items": rowRef},
+			"paging":  g.componentOr("GenericCRUDSelectPaging"),
+		},
+	}
+
+	create := g.operation("create"+name, "Create "+t.Name, desc, tags, []any{idempotencyParam}, ref(name+"Create"), "Created", writeResp)
+	get := g.operation("get"+name, "Get "+t.Name+" by primary key", desc, tags, getParams, nil, "OK", getResp)
+	update := g.operation("update"+name, "Update "+t.Name, desc, tags, nil, ref(name+"Update"), "Updated", writeResp)
+	patch := g.operation("patch"+name, "Partially update "+t.Name, desc, tags, nil, ref(name+"Update"), "Updated", writeResp)
+	del := g.operation("delete"+name, "Delete "+t.Name, desc, tags, nil, nil, "Deleted", writeResp)
+	sel := g.operation("select"+name, "Select "+t.Name, desc, tags, nil, g.componentOr("GenericCRUDSelectRequest"), "OK", selectResp)
+
+	for _, op := range []map[string]any{get, update, patch, del} {
+		op["responses"].(map[string]any)["404"] = jsonResponse("Not found", g.componentOr("ServiceNotFoundError"))
+	}
+	if s.Global {
+		for _, op := range []map[string]any{create, update, patch, del} {
+			op["responses"].(map[string]any)["403"] = jsonResponse("Super-admin role required", g.componentOr("ServiceError"))
+		}
+	}
+
+	paths["/v1/crud/"+t.Name] = map[string]any{"post": create}
+	paths["/v1/crud/"+t.Name+"/{pk}"] = map[string]any{"parameters": []any{pkParam}, "get": get, "put": update, "patch": patch, "delete": del}
+	paths["/v1/crud/"+t.Name+"/select"] = map[string]any{"post": sel}
+}
+
+func (g generator) operation(id, summary, desc string, tags, params []any, body map[string]any, okDesc string, okSchema map[string]any) map[string]any {
+	responses := map[string]any{
+		"200": jsonResponse(okDesc, okSchema),
+		"401": jsonResponse("Unauthorized", g.componentOr("ServiceUnauthorizedError")),
+		"422": jsonResponse("Validation error", g.componentOr("ServiceValidationError")),
+	}
+	op := map[string]any{
+		"operationId": id,
+		"summary":     summary,
+		"description": desc,
+		"tags":        tags,
+		"responses":   responses,
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if body != nil {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": body}},
+		}
+	}
+	return op
+}
+
+// componentOr references a component of the static contract, or a free-form object when the
+// contract does not define it (skeleton document).
+func (g generator) componentOr(name string) map[string]any {
+	if _, ok := g.schemas[name]; ok {
+		return ref(name)
+	}
+	return map[string]any{"type": "object"}
+}
+
+// rowSchema describes a row in responses: visible columns, computed columns and eager-loaded
+// relations (only present when requested with `with`). Nothing is required because `fields`
+// may project a subset.
+func (g generator) rowSchema(s eloquent.Schema) map[string]any {
+	props := map[string]any{}
+	for _, c := range s.VisibleColumns() {
+		props[c] = columnSchema(s, c, false)
+	}
+	for name := range s.Computed {
+		cs := columnSchema(s, name, false)
+		cs["readOnly"] = true
+		props[name] = cs
+	}
+	for name, rel := range s.Relations {
+		var target map[string]any
+		if comp, ok := g.names[rel.Table]; ok {
+			target = ref(comp)
+		} else {
+			target = map[string]any{"type": "object"}
+		}
+		desc := fmt.Sprintf("%s %s (only with `with=%s`).", rel.Type, rel.Table, name)
+		if rel.Type == eloquent.HasMany {
+			props[name] = map[string]any{"type": "array", "items": target, "description": desc}
+		} else {
+			props[name] = map[string]any{"anyOf": []any{target, map[string]any{"type": "null"}}, "description": desc}
+		}
+	}
+	return map[string]any{"type": "object", "properties": props}
+}
+
+// writeSchema describes a create (required fields enforced) or update payload. The tenant
+// column and managed timestamps are omitted because the server fills them.
+func writeSchema(s eloquent.Schema, create bool) map[string]any {
+	props := map[string]any{}
+	required := []any{}
+	tenantCol := s.TenantColumnName()
+	for _, c := range s.FillableColumns() {
+		if c == tenantCol || (s.Timestamps && (c == "created_at" || c == "updated_at")) {
+			continue
+		}
+		cs := columnSchema(s, c, true)
+		if s.IsHidden(c) {
+			cs["writeOnly"] = true
+		}
+		props[c] = cs
+		if create && s.RequiredOnCreate(c) {
+			required = append(required, c)
+		}
+	}
+	out := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// columnSchema maps a column's cast, DB constraints and rules to a JSON schema. Input schemas
+// accept the lenient forms the casts accept (e.g. decimals as string or number).
+func columnSchema(s eloquent.Schema, col string, input bool) map[string]any {
+	ct := s.Casts[col]
+	out := castSchema(ct, input)
+	info, hasInfo := s.ColumnInfo[col]
+	if hasInfo && info.MaxLength > 0 && out["type"] == "string" {
+		out["maxLength"] = info.MaxLength
+	}
+	if vals := ct.EnumValues(); len(vals) > 0 {
+		out["enum"] = stringsToAny(vals)
+	} else if hasInfo && len(info.EnumValues) > 0 {
+		out["enum"] = stringsToAny(info.EnumValues)
+	}
+
+	nullable := !hasInfo || info.Nullable
+	for _, r := range s.Rules[col] {
+		arg := ""
+		if len(r.Args) > 0 {
+			arg = r.Args[0]
+		}
+		n, numErr := strconv.ParseFloat(arg, 64)
+		switch r.Name {
+		case eloquent.RuleNullable:
+			nullable = true
+		case eloquent.RuleEmail:
+			out["format"] = "email"
+		case eloquent.RuleRegex:
+			out["pattern"] = strings.Join(r.Args, ",")
+		case eloquent.RuleIn:
+			out["enum"] = stringsToAny(r.Args)
+		case eloquent.RuleMax, eloquent.RuleMin:
+			if numErr != nil {
+				continue
+			}
+			key := "maximum"
+			switch {
+			case r.Name == eloquent.RuleMax && out["type"] == "string":
+				key = "maxLength"
+			case r.Name == eloquent.RuleMin && out["type"] == "string":
+				key = "minLength"
+			case r.Name == eloquent.RuleMin:
+				key = "minimum"
+			}
+			out[key] = json.Number(strconv.FormatFloat(n, 'f', -1, 64))
+		}
+	}
+	if nullable && col != s.PrimaryKey {
+		if t, ok := out["type"].(string); ok {
+			out["type"] = []any{t, "null"}
+		} else if types, ok := out["type"].([]any); ok {
+			out["type"] = append(types, "null")
+		}
+		if enum, ok := out["enum"].([]any); ok {
+			out["enum"] = append(enum, nil)
+		}
+	}
+	return out
+}
+
+func castSchema(ct eloquent.CastType, input bool) map[string]any {
+	switch ct.Kind() {
+	case eloquent.CastString:
+		return map[string]any{"type": "string"}
+	case eloquent.CastInt:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case eloquent.CastFloat:
+		return map[string]any{"type": "number"}
+	case eloquent.CastBool:
+		return map[string]any{"type": "boolean"}
+	case eloquent.CastDecimal:
+		if input {
+			return map[string]any{"type": []any{"string", "number"}, "format": "decimal"}
+		}
+		if eloquent.DecimalsAsNumbers() {
+			return map[string]any{"type": "number"}
+		}
+		return map[string]any{"type": "string", "format": "decimal"}
+	case eloquent.CastDateTime:
+		if input {
+			return map[string]any{"type": "string", "description": "RFC3339, YYYY-MM-DD HH:MM:SS or YYYY-MM-DD."}
+		}
+		return map[string]any{"type": "string", "format": "date-time"}
+	case eloquent.CastDate:
+		return map[string]any{"type": "string", "format": "date"}
+	case eloquent.CastTime:
+		return map[string]any{"type": "string", "format": "time"}
+	case eloquent.CastUUID:
+		return map[string]any{"type": "string", "format": "uuid"}
+	case eloquent.CastEnum:
+		return map[string]any{"type": "string"}
+	case eloquent.CastArray:
+		items := map[string]any{}
+		if p := ct.Param(); p != "" {
+			items = castSchema(eloquent.CastType(p), input)
+		}
+		return map[string]any{"type": "array", "items": items}
+	}
+	// json, or no cast: any JSON value.
+	return map[string]any{}
+}
+
+// componentName turns a table name into a PascalCase component name that does not collide with
+// the static contract's components ("pasien" -> "Pasien", "lab_test" -> "LabTest").
+func componentName(table string, existing map[string]any) string {
+	var b strings.Builder
+	for _, part := range strings.Split(table, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	name := b.String()
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "Table" + name
+	}
+	if _, taken := existing[name]; taken {
+		name += "Row"
+	}
+	return name
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonResponse(desc string, schema map[string]any) map[string]any {
+	return map[string]any{
+		"description": desc,
+		"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+func childMap(parent map[string]any, key string) map[string]any {
+	if m, ok := parent[key].(map[string]any); ok {
+		return m
+	}
+	m := map[string]any{}
+	parent[key] = m
+	return m
+}
+
+func stringsToAny(in []string) []any {
+	out := make([]any, len(in))
+	for i, s := range in {
+		out[i] = s
+	}
+	return out
+}
+
+func deepCopy(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			out[k] = deepCopy(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, val := range t {
+			out[i] = deepCopy(val)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,106 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"mylab-api-go/internal/database/eloquent"
+)
+
+func pasienSchema() eloquent.Schema {
+	return eloquent.Schema{
+		Table:      "pasien",
+		PrimaryKey: "kd_ps",
+		Columns:    []string{"kd_ps", "nama_ps", "jk", "pnc_total_point", "pin", "kd_dr", "company_id"},
+		Casts: map[string]eloquent.CastType{
+			"kd_ps": eloquent.CastString, "nama_ps": eloquent.CastString, "jk": "enum:L|P",
+			"pnc_total_point": eloquent.CastDecimal, "pin": eloquent.CastString, "kd_dr": eloquent.CastInt,
+			"company_id": eloquent.CastInt,
+		},
+		Hidden: []string{"pin"},
+		Rules:  map[string][]eloquent.Rule{"nama_ps": {{Name: eloquent.RuleRequired}, {Name: eloquent.RuleMax, Args: []string{"100"}}}},
+		ColumnInfo: map[string]eloquent.ColumnInfo{
+			"kd_ps":           {Nullable: false},
+			"nama_ps":         {Nullable: false, MaxLength: 120},
+			"jk":              {Nullable: true},
+			"pnc_total_point": {Nullable: true},
+			"pin":             {Nullable: true},
+			"kd_dr":           {Nullable: false},
+			"company_id":      {Nullable: false},
+		},
+		Relations: map[string]eloquent.Relation{
+			"dokter": {Name: "dokter", Type: eloquent.BelongsTo, LocalKey: "kd_dr", Table: "dokter", ForeignKey: "kd_dr"},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	base := Skeleton()
+	base["paths"].(map[string]any)["/v1/crud/{table}"] = map[string]any{}
+	dokter := eloquent.Schema{Table: "dokter", PrimaryKey: "kd_dr", Columns: []string{"kd_dr", "nama"}, Global: true}
+
+	doc := Build(base, []Table{{Name: "pasien", Schema: pasienSchema()}, {Name: "dokter", Schema: dokter}})
+	if _, ok := base["components"].(map[string]any)["schemas"]; ok {
+		t.Fatal("Build must not modify base")
+	}
+	paths := doc["paths"].(map[string]any)
+	if _, ok := paths["/v1/crud/{table}"]; ok {
+		t.Fatal("generic CRUD path should be replaced")
+	}
+	for _, p := range []string{"/v1/crud/pasien", "/v1/crud/pasien/{pk}", "/v1/crud/pasien/select", "/v1/crud/dokter"} {
+		if _, ok := paths[p]; !ok {
+			t.Fatalf("missing path %s", p)
+		}
+	}
+
+	// Round-trip through JSON so assertions see what clients see.
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	schemas := got["components"].(map[string]any)["schemas"].(map[string]any)
+
+	row := schemas["Pasien"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := row["pin"]; ok {
+		t.Fatal("hidden column in row schema")
+	}
+	checks := map[string]any{
+		"kd_ps":           map[string]any{"type": "string"},
+		"jk":              map[string]any{"type": []any{"string", "null"}, "enum": []any{"L", "P", nil}},
+		"pnc_total_point": map[string]any{"type": []any{"string", "null"}, "format": "decimal"},
+		"dokter":          map[string]any{"anyOf": []any{map[string]any{"$ref": "#/components/schemas/Dokter"}, map[string]any{"type": "null"}}, "description": "belongsTo dokter (only with `with=dokter`)."},
+	}
+	for col, want := range checks {
+		if !reflect.DeepEqual(row[col], want) {
+			t.Errorf("Pasien.%s = %v, want %v", col, row[col], want)
+		}
+	}
+
+	create := schemas["PasienCreate"].(map[string]any)
+	props := create["properties"].(map[string]any)
+	if _, ok := props["company_id"]; ok {
+		t.Fatal("tenant column must not be writable")
+	}
+	if props["pin"].(map[string]any)["writeOnly"] != true {
+		t.Fatal("hidden fillable column should be writeOnly")
+	}
+	if want := map[string]any{"type": "string", "maxLength": 100.0}; !reflect.DeepEqual(props["nama_ps"], want) {
+		t.Fatalf("nama_ps = %v", props["nama_ps"])
+	}
+	if !reflect.DeepEqual(create["required"], []any{"nama_ps", "kd_dr"}) {
+		t.Fatalf("required = %v", create["required"])
+	}
+	if _, ok := schemas["PasienUpdate"].(map[string]any)["required"]; ok {
+		t.Fatal("update schema must not require fields")
+	}
+
+	dokterPost := got["paths"].(map[string]any)["/v1/crud/dokter"].(map[string]any)["post"].(map[string]any)
+	if _, ok := dokterPost["responses"].(map[string]any)["403"]; !ok {
+		t.Fatal("global table writes should document 403")
+	}
+}
@@ -5,17 +5,21 @@ import (
 	"fmt"
 	"strings"
 
+	"mylab-api-go/internal/database/dialect"
 	"mylab-api-go/internal/database/eloquent"
 )
 
 type BuiltQuery struct {
 	SQL  string
 	Args []any
+	// Sources maps result column names to the table they come from (first wins on clashes);
+	// used for export headers. Only filled by BuildSQLWithIntrospection.
+	Sources map[string]string
 }
 
 // BuildSQL validates QuerySpec using the provided Registry and builds a parameterized SQL query.
-// It enforces tenant filtering by injecting `alias.company_id = companyID` for all tables that
-// contain a `company_id` column.
+// It enforces tenant filtering by injecting `alias.<tenant column> = companyID` for every table
+// with a tenant column (see eloquent.Schema.TenantColumnName); global tables are not filtered.
 func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySpec) (*BuiltQuery, error) {
 	_ = ctx
 	if companyID <= 0 {
@@ -39,6 +43,7 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 
 	aliasToTable := map[string]string{baseAlias: spec.FromTable}
 	schemaByAlias := map[string]eloquent.Schema{baseAlias: baseSchema}
+	aliases := []string{baseAlias} // base first, then joins in request order
 
 	// Register joins
 	for i, j := range spec.Joins {
@@ -56,8 +61,13 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 		if !ok {
 			return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("joins[%d].table", i): "unknown"}}
 		}
+		// Joined tables are tenant-filtered like the base table.
+		if msg := tenantProblem(s); msg != "" {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("joins[%d].table", i): msg}}
+		}
 		aliasToTable[alias] = j.Table
 		schemaByAlias[alias] = s
+		aliases = append(aliases, alias)
 	}
 
 	// Helper to validate a ColumnRef against schemas
@@ -74,12 +84,29 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 		if !ok {
 			return ColumnRef{}, &eloquent.ValidationError{Errors: map[string]string{fieldKey: "unknown table alias"}}
 		}
-		if !schema.HasColumn(col) {
+		if !schema.HasColumn(col) && !schema.IsComputed(col) {
 			return ColumnRef{}, &eloquent.ValidationError{Errors: map[string]string{fieldKey: "unknown field"}}
 		}
 		return ColumnRef{Alias: alias, Column: col}, nil
 	}
 
+	// colExpr renders a validated column reference; computed columns expand to their expression.
+	colExpr := func(ref ColumnRef) string {
+		s := schemaByAlias[ref.Alias]
+		if s.IsComputed(ref.Column) {
+			if expr, err := eloquent.RenderComputedExpr(s.Computed[ref.Column], s.HasColumn, ref.Alias); err == nil {
+				return expr
+			}
+		}
+		return ref.String()
+	}
+	selectExpr := func(ref ColumnRef) string {
+		if schemaByAlias[ref.Alias].IsComputed(ref.Column) {
+			return colExpr(ref) + " AS " + ref.Column
+		}
+		return ref.String()
+	}
+
 	b := newSQLBuilder()
 
 	// SELECT
@@ -91,9 +118,42 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 			if verr != nil {
 				return nil, verr
 			}
-			cols = append(cols, ref.String())
+			if schemaByAlias[ref.Alias].IsHidden(ref.Column) {
+				return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("select[%d]", i): "hidden field"}}
+			}
+			cols = append(cols, selectExpr(ref))
 		}
 		selectSQL = strings.Join(cols, ",")
+	} else {
+		// Expand "*" when any table has hidden columns so they are never returned.
+		aliases := []string{baseAlias}
+		for _, j := range spec.Joins {
+			alias := strings.TrimSpace(j.Alias)
+			if alias == "" {
+				alias = j.Table
+			}
+			aliases = append(aliases, alias)
+		}
+		expand := false
+		for _, alias := range aliases {
+			if len(schemaByAlias[alias].Hidden) > 0 || len(schemaByAlias[alias].Computed) > 0 {
+				expand = true
+			}
+		}
+		if expand {
+			cols := []string{}
+			for _, alias := range aliases {
+				for _, c := range schemaByAlias[alias].VisibleColumns() {
+					cols = append(cols, ColumnRef{Alias: alias, Column: c}.String())
+				}
+				for _, name := range sortedComputedNames(schemaByAlias[alias].Computed) {
+					if schemaByAlias[alias].IsComputed(name) && !schemaByAlias[alias].IsHidden(name) {
+						cols = append(cols, selectExpr(ColumnRef{Alias: alias, Column: name}))
+					}
+				}
+			}
+			selectSQL = strings.Join(cols, ",")
+		}
 	}
 
 	fromSQL := fmt.Sprintf("%s AS %s", spec.FromTable, baseAlias)
@@ -116,21 +176,21 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 		if j.On.Op != "=" {
 			return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("joins[%d].on.op", i): "only '=' supported"}}
 		}
-		joinParts = append(joinParts, fmt.Sprintf("JOIN %s AS %s ON %s = %s", j.Table, alias, left.String(), right.String()))
+		joinParts = append(joinParts, fmt.Sprintf("JOIN %s AS %s ON %s = %s", j.Table, alias, colExpr(left), colExpr(right)))
 	}
 
 	// WHERE
 	whereParts := make([]string, 0, 8)
 
-	// Enforce tenant for all aliases that have company_id.
-	for alias, schema := range schemaByAlias {
-		if schema.HasColumn("company_id") {
-			whereParts = append(whereParts, fmt.Sprintf("%s.company_id = %s", alias, b.push(companyID)))
+	// Enforce tenant for all aliases that have a tenant column.
+	for _, alias := range aliases {
+		if col := schemaByAlias[alias].TenantColumnName(); col != "" {
+			whereParts = append(whereParts, fmt.Sprintf("%s.%s = %s", alias, col, b.push(companyID)))
 		}
 	}
-	// If base table does not support tenant enforcement, reject.
-	if !baseSchema.HasColumn("company_id") {
-		return nil, &eloquent.ValidationError{Errors: map[string]string{"company_id": "schema does not support tenant filter (company_id missing)"}}
+	// If base table does not support tenant enforcement (and is not global), reject.
+	if msg := tenantProblem(baseSchema); msg != "" {
+		return nil, &eloquent.ValidationError{Errors: map[string]string{"company_id": msg}}
 	}
 
 	for i, w := range spec.Where {
@@ -139,11 +199,15 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 			return nil, verr
 		}
 		op := strings.ToLower(strings.TrimSpace(w.Op))
+		// Hidden columns allow equality only; ranges and patterns leak the value piecewise.
+		if op != "=" && schemaByAlias[left.Alias].IsHidden(left.Column) {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("where[%d].field", i): "hidden field"}}
+		}
 		switch op {
 		case "=", "<=", ">=", "<", ">":
-			whereParts = append(whereParts, fmt.Sprintf("%s %s %s", left.String(), op, b.push(w.Value)))
+			whereParts = append(whereParts, fmt.Sprintf("%s %s %s", colExpr(left), op, b.push(w.Value)))
 		case "like":
-			whereParts = append(whereParts, fmt.Sprintf("%s ILIKE %s", left.String(), b.push(fmt.Sprintf("%%%v%%", w.Value))))
+			whereParts = append(whereParts, b.ilike(colExpr(left), fmt.Sprintf("%%%v%%", w.Value)))
 		default:
 			return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("where[%d].op", i): "unsupported operator"}}
 		}
@@ -158,6 +222,9 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 			if verr != nil {
 				return nil, verr
 			}
+			if schemaByAlias[field.Alias].IsHidden(field.Column) {
+				return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("order_by[%d].field", i): "hidden field"}}
+			}
 			dir := strings.ToUpper(strings.ToLower(strings.TrimSpace(ob.Dir)))
 			if dir == "" {
 				dir = "ASC"
@@ -165,7 +232,7 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 			if dir != "ASC" && dir != "DESC" {
 				return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("order_by[%d].dir", i): "must be asc or desc"}}
 			}
-			parts = append(parts, fmt.Sprintf("%s %s", field.String(), dir))
+			parts = append(parts, fmt.Sprintf("%s %s", colExpr(field), dir))
 		}
 		orderSQL = " ORDER BY " + strings.Join(parts, ",")
 	}
@@ -176,6 +243,11 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 		limitSQL = " LIMIT " + b.push(spec.Limit)
 	}
 
+	if len(whereParts) == 0 {
+		// Unfiltered read of a global table.
+		whereParts = append(whereParts, "TRUE")
+	}
+
 	sql := fmt.Sprintf(
 		"SELECT %s FROM %s %s WHERE %s%s%s",
 		selectSQL,
@@ -191,14 +263,30 @@ func BuildSQL(ctx context.Context, reg *Registry, companyID int64, spec *QuerySp
 
 // local sql builder to keep parameter numbering consistent
 type sqlBuilder struct {
+	d    dialect.Dialect
 	args []any
 }
 
 func newSQLBuilder() *sqlBuilder {
-	return &sqlBuilder{args: make([]any, 0, 16)}
+	return &sqlBuilder{d: dialect.Current(), args: make([]any, 0, 16)}
 }
 
 func (b *sqlBuilder) push(v any) string {
 	b.args = append(b.args, v)
-	return fmt.Sprintf("$%d", len(b.args))
+	return b.d.Placeholder(len(b.args))
+}
+
+func (b *sqlBuilder) ilike(col string, v any) string {
+	return b.d.ILike(col, b.push(v))
+}
+
+// tenantProblem explains why a non-global schema cannot be tenant-filtered ("" if it can).
+func tenantProblem(s eloquent.Schema) string {
+	if s.Global || s.TenantColumnName() != "" {
+		return ""
+	}
+	if s.TenantColumn != "" {
+		return fmt.Sprintf("tenant_column %s not found in %s", s.TenantColumn, s.Table)
+	}
+	return "schema does not support tenant filter (company_id missing)"
 }
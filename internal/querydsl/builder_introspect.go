@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/schema"
 )
 
 var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
@@ -22,7 +24,8 @@ func isSafeIdent(s string) bool {
 // Security:
 // - Only safe identifiers are allowed for table/alias/column.
 // - Only SELECT is generated.
-// - Tenant filtering is enforced via injected `alias.company_id = companyID`.
+// - Tenant filtering is enforced via injected `alias.<tenant column> = companyID` (see tenantColumnFor).
+// - Every table, joined ones included, needs a tenant column unless declared tenant=global.
 func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID int64, spec *QuerySpec, policy TablePolicy) (*BuiltQuery, error) {
 	if companyID <= 0 {
 		return nil, &eloquent.ValidationError{Errors: map[string]string{"company_id": "invalid"}}
@@ -50,6 +53,8 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 	}
 
 	aliasToTable := map[string]string{baseAlias: spec.FromTable}
+	aliases := []string{baseAlias}                    // base first, then joins in request order
+	aliasKey := map[string]string{baseAlias: "table"} // error key of each alias
 
 	// Register joins first (and validate table/alias identifiers early).
 	for i, j := range spec.Joins {
@@ -74,6 +79,8 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 			return nil, &eloquent.ValidationError{Errors: map[string]string{"join": "duplicate alias"}}
 		}
 		aliasToTable[alias] = table
+		aliases = append(aliases, alias)
+		aliasKey[alias] = fmt.Sprintf("joins[%d].table", i)
 	}
 
 	// Load columns for each referenced table.
@@ -86,8 +93,71 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 		columnsByAlias[alias] = cols
 	}
 
-	// Base table must support tenant enforcement.
-	if !columnsByAlias[baseAlias]["company_id"] {
+	// Schema-file directives: hidden columns can be filtered/sorted but never selected;
+	// computed columns behave like read-only real columns.
+	hiddenByAlias := map[string]map[string]bool{}
+	computedByAlias := map[string]map[string]string{}
+	tenantByAlias := map[string]string{}
+	globalByAlias := map[string]bool{}
+	for _, alias := range aliases {
+		table := aliasToTable[alias]
+		d, _, err := schema.LoadDirectives(table)
+		if err != nil {
+			return nil, err
+		}
+		tenantCol, ok := tenantColumnFor(columnsByAlias[alias], d)
+		if !ok {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{aliasKey[alias]: fmt.Sprintf("tenant_column %s not found in %s", d.TenantColumn, table)}}
+		}
+		// Joined tables are tenant-filtered too; one without a tenant column would expose
+		// every tenant's rows through the join.
+		if tenantCol == "" && !d.Global && alias != baseAlias {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{aliasKey[alias]: "table does not support tenant filter (company_id missing)"}}
+		}
+		tenantByAlias[alias] = tenantCol
+		globalByAlias[alias] = d.Global
+		if len(d.Hidden) > 0 {
+			set := map[string]bool{}
+			for _, h := range d.Hidden {
+				set[strings.ToLower(strings.TrimSpace(h))] = true
+			}
+			hiddenByAlias[alias] = set
+		}
+		if len(d.Computed) > 0 {
+			cols := columnsByAlias[alias]
+			isCol := func(c string) bool { return cols[strings.ToLower(c)] }
+			exprs := map[string]string{}
+			for name, expr := range d.Computed {
+				key := strings.ToLower(strings.TrimSpace(name))
+				if cols[key] || !isSafeIdent(key) {
+					continue
+				}
+				rendered, err := eloquent.RenderComputedExpr(expr, isCol, alias)
+				if err != nil {
+					return nil, &eloquent.ValidationError{Errors: map[string]string{"computed." + name: "invalid expression: " + err.Error()}}
+				}
+				exprs[key] = rendered
+			}
+			computedByAlias[alias] = exprs
+		}
+	}
+
+	// colExpr returns the SQL for a validated column reference (real or computed).
+	colExpr := func(ref ColumnRef) string {
+		if expr, ok := computedByAlias[ref.Alias][strings.ToLower(ref.Column)]; ok {
+			return expr
+		}
+		return ref.String()
+	}
+	selectExpr := func(ref ColumnRef) string {
+		if expr, ok := computedByAlias[ref.Alias][strings.ToLower(ref.Column)]; ok {
+			return expr + " AS " + ref.Column
+		}
+		return ref.String()
+	}
+
+	// Base table must support tenant enforcement unless it is a global (shared) table.
+	if tenantByAlias[baseAlias] == "" && !globalByAlias[baseAlias] {
 		return nil, &eloquent.ValidationError{Errors: map[string]string{"company_id": "table does not support tenant filter (company_id missing)"}}
 	}
 
@@ -108,7 +178,9 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 			return ColumnRef{}, &eloquent.ValidationError{Errors: map[string]string{fieldKey: "unknown table alias"}}
 		}
 		if !cols[strings.ToLower(col)] {
-			return ColumnRef{}, &eloquent.ValidationError{Errors: map[string]string{fieldKey: "unknown field"}}
+			if _, computed := computedByAlias[alias][strings.ToLower(col)]; !computed {
+				return ColumnRef{}, &eloquent.ValidationError{Errors: map[string]string{fieldKey: "unknown field"}}
+			}
 		}
 		return ColumnRef{Alias: alias, Column: col}, nil
 	}
@@ -117,6 +189,12 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 
 	// SELECT
 	selectSQL := "*"
+	sources := map[string]string{}
+	addSource := func(col, alias string) {
+		if _, ok := sources[col]; !ok {
+			sources[col] = aliasToTable[alias]
+		}
+	}
 	if len(spec.Select) > 0 {
 		cols := make([]string, 0, len(spec.Select))
 		for i, raw := range spec.Select {
@@ -124,9 +202,43 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 			if verr != nil {
 				return nil, verr
 			}
-			cols = append(cols, ref.String())
+			if hiddenByAlias[ref.Alias][strings.ToLower(ref.Column)] {
+				return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("select[%d]", i): "hidden field"}}
+			}
+			cols = append(cols, selectExpr(ref))
+			addSource(ref.Column, ref.Alias)
 		}
 		selectSQL = strings.Join(cols, ",")
+	} else {
+		// Expand "*" so hidden columns are never returned and computed columns are included.
+		aliases := []string{baseAlias}
+		for _, j := range spec.Joins {
+			alias := strings.TrimSpace(j.Alias)
+			if alias == "" {
+				alias = strings.TrimSpace(j.Table)
+			}
+			aliases = append(aliases, alias)
+		}
+		cols := []string{}
+		for _, alias := range aliases {
+			for _, c := range sortedColumnNames(columnsByAlias[alias]) {
+				if hiddenByAlias[alias][c] {
+					continue
+				}
+				cols = append(cols, ColumnRef{Alias: alias, Column: c}.String())
+				addSource(c, alias)
+			}
+			for _, c := range sortedComputedNames(computedByAlias[alias]) {
+				if hiddenByAlias[alias][c] {
+					continue
+				}
+				cols = append(cols, selectExpr(ColumnRef{Alias: alias, Column: c}))
+				addSource(c, alias)
+			}
+		}
+		if len(hiddenByAlias) > 0 || len(computedByAlias) > 0 {
+			selectSQL = strings.Join(cols, ",")
+		}
 	}
 
 	fromSQL := fmt.Sprintf("%s AS %s", spec.FromTable, baseAlias)
@@ -149,16 +261,16 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 		if strings.TrimSpace(j.On.Op) != "=" {
 			return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("joins[%d].on.op", i): "only '=' supported"}}
 		}
-		joinParts = append(joinParts, fmt.Sprintf("JOIN %s AS %s ON %s = %s", j.Table, alias, left.String(), right.String()))
+		joinParts = append(joinParts, fmt.Sprintf("JOIN %s AS %s ON %s = %s", j.Table, alias, colExpr(left), colExpr(right)))
 	}
 
 	// WHERE
 	whereParts := make([]string, 0, 8)
 
-	// Enforce tenant for all aliases that have company_id.
-	for alias, cols := range columnsByAlias {
-		if cols["company_id"] {
-			whereParts = append(whereParts, fmt.Sprintf("%s.company_id = %s", alias, b.push(companyID)))
+	// Enforce tenant for all aliases that have a tenant column.
+	for _, alias := range aliases {
+		if col := tenantByAlias[alias]; col != "" {
+			whereParts = append(whereParts, fmt.Sprintf("%s.%s = %s", alias, col, b.push(companyID)))
 		}
 	}
 
@@ -168,11 +280,15 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 			return nil, verr
 		}
 		op := strings.ToLower(strings.TrimSpace(w.Op))
+		// Hidden columns allow equality only; ranges and patterns leak the value piecewise.
+		if op != "=" && hiddenByAlias[left.Alias][strings.ToLower(left.Column)] {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("where[%d].field", i): "hidden field"}}
+		}
 		switch op {
 		case "=", "<=", ">=", "<", ">":
-			whereParts = append(whereParts, fmt.Sprintf("%s %s %s", left.String(), op, b.push(w.Value)))
+			whereParts = append(whereParts, fmt.Sprintf("%s %s %s", colExpr(left), op, b.push(w.Value)))
 		case "like":
-			whereParts = append(whereParts, fmt.Sprintf("%s ILIKE %s", left.String(), b.push(fmt.Sprintf("%%%v%%", w.Value))))
+			whereParts = append(whereParts, b.ilike(colExpr(left), fmt.Sprintf("%%%v%%", w.Value)))
 		default:
 			return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("where[%d].op", i): "unsupported operator"}}
 		}
@@ -187,6 +303,9 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 			if verr != nil {
 				return nil, verr
 			}
+			if hiddenByAlias[field.Alias][strings.ToLower(field.Column)] {
+				return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("order_by[%d].field", i): "hidden field"}}
+			}
 			dir := strings.ToUpper(strings.ToLower(strings.TrimSpace(ob.Dir)))
 			if dir == "" {
 				dir = "ASC"
@@ -194,7 +313,7 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 			if dir != "ASC" && dir != "DESC" {
 				return nil, &eloquent.ValidationError{Errors: map[string]string{fmt.Sprintf("order_by[%d].dir", i): "must be asc or desc"}}
 			}
-			parts = append(parts, fmt.Sprintf("%s %s", field.String(), dir))
+			parts = append(parts, fmt.Sprintf("%s %s", colExpr(field), dir))
 		}
 		orderSQL = " ORDER BY " + strings.Join(parts, ",")
 	}
@@ -206,7 +325,11 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 	}
 
 	if len(whereParts) == 0 {
-		return nil, &eloquent.ValidationError{Errors: map[string]string{"where": "tenant filter missing"}}
+		if !globalByAlias[baseAlias] {
+			return nil, &eloquent.ValidationError{Errors: map[string]string{"where": "tenant filter missing"}}
+		}
+		// Unfiltered read of a global table.
+		whereParts = append(whereParts, "TRUE")
 	}
 
 	sql := fmt.Sprintf(
@@ -219,5 +342,44 @@ func BuildSQLWithIntrospection(ctx context.Context, q columnQuerier, companyID i
 		limitSQL,
 	)
 
-	return &BuiltQuery{SQL: sql, Args: b.args}, nil
+	return &BuiltQuery{SQL: sql, Args: b.args, Sources: sources}, nil
+}
+
+// tenantColumnFor picks the tenant column of an introspected table: tenant_column= from the
+// schema file, else company_id, else com_id. Global tables have none. ok is false when the
+// schema file declares a tenant_column the table does not have.
+func tenantColumnFor(cols map[string]bool, d schema.Directives) (string, bool) {
+	if d.Global {
+		return "", true
+	}
+	if d.TenantColumn != "" {
+		if col := strings.ToLower(d.TenantColumn); cols[col] && isSafeIdent(col) {
+			return col, true
+		}
+		return "", false
+	}
+	for _, col := range []string{"company_id", "com_id"} {
+		if cols[col] {
+			return col, true
+		}
+	}
+	return "", true
+}
+
+func sortedColumnNames(cols map[string]bool) []string {
+	out := make([]string, 0, len(cols))
+	for c := range cols {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sortedComputedNames(exprs map[string]string) []string {
+	out := make([]string, 0, len(exprs))
+	for c := range exprs {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
 }
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"mylab-api-go/internal/database/dialect"
 )
 
 type columnQuerier interface {
@@ -33,9 +35,13 @@ func loadTableColumns(ctx context.Context, q columnQuerier, table string) (map[s
 		}
 	}
 
-	// Best-effort portable enough for Postgres/MySQL; we already use $n placeholders project-wide.
+	d := dialect.Current()
+	schemaFilter := "table_schema NOT IN ('pg_catalog','information_schema')"
+	if d.Name() == dialect.MySQLName {
+		schemaFilter = "table_schema = DATABASE()"
+	}
 	rows, err := q.QueryContext(ctx,
-		"SELECT column_name FROM information_schema.columns WHERE table_name = $1 AND table_schema NOT IN ('pg_catalog','information_schema')",
+		"SELECT column_name FROM information_schema.columns WHERE table_name = "+d.Placeholder(1)+" AND "+schemaFilter,
 		table,
 	)
 	if err != nil {
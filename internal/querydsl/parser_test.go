@@ -2,10 +2,12 @@ package querydsl
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
 	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/schema"
 )
 
 func TestParseAndBuildSQL_TenantInjected(t *testing.T) {
@@ -38,3 +40,120 @@ func TestParseAndBuildSQL_TenantInjected(t *testing.T) {
 		t.Fatalf("expected args")
 	}
 }
+
+func TestBuildSQL_HiddenColumns(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("users", func() eloquent.Schema {
+		return eloquent.Schema{
+			Table:      "users",
+			PrimaryKey: "id",
+			Columns:    []string{"id", "email", "password", "company_id"},
+			Hidden:     []string{"password"},
+		}
+	})
+
+	spec, err := ParseLaravelQuery("table('users as u')->where('u.password','=','x')")
+	if err != nil {
+		t.Fatalf("ParseLaravelQuery err: %v", err)
+	}
+	built, err := BuildSQL(context.TODO(), reg, 7, spec)
+	if err != nil {
+		t.Fatalf("BuildSQL err: %v", err)
+	}
+	if strings.Contains(built.SQL, "u.password,") || strings.HasPrefix(built.SQL, "SELECT *") {
+		t.Fatalf("hidden column must not be selected, got: %s", built.SQL)
+	}
+	if !strings.Contains(built.SQL, "u.password = ") {
+		t.Fatalf("hidden column must stay filterable, got: %s", built.SQL)
+	}
+
+	spec, _ = ParseLaravelQuery("table('users as u')->select('u.password')")
+	if _, err := BuildSQL(context.TODO(), reg, 7, spec); err == nil {
+		t.Fatalf("expected error when selecting hidden column")
+	}
+
+	// Patterns, ranges and sorting would reveal the value piecewise.
+	for _, q := range []string{
+		"table('users as u')->where('u.password','like','a')",
+		"table('users as u')->where('u.password','>','a')",
+		"table('users as u')->orderBy('u.password','asc')",
+	} {
+		spec, err := ParseLaravelQuery(q)
+		if err != nil {
+			t.Fatalf("ParseLaravelQuery(%s) err: %v", q, err)
+		}
+		_, err = BuildSQL(context.TODO(), reg, 7, spec)
+		if ve, ok := err.(*eloquent.ValidationError); !ok || !strings.Contains(fmt.Sprint(ve.Errors), "hidden field") {
+			t.Fatalf("%s: err = %v", q, err)
+		}
+	}
+}
+
+func TestBuildSQL_JoinTenant(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("orders", func() eloquent.Schema {
+		return eloquent.Schema{Table: "orders", PrimaryKey: "no_lab", Columns: []string{"no_lab", "kd_ps", "company_id"}}
+	})
+	reg.Register("pasien", func() eloquent.Schema {
+		return eloquent.Schema{Table: "pasien", PrimaryKey: "kd_ps", Columns: []string{"kd_ps", "com_id"}}
+	})
+	reg.Register("provinsi", func() eloquent.Schema {
+		return eloquent.Schema{Table: "provinsi", PrimaryKey: "kd_ps", Columns: []string{"kd_ps", "nama"}, Global: true}
+	})
+	reg.Register("catatan", func() eloquent.Schema {
+		return eloquent.Schema{Table: "catatan", PrimaryKey: "kd_ps", Columns: []string{"kd_ps", "isi"}}
+	})
+	reg.Register("cabang", func() eloquent.Schema {
+		return eloquent.Schema{Table: "cabang", PrimaryKey: "kd_ps", Columns: []string{"kd_ps", "company_id"}, TenantColumn: "lab_id"}
+	})
+
+	join := func(table string) *QuerySpec {
+		return &QuerySpec{FromTable: "orders", FromAlias: "o", Joins: []JoinSpec{{Table: table, Alias: "j", On: JoinOn{
+			Left: ColumnRef{Alias: "o", Column: "kd_ps"}, Op: "=", Right: ColumnRef{Alias: "j", Column: "kd_ps"},
+		}}}}
+	}
+
+	built, err := BuildSQL(context.TODO(), reg, 7, join("pasien"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(built.SQL, "WHERE o.company_id = $1 AND j.com_id = $2") {
+		t.Fatalf("both tables must be tenant-filtered: %s", built.SQL)
+	}
+	if built, err = BuildSQL(context.TODO(), reg, 7, join("provinsi")); err != nil || !strings.Contains(built.SQL, "WHERE o.company_id = $1") || len(built.Args) != 1 {
+		t.Fatalf("global join is not filtered: %v %v", built, err)
+	}
+
+	for table, msg := range map[string]string{
+		"catatan": "schema does not support tenant filter (company_id missing)",
+		"cabang":  "tenant_column lab_id not found in cabang",
+	} {
+		_, err := BuildSQL(context.TODO(), reg, 7, join(table))
+		if ve, ok := err.(*eloquent.ValidationError); !ok || ve.Errors["joins[0].table"] != msg {
+			t.Fatalf("%s: err = %v", table, err)
+		}
+	}
+}
+
+func TestTenantColumnFor(t *testing.T) {
+	cols := map[string]bool{"id": true, "company_id": true, "com_id": true, "lab_id": true}
+	cases := []struct {
+		name string
+		cols map[string]bool
+		d    schema.Directives
+		want string
+		ok   bool
+	}{
+		{"company_id", cols, schema.Directives{}, "company_id", true},
+		{"legacy com_id", map[string]bool{"id": true, "com_id": true}, schema.Directives{}, "com_id", true},
+		{"declared", cols, schema.Directives{TenantColumn: "Lab_ID"}, "lab_id", true},
+		{"declared but missing", cols, schema.Directives{TenantColumn: "cabang_id"}, "", false},
+		{"global", cols, schema.Directives{Global: true, TenantColumn: "cabang_id"}, "", true},
+		{"none", map[string]bool{"id": true}, schema.Directives{}, "", true},
+	}
+	for _, tc := range cases {
+		if got, ok := tenantColumnFor(tc.cols, tc.d); got != tc.want || ok != tc.ok {
+			t.Errorf("%s: got %q %v, want %q %v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
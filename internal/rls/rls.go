@@ -0,0 +1,194 @@
+package rls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mylab-api-go/internal/database/dialect"
+	"mylab-api-go/internal/schema"
+)
+
+// PolicyName is the tenant isolation policy created on every tenant table.
+const PolicyName = "gateway_tenant_isolation"
+
+// ErrUnsupportedDialect is returned on databases without row-level security (MySQL).
+var ErrUnsupportedDialect = errors.New("row-level security requires PostgreSQL")
+
+var identRE = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Options controls which tables get a policy and how strict it is.
+type Options struct {
+	// Schema is the information_schema.table_schema to scan (default DB_SCHEMA, else public).
+	Schema string
+	// Strict hides every row when app.company_id is unset. Without it, connections outside a
+	// request transaction (login, webhook dispatcher, migrations) are not filtered.
+	Strict bool
+	// Exclude lists tables that never get a policy (gateway-owned tables, ...).
+	Exclude map[string]bool
+}
+
+// TenantTable is a table that gets a policy on Column (Type is its udt_name, used for the cast).
+type TenantTable struct {
+	Table  string
+	Column string
+	Type   string
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// TenantTables lists the base tables that are tenant-scoped the same way the gateway scopes them:
+// tenant_column= from the schema file, else company_id, else com_id. Global tables are skipped.
+func TenantTables(ctx context.Context, q querier, opts Options) ([]TenantTable, error) {
+	if !dialect.IsPostgres() {
+		return nil, ErrUnsupportedDialect
+	}
+	rows, err := q.QueryContext(ctx, `
+		select c.table_name, c.column_name, c.udt_name
+		from information_schema.columns c
+		join information_schema.tables t on t.table_schema = c.table_schema and t.table_name = c.table_name
+		where c.table_schema = $1 and t.table_type = 'BASE TABLE'
+		order by c.table_name, c.ordinal_position`, opts.schemaName())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols := map[string]map[string]string{}
+	for rows.Next() {
+		var table, col, typ string
+		if err := rows.Scan(&table, &col, &typ); err != nil {
+			return nil, err
+		}
+		if cols[table] == nil {
+			cols[table] = map[string]string{}
+		}
+		cols[table][col] = typ
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := []TenantTable{}
+	for table, types := range cols {
+		if opts.Exclude[table] {
+			continue
+		}
+		col, err := tenantColumn(table, types)
+		if err != nil {
+			return nil, err
+		}
+		if col == "" {
+			continue
+		}
+		out = append(out, TenantTable{Table: table, Column: col, Type: types[col]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Table < out[j].Table })
+	return out, nil
+}
+
+func tenantColumn(table string, types map[string]string) (string, error) {
+	d, _, err := schema.LoadDirectives(table)
+	if err != nil {
+		return "", err
+	}
+	if d.Global {
+		return "", nil
+	}
+	if d.TenantColumn != "" {
+		if _, ok := types[d.TenantColumn]; ok {
+			return d.TenantColumn, nil
+		}
+		return "", fmt.Errorf("rls: %s: tenant_column %q not found", table, d.TenantColumn)
+	}
+	for _, col := range []string{"company_id", "com_id"} {
+		if _, ok := types[col]; ok {
+			return col, nil
+		}
+	}
+	return "", nil
+}
+
+// EnableStatements renders idempotent DDL that enables and forces RLS with the tenant policy.
+// FORCE makes the policy apply to the table owner too (the gateway usually connects as the owner).
+func EnableStatements(tables []TenantTable, opts Options) ([]string, error) {
+	out := make([]string, 0, len(tables)*4)
+	for _, t := range tables {
+		name, err := opts.qualified(t.Table)
+		if err != nil {
+			return nil, err
+		}
+		if !identRE.MatchString(t.Column) || !identRE.MatchString(t.Type) {
+			return nil, fmt.Errorf("rls: unsupported tenant column %s.%s (%s)", t.Table, t.Column, t.Type)
+		}
+		setting := fmt.Sprintf("nullif(current_setting('app.company_id', true), '')::%s", t.Type)
+		cond := fmt.Sprintf(`%q = %s`, t.Column, setting)
+		if !opts.Strict {
+			cond = fmt.Sprintf("nullif(current_setting('app.company_id', true), '') is null or %s", cond)
+		}
+		out = append(out,
+			fmt.Sprintf("alter table %s enable row level security", name),
+			fmt.Sprintf("alter table %s force row level security", name),
+			fmt.Sprintf("drop policy if exists %s on %s", PolicyName, name),
+			fmt.Sprintf("create policy %s on %s using (%s) with check (%s)", PolicyName, name, cond, cond),
+		)
+	}
+	return out, nil
+}
+
+// DisableStatements renders DDL that removes the tenant policy and turns RLS off again.
+func DisableStatements(tables []TenantTable, opts Options) ([]string, error) {
+	out := make([]string, 0, len(tables)*3)
+	for _, t := range tables {
+		name, err := opts.qualified(t.Table)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out,
+			fmt.Sprintf("drop policy if exists %s on %s", PolicyName, name),
+			fmt.Sprintf("alter table %s no force row level security", name),
+			fmt.Sprintf("alter table %s disable row level security", name),
+		)
+	}
+	return out, nil
+}
+
+// Apply runs stmts in one transaction (all tables change, or none).
+func Apply(ctx context.Context, db *sql.DB, stmts []string) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (o Options) schemaName() string {
+	if s := strings.TrimSpace(o.Schema); s != "" {
+		return s
+	}
+	if s := strings.TrimSpace(os.Getenv("DB_SCHEMA")); s != "" {
+		return s
+	}
+	return dialect.Postgres.DefaultSchema()
+}
+
+func (o Options) qualified(table string) (string, error) {
+	s := o.schemaName()
+	if !identRE.MatchString(s) || !identRE.MatchString(table) {
+		return "", fmt.Errorf("rls: unsupported identifier %s.%s", s, table)
+	}
+	return fmt.Sprintf("%q.%q", s, table), nil
+}
@@ -0,0 +1,41 @@
+package rls
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnableStatements(t *testing.T) {
+	tables := []TenantTable{{Table: "pasien", Column: "company_id", Type: "int8"}}
+
+	stmts, err := EnableStatements(tables, Options{Schema: "public"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`alter table "public"."pasien" enable row level security`,
+		`alter table "public"."pasien" force row level security`,
+		`drop policy if exists gateway_tenant_isolation on "public"."pasien"`,
+	}
+	for i, w := range want {
+		if stmts[i] != w {
+			t.Fatalf("stmt %d:\n got %s\nwant %s", i, stmts[i], w)
+		}
+	}
+	lenient := "nullif(current_setting('app.company_id', true), '') is null or \"company_id\" = nullif(current_setting('app.company_id', true), '')::int8"
+	if !strings.Contains(stmts[3], "using ("+lenient+") with check ("+lenient+")") {
+		t.Fatalf("policy: %s", stmts[3])
+	}
+
+	strict, err := EnableStatements(tables, Options{Schema: "public", Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(strict[3], "is null") {
+		t.Fatalf("strict policy must not allow unscoped sessions: %s", strict[3])
+	}
+
+	if _, err := EnableStatements([]TenantTable{{Table: "x; drop", Column: "company_id", Type: "int8"}}, Options{Schema: "public"}); err == nil {
+		t.Fatal("expected identifier error")
+	}
+}
@@ -1,6 +1,10 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"os"
+	"strings"
+)
 
 type authInfoKeyType struct{}
 
@@ -25,3 +29,32 @@ func AuthInfoFromContext(ctx context.Context) (AuthInfo, bool) {
 	info, ok := val.(AuthInfo)
 	return info, ok
 }
+
+// IsAdmin reports whether the caller's role is a tenant admin role.
+// Admin roles come from ADMIN_ROLES (comma-separated, default "admin").
+func IsAdmin(info AuthInfo) bool {
+	return hasRole(info, "ADMIN_ROLES", "admin")
+}
+
+// IsSuperAdmin reports whether the caller may write global (shared) tables.
+// Super-admin roles come from SUPER_ADMIN_ROLES (comma-separated, default "superadmin").
+func IsSuperAdmin(info AuthInfo) bool {
+	return hasRole(info, "SUPER_ADMIN_ROLES", "superadmin")
+}
+
+func hasRole(info AuthInfo, envKey, fallback string) bool {
+	role := strings.ToLower(strings.TrimSpace(info.Role))
+	if role == "" {
+		return false
+	}
+	raw := strings.TrimSpace(os.Getenv(envKey))
+	if raw == "" {
+		raw = fallback
+	}
+	for _, part := range strings.Split(raw, ",") {
+		if strings.ToLower(strings.TrimSpace(part)) == role {
+			return true
+		}
+	}
+	return false
+}
@@ -11,14 +11,20 @@ import (
 	"strings"
 	"time"
 
+	auditcontroller "mylab-api-go/internal/controllers/audit"
 	authcontroller "mylab-api-go/internal/controllers/auth"
 	crudcontroller "mylab-api-go/internal/controllers/crud"
+	metacontroller "mylab-api-go/internal/controllers/meta"
+	openapicontroller "mylab-api-go/internal/controllers/openapi"
 	pluginscontroller "mylab-api-go/internal/controllers/plugins"
 	querycontroller "mylab-api-go/internal/controllers/query"
+	webhookscontroller "mylab-api-go/internal/controllers/webhooks"
+	"mylab-api-go/internal/idempotency"
 	"mylab-api-go/internal/observability"
 	"mylab-api-go/internal/routes/auth"
 	"mylab-api-go/internal/routes/serverdua"
 	"mylab-api-go/internal/routes/shared"
+	"mylab-api-go/internal/schema"
 )
 
 type Server struct {
@@ -33,7 +39,12 @@ func New(addr string, logLevelRaw string, sqlDB *sql.DB) *Server {
 	authCtrl := authcontroller.NewAuthController(sqlDB)
 	queryCtrl := querycontroller.NewQueryController(sqlDB)
 	crudCtrl := crudcontroller.NewTableCRUDController(sqlDB)
+	auditCtrl := auditcontroller.NewAuditController(sqlDB)
+	webhookCtrl := webhookscontroller.NewWebhookController(sqlDB)
+	metaCtrl := metacontroller.NewMetaController(sqlDB, crudCtrl.Allows)
+	openapiCtrl := openapicontroller.NewOpenAPIController(sqlDB, crudCtrl.Allows)
 	plgProxy := pluginscontroller.NewPluginProxyController()
+	idem := idempotency.FromEnv(sqlDB)
 
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -64,6 +75,28 @@ func New(addr string, logLevelRaw string, sqlDB *sql.DB) *Server {
 		shared.WriteJSON(w, status, report)
 	})
 
+	// Schema file load status: 503 if any file in SCHEMA_DIR fails to parse.
+	mux.HandleFunc("/healthz/schemas", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		files, err := schema.CheckDir()
+		if err != nil {
+			shared.WriteJSON(w, http.StatusServiceUnavailable, map[string]any{"ok": false, "dir": schema.SchemaDir(), "error": err.Error()})
+			return
+		}
+		ok := true
+		for _, f := range files {
+			ok = ok && f.OK
+		}
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusServiceUnavailable
+		}
+		shared.WriteJSON(w, status, map[string]any{"ok": ok, "dir": schema.SchemaDir(), "files": files})
+	})
+
 	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -98,8 +131,16 @@ func New(addr string, logLevelRaw string, sqlDB *sql.DB) *Server {
 	mux.HandleFunc("/v1/auth/login", authCtrl.HandleLogin)
 	mux.HandleFunc("/v1/auth/logout", authCtrl.HandleLogout)
 	mux.HandleFunc("/v1/query", queryCtrl.HandleQuery)
-	mux.Handle("/v1/crud/", shared.WithRateLimit(http.HandlerFunc(crudCtrl.Handle)))
-	mux.Handle("/v1/plugins/", plgProxy)
+	mux.Handle("/v1/crud/", shared.WithRateLimit(idem.Wrap(http.HandlerFunc(crudCtrl.Handle))))
+	mux.Handle("/v1/batch", shared.WithRateLimit(idem.Wrap(http.HandlerFunc(crudCtrl.HandleBatch))))
+	mux.HandleFunc("/v1/audit", auditCtrl.HandleList)
+	mux.HandleFunc("/v1/openapi.json", openapiCtrl.HandleSpec)
+	mux.HandleFunc("/v1/meta/", metaCtrl.Handle)
+	mux.HandleFunc("/v1/webhooks/", webhookCtrl.Handle)
+	mux.Handle("/v1/plugins/", idem.Wrap(plgProxy))
+
+	// Signed file downloads (public: the signature is the credential, see crud files.go).
+	mux.Handle("/files/", shared.WithRateLimit(http.HandlerFunc(crudCtrl.HandleDownload)))
 
 	// Register route tambahan dari serverdua.go
 	serverdua.RegisterRoutesDua(mux)
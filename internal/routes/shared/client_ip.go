@@ -0,0 +1,78 @@
+package shared
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ClientIP returns the client address recorded in the audit trail.
+//
+// X-Forwarded-For is only believed when the direct peer is a trusted proxy (TRUSTED_PROXIES:
+// comma-separated IPs or CIDRs). The header is then walked from the right, skipping trusted
+// hops, and the first untrusted address is the client: entries left of it were written by the
+// client and can be forged. Without trusted proxies this is the RemoteAddr host.
+func ClientIP(r *http.Request) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	trusted := trustedProxies()
+	if len(trusted) == 0 || !isTrustedProxy(trusted, peer) {
+		return peer
+	}
+	hops := []string{}
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// Garbage from an untrusted hop: stop at the last address we can vouch for.
+			return peer
+		}
+		if !isTrustedProxy(trusted, hop) {
+			return hop
+		}
+		peer = hop
+	}
+	return peer
+}
+
+func trustedProxies() []*net.IPNet {
+	out := []*net.IPNet{}
+	for _, raw := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "/") {
+			if ip := net.ParseIP(raw); ip != nil {
+				bits := 8 * len(ip.To16())
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 32
+				}
+				out = append(out, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			}
+			continue
+		}
+		if _, n, err := net.ParseCIDR(raw); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func isTrustedProxy(trusted []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
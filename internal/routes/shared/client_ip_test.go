@@ -0,0 +1,39 @@
+package shared
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	cases := []struct {
+		name    string
+		trusted string
+		remote  string
+		xff     []string
+		want    string
+	}{
+		{"no proxies ignores header", "", "203.0.113.9:5000", []string{"10.9.9.9"}, "203.0.113.9"},
+		{"untrusted peer ignores header", "10.0.0.0/8", "203.0.113.9:5000", []string{"1.2.3.4"}, "203.0.113.9"},
+		{"trusted peer", "10.0.0.0/8", "10.0.0.2:5000", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"forged left entry", "10.0.0.0/8", "10.0.0.2:5000", []string{"1.1.1.1, 198.51.100.7"}, "198.51.100.7"},
+		{"proxy chain", "10.0.0.0/8,192.0.2.1", "10.0.0.2:5000", []string{"198.51.100.7, 192.0.2.1", "10.0.0.5"}, "198.51.100.7"},
+		{"all hops trusted", "10.0.0.0/8", "10.0.0.2:5000", []string{"10.0.0.7"}, "10.0.0.7"},
+		{"garbage hop", "10.0.0.0/8", "10.0.0.2:5000", []string{"198.51.100.7, bogus"}, "10.0.0.2"},
+		{"no header", "10.0.0.0/8", "10.0.0.2:5000", nil, "10.0.0.2"},
+		{"ipv6 peer", "::1", "[::1]:5000", []string{"2001:db8::1"}, "2001:db8::1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tc.trusted)
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.remote
+			for _, h := range tc.xff {
+				r.Header.Add("X-Forwarded-For", h)
+			}
+			if got := ClientIP(r); got != tc.want {
+				t.Fatalf("got %q want %q", got, tc.want)
+			}
+		})
+	}
+}
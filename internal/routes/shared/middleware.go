@@ -45,10 +45,18 @@ func (s *statusCapturingResponseWriter) Write(p []byte) (int, error) {
 	return n, err
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer (flush, write deadlines).
+func (s *statusCapturingResponseWriter) Unwrap() http.ResponseWriter {
+	return s.w
+}
+
 func WithRecovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec) // deliberate abort of a streamed response; let net/http drop the connection
+				}
 				log.Printf(`{"ts":%q,"level":"error","msg":"panic recovered"}`, time.Now().UTC().Format(time.RFC3339Nano))
 				err := map[string]string{"code": "panic"}
 				rid := RequestIDFromContext(r.Context())
@@ -87,7 +95,7 @@ func WithCORS(next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
 			w.Header().Set("Vary", "Origin")
 			w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization,X-User-Id,X-Request-Id")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization,X-User-Id,X-Request-Id,Idempotency-Key")
 			w.Header().Set("Access-Control-Max-Age", "600")
 		}
 
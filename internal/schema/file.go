@@ -0,0 +1,537 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mylab-api-go/internal/database/eloquent"
+	"mylab-api-go/internal/yaml"
+)
+
+// Schema file formats, in lookup order. One table must use exactly one of them.
+var schemaFileExts = []string{".yaml", ".yml", ".json", ".txt"}
+
+// FileError reports an unusable schema file. Requests for the table fail with it instead of
+// falling back to introspection.
+type FileError struct {
+	Table    string
+	Path     string
+	Problems []string
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("schema file %s: %s", e.Path, strings.Join(e.Problems, "; "))
+}
+
+// FileStatus is the last load result of one schema file (see Statuses and /healthz/schemas).
+type FileStatus struct {
+	Table     string    `json:"table"`
+	Path      string    `json:"path"`
+	Format    string    `json:"format"`
+	OK        bool      `json:"ok"`
+	Problems  []string  `json:"problems,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+var (
+	statusMu sync.RWMutex
+	statuses = map[string]FileStatus{}
+)
+
+func recordStatus(st FileStatus) {
+	statusMu.Lock()
+	statuses[st.Table] = st
+	statusMu.Unlock()
+}
+
+// Statuses returns the last load result of every schema file seen so far, sorted by table.
+func Statuses() []FileStatus {
+	statusMu.RLock()
+	out := make([]FileStatus, 0, len(statuses))
+	for _, st := range statuses {
+		out = append(out, st)
+	}
+	statusMu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Table < out[j].Table })
+	return out
+}
+
+// SchemaDir returns SCHEMA_DIR ("" when schema files are disabled).
+func SchemaDir() string {
+	return strings.TrimSpace(os.Getenv("SCHEMA_DIR"))
+}
+
+// CheckDir parses every schema file in SCHEMA_DIR (no database access), records the results
+// in Statuses and returns them. Startup calls this so broken files are logged immediately.
+func CheckDir() ([]FileStatus, error) {
+	dir := SchemaDir()
+	if dir == "" {
+		return nil, nil
+	}
+	tables, err := schemaDirTables(dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]FileStatus, 0, len(tables))
+	for _, table := range tables {
+		_, _, _ = tryLoadSchemaFile(table)
+		statusMu.RLock()
+		st, ok := statuses[table]
+		statusMu.RUnlock()
+		if ok {
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
+
+// schemaDirTables lists the table names that have a schema file in dir.
+func schemaDirTables(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	out := []string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if !containsExt(ext) {
+			continue
+		}
+		table := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+		if !seen[table] {
+			seen[table] = true
+			out = append(out, table)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func containsExt(ext string) bool {
+	for _, e := range schemaFileExts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// tryLoadSchemaFile loads SCHEMA_DIR/<table>.<ext>. ok=false when there is no file; a file that
+// cannot be used is a *FileError. Every attempt updates Statuses.
+func tryLoadSchemaFile(table string) (fileSchemaDef, bool, error) {
+	dir := SchemaDir()
+	if dir == "" {
+		return fileSchemaDef{}, false, nil
+	}
+
+	found := []string{}
+	for _, ext := range schemaFileExts {
+		path := filepath.Join(dir, table+ext)
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+	if len(found) == 0 {
+		return fileSchemaDef{}, false, nil
+	}
+
+	path := found[0]
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	if format == "yml" {
+		format = "yaml"
+	}
+	fail := func(problems ...string) (fileSchemaDef, bool, error) {
+		return fileSchemaDef{}, false, fileFailed(table, path, format, problems)
+	}
+
+	if !tableNameRE.MatchString(table) {
+		return fail("file name is not a valid table name (allowed: a-z0-9_ only)")
+	}
+	if len(found) > 1 {
+		return fail("multiple schema files for one table: " + strings.Join(found, ", "))
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	var (
+		def      fileSchemaDef
+		problems []string
+	)
+	switch format {
+	case "txt":
+		def, problems = parseSchemaTXT(string(b))
+	case "json":
+		def, problems = parseSchemaJSON(table, b)
+	default:
+		def, problems = parseSchemaYAML(table, string(b))
+	}
+	if len(problems) > 0 {
+		return fail(problems...)
+	}
+	recordStatus(FileStatus{Table: table, Path: path, Format: format, OK: true, CheckedAt: time.Now().UTC()})
+	def.path, def.format = path, format
+	return def, true, nil
+}
+
+// fileFailed records a failed load of a schema file and returns its *FileError.
+func fileFailed(table, path, format string, problems []string) *FileError {
+	recordStatus(FileStatus{Table: table, Path: path, Format: format, Problems: problems, CheckedAt: time.Now().UTC()})
+	return &FileError{Table: table, Path: path, Problems: problems}
+}
+
+func parseSchemaJSON(table string, b []byte) (fileSchemaDef, []string) {
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return fileSchemaDef{}, []string{"invalid JSON: " + err.Error()}
+	}
+	return decodeSchemaDoc(table, doc)
+}
+
+func parseSchemaYAML(table, raw string) (fileSchemaDef, []string) {
+	doc, err := yaml.Parse(raw)
+	if err != nil {
+		return fileSchemaDef{}, []string{"invalid YAML: " + err.Error()}
+	}
+	return decodeSchemaDoc(table, doc)
+}
+
+// decodeSchemaDoc maps a parsed YAML/JSON document onto fileSchemaDef. The structure is:
+//
+//	table: pasien                  # optional; must match the file name
+//	primary_key: kd_ps
+//	timestamps: true
+//	versioned: true                # keep row snapshots (history, as_of, revert)
+//	tenant_column: company_id      # or: tenant: global
+//	columns: [kd_ps, nama_ps, jk, kd_dr, company_id]
+//	fillable: [nama_ps, jk, kd_dr]
+//	hidden: [password]
+//	guarded: [pnc_sync]
+//	aliases:
+//	  nama: nama_ps
+//	casts:
+//	  jk: enum:L|P
+//	rules:
+//	  nama_ps: required|max:100
+//	  jk: [nullable, "in:L,P"]
+//	computed:
+//	  usia: date_part('year', age(tgl_lahir))
+//	relations:
+//	  dokter:
+//	    type: belongsTo
+//	    local_key: kd_dr
+//	    table: dokter
+//	    foreign_key: kd_dr
+//	ui:
+//	  kd_dr:
+//	    label:
+//	      id: Dokter
+//	      en: Doctor
+//	    widget: lookup
+//	    lookup: dokter.kd_dr:nama_dr
+//	    order: 3
+//	generate:
+//	  kd_ps: "{company}-{yyyy}{mm}-{seq:6}"
+//	  no_lab:
+//	    pattern: "LAB{yy}{mm}{dd}{seq:4}"
+//	    sequence: no_lab
+//	files:
+//	  gambar:
+//	    types: [image/jpeg, image/png]
+//	    max_size: 2MB
+//
+// JSON files use the same keys. List fields also accept a comma-separated string; a relation may
+// be the txt shorthand "belongsTo:kd_dr->dokter.kd_dr". Unknown keys are problems.
+func decodeSchemaDoc(table string, doc map[string]any) (fileSchemaDef, []string) {
+	def := newFileSchemaDef()
+	problems := []string{}
+	bad := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	for _, key := range sortedDocKeys(doc) {
+		val := doc[key]
+		switch key {
+		case "table":
+			if s, ok := docString(val); !ok || !strings.EqualFold(s, table) {
+				bad("table: must match the file name (%s)", table)
+			}
+		case "primary_key", "pk":
+			if s, ok := docString(val); ok {
+				def.PrimaryKey = s
+			} else {
+				bad("%s: must be a string", key)
+			}
+		case "timestamps":
+			b, ok := docBool(val)
+			if !ok {
+				bad("timestamps: must be true or false")
+				continue
+			}
+			def.Timestamps = &b
+		case "versioned":
+			b, ok := docBool(val)
+			if !ok {
+				bad("versioned: must be true or false")
+				continue
+			}
+			def.Versioned = b
+		case "tenant_column":
+			if s, ok := docString(val); ok {
+				def.TenantColumn = s
+			} else {
+				bad("tenant_column: must be a string")
+			}
+		case "tenant":
+			if s, ok := docString(val); ok && strings.EqualFold(s, "global") {
+				def.Global = true
+			} else {
+				bad("tenant: only \"global\" is supported (use tenant_column for a custom column)")
+			}
+		case "columns", "fillable", "hidden", "guarded":
+			list, ok := docStringList(val)
+			if !ok {
+				bad("%s: must be a list of column names", key)
+				continue
+			}
+			switch key {
+			case "columns":
+				def.Columns = list
+			case "fillable":
+				def.Fillable = list
+			case "hidden":
+				def.Hidden = list
+			default:
+				def.Guarded = list
+			}
+		case "aliases", "casts", "computed":
+			m, ok := val.(map[string]any)
+			if !ok {
+				bad("%s: must be a mapping", key)
+				continue
+			}
+			for _, k := range sortedDocKeys(m) {
+				s, ok := docString(m[k])
+				if !ok || s == "" {
+					bad("%s.%s: must be a non-empty string", key, k)
+					continue
+				}
+				switch key {
+				case "aliases":
+					def.Aliases[k] = s
+				case "casts":
+					if err := addCast(def.Casts, k, s, true); err != "" {
+						bad("%s", err)
+					}
+				default:
+					def.Computed[k] = s
+				}
+			}
+		case "rules":
+			m, ok := val.(map[string]any)
+			if !ok {
+				bad("rules: must be a mapping of column to rules")
+				continue
+			}
+			for _, col := range sortedDocKeys(m) {
+				specs := []string{}
+				if s, ok := docString(m[col]); ok {
+					specs = append(specs, s)
+				} else if list, ok := m[col].([]any); ok {
+					for _, item := range list {
+						s, ok := docString(item)
+						if !ok {
+							bad("rules.%s: items must be strings", col)
+							continue
+						}
+						specs = append(specs, s)
+					}
+				} else {
+					bad("rules.%s: must be a string or a list of strings", col)
+					continue
+				}
+				for _, spec := range specs {
+					if err := addRules(def.Rules, col, spec, true); err != "" {
+						bad("%s", err)
+					}
+				}
+			}
+		case "relations":
+			m, ok := val.(map[string]any)
+			if !ok {
+				bad("relations: must be a mapping")
+				continue
+			}
+			for _, name := range sortedDocKeys(m) {
+				rel, err := decodeRelation(name, m[name])
+				if err != "" {
+					bad("%s", err)
+					continue
+				}
+				def.Relations[name] = rel
+			}
+		case "generate":
+			m, ok := val.(map[string]any)
+			if !ok {
+				bad("generate: must be a mapping of column to pattern")
+				continue
+			}
+			for _, col := range sortedDocKeys(m) {
+				pattern, sequence, err := decodeGenerator(col, m[col])
+				if err == "" {
+					err = addGenerator(def.Generators, col, pattern, sequence, true)
+				}
+				if err != "" {
+					bad("%s", err)
+				}
+			}
+		case "files":
+			m, ok := val.(map[string]any)
+			if !ok {
+				bad("files: must be a mapping of column to upload limits")
+				continue
+			}
+			for _, col := range sortedDocKeys(m) {
+				fc, errs := decodeFileColumn(col, m[col])
+				problems = append(problems, errs...)
+				if len(errs) == 0 {
+					def.Files[col] = fc
+				}
+			}
+		case "ui":
+			m, ok := val.(map[string]any)
+			if !ok {
+				bad("ui: must be a mapping of column to hints")
+				continue
+			}
+			for _, col := range sortedDocKeys(m) {
+				ui, errs := decodeColumnUI(col, m[col])
+				problems = append(problems, errs...)
+				if len(errs) == 0 {
+					def.UI[col] = ui
+				}
+			}
+		default:
+			bad("unknown key %q", key)
+		}
+	}
+	return def, problems
+}
+
+func decodeRelation(name string, v any) (eloquent.Relation, string) {
+	if s, ok := docString(v); ok {
+		return parseRelationSpec(name, s)
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return eloquent.Relation{}, fmt.Sprintf("relations.%s: must be a mapping or a string", name)
+	}
+	fields := map[string]string{}
+	for _, k := range sortedDocKeys(m) {
+		switch k {
+		case "type", "local_key", "table", "foreign_key":
+			s, ok := docString(m[k])
+			if !ok || s == "" {
+				return eloquent.Relation{}, fmt.Sprintf("relations.%s.%s: must be a non-empty string", name, k)
+			}
+			fields[k] = s
+		default:
+			return eloquent.Relation{}, fmt.Sprintf("relations.%s: unknown key %q", name, k)
+		}
+	}
+	for _, k := range []string{"type", "local_key", "table", "foreign_key"} {
+		if fields[k] == "" {
+			return eloquent.Relation{}, fmt.Sprintf("relations.%s.%s: required", name, k)
+		}
+	}
+	return parseRelationSpec(name, fields["type"]+":"+fields["local_key"]+"->"+fields["table"]+"."+fields["foreign_key"])
+}
+
+func sortedDocKeys(m map[string]any) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func docString(v any) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t), true
+	case json.Number:
+		return t.String(), true
+	}
+	return "", false
+}
+
+func docBool(v any) (bool, bool) {
+	switch t := v.(type) {
+	case bool:
+		return t, true
+	case string:
+		return parseBool(t)
+	}
+	return false, false
+}
+
+func docStringList(v any) ([]string, bool) {
+	if s, ok := v.(string); ok {
+		return splitCSV(s), true
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		s, ok := docString(item)
+		if !ok {
+			return nil, false
+		}
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out, true
+}
+
+// decodeGenerator reads a generate entry: a pattern string or {pattern, sequence}.
+func decodeGenerator(col string, v any) (string, string, string) {
+	if s, ok := docString(v); ok {
+		return s, "", ""
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return "", "", fmt.Sprintf("generate.%s: must be a pattern string or a mapping with pattern and sequence", col)
+	}
+	var pattern, sequence string
+	for _, key := range sortedDocKeys(m) {
+		s, ok := docString(m[key])
+		switch {
+		case !ok:
+			return "", "", fmt.Sprintf("generate.%s.%s: must be a string", col, key)
+		case key == "pattern":
+			pattern = s
+		case key == "sequence":
+			sequence = s
+		default:
+			return "", "", fmt.Sprintf("generate.%s: unknown key %q", col, key)
+		}
+	}
+	return pattern, sequence, ""
+}
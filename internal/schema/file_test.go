@@ -0,0 +1,220 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSchemaYAML(t *testing.T) {
+	raw := `# pasien
+table: pasien
+primary_key: kd_ps
+timestamps: false
+versioned: yes
+tenant_column: company_id
+fillable: [nama_ps, jk, "kd_dr"]
+hidden:
+  - password
+aliases:
+  nama: nama_ps
+casts:
+  jk: enum:L|P
+  pnc_total_point: decimal
+rules:
+  nama_ps: required|max:100
+  jk: [nullable, "in:L,P"]
+relations:
+  dokter:
+    type: belongsTo
+    local_key: kd_dr
+    table: dokter
+    foreign_key: kd_dr
+  cabang: belongsTo:kd_cb->cabang.kd_cb
+`
+	def, problems := parseSchemaYAML("pasien", raw)
+	if len(problems) > 0 {
+		t.Fatalf("problems: %v", problems)
+	}
+	if def.PrimaryKey != "kd_ps" || def.Timestamps == nil || *def.Timestamps || !def.Versioned || def.TenantColumn != "company_id" {
+		t.Fatalf("def: %+v", def)
+	}
+	if strings.Join(def.Fillable, ",") != "nama_ps,jk,kd_dr" || strings.Join(def.Hidden, ",") != "password" {
+		t.Fatalf("lists: %v %v", def.Fillable, def.Hidden)
+	}
+	if def.Aliases["nama"] != "nama_ps" || def.Casts["jk"].Kind() != "enum" || len(def.Rules["jk"]) != 2 {
+		t.Fatalf("maps: %+v %+v %+v", def.Aliases, def.Casts, def.Rules)
+	}
+	if rel := def.Relations["dokter"]; rel.Table != "dokter" || rel.LocalKey != "kd_dr" || rel.ForeignKey != "kd_dr" {
+		t.Fatalf("relation: %+v", rel)
+	}
+	if rel := def.Relations["cabang"]; rel.Table != "cabang" || rel.ForeignKey != "kd_cb" {
+		t.Fatalf("shorthand relation: %+v", rel)
+	}
+}
+
+func TestSchemaFileProblems(t *testing.T) {
+	cases := map[string]struct {
+		parse func() []string
+		want  string
+	}{
+		"yaml unknown key": {func() []string { _, p := parseSchemaYAML("t", "fillabel: [a]\n"); return p }, "fillabel"},
+		"yaml flow map":    {func() []string { _, p := parseSchemaYAML("t", "aliases: {a: b}\n"); return p }, "flow mappings"},
+		"yaml tabs":        {func() []string { _, p := parseSchemaYAML("t", "casts:\n\ta: int\n"); return p }, "tabs"},
+		"json table name":  {func() []string { _, p := parseSchemaJSON("t", []byte(`{"table":"other"}`)); return p }, "file name"},
+		"json bad cast":    {func() []string { _, p := parseSchemaJSON("t", []byte(`{"casts":{"a":"money"}}`)); return p }, "casts"},
+		"txt no equals":    {func() []string { _, p := parseSchemaTXT("fillable a,b\n"); return p }, "line 1"},
+		"txt unknown key":  {func() []string { _, p := parseSchemaTXT("# c\nfilable=a\n"); return p }, "line 2"},
+		"txt tenant":       {func() []string { _, p := parseSchemaTXT("tenant=shared\n"); return p }, "tenant"},
+	}
+	for name, tc := range cases {
+		problems := tc.parse()
+		if len(problems) == 0 || !strings.Contains(strings.Join(problems, "; "), tc.want) {
+			t.Errorf("%s: problems %v, want one mentioning %q", name, problems, tc.want)
+		}
+	}
+}
+
+func TestTryLoadSchemaFileFailsClosed(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SCHEMA_DIR", dir)
+	if err := os.WriteFile(filepath.Join(dir, "pasien.yaml"), []byte("fillable: [a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := tryLoadSchemaFile("pasien")
+	fe, ok := err.(*FileError)
+	if !ok || fe.Table != "pasien" || len(fe.Problems) == 0 {
+		t.Fatalf("expected *FileError, got %v", err)
+	}
+	statuses, err := CheckDir()
+	if err != nil || len(statuses) != 1 || statuses[0].OK || statuses[0].Format != "yaml" {
+		t.Fatalf("statuses: %+v (%v)", statuses, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "pasien.txt"), []byte("fillable=a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tryLoadSchemaFile("pasien"); err == nil || !strings.Contains(err.Error(), "multiple schema files") {
+		t.Fatalf("expected duplicate file error, got %v", err)
+	}
+}
+
+func TestParseUIHints(t *testing.T) {
+	txt, problems := parseSchemaTXT("ui=kd_dr:label.id=Dokter;label.en=Doctor;widget=lookup;lookup=dokter.kd_dr:nama_dr;order=2\n")
+	if len(problems) > 0 {
+		t.Fatalf("txt problems: %v", problems)
+	}
+	yml, problems := parseSchemaYAML("pasien", `ui:
+  kd_dr:
+    label:
+      id: Dokter
+      en: Doctor
+    widget: lookup
+    lookup: dokter.kd_dr:nama_dr
+    order: 2
+`)
+	if len(problems) > 0 {
+		t.Fatalf("yaml problems: %v", problems)
+	}
+	for name, def := range map[string]fileSchemaDef{"txt": txt, "yaml": yml} {
+		ui := def.UI["kd_dr"]
+		if ui.Label["id"] != "Dokter" || ui.Label["en"] != "Doctor" || ui.Widget != "lookup" || ui.Order != 2 {
+			t.Errorf("%s: %+v", name, ui)
+		}
+		if ui.Lookup == nil || *ui.Lookup != (Lookup{Table: "dokter", Value: "kd_dr", Label: "nama_dr"}) {
+			t.Errorf("%s: lookup %+v", name, ui.Lookup)
+		}
+	}
+
+	for raw, want := range map[string]string{
+		"ui=jk:widget=slider\n":           "unknown widget",
+		"ui=kd_dr:widget=lookup\n":        "requires lookup",
+		"ui=kd_dr:lookup=dokter\n":        "table.value_column",
+		"ui=jk:order=first\n":             "order",
+		"ui=jk:label.indonesia=Kelamin\n": "language code",
+		"ui=jk:colour=red\n":              "unknown key",
+	} {
+		if _, problems := parseSchemaTXT(raw); !strings.Contains(strings.Join(problems, "; "), want) {
+			t.Errorf("%q: problems %v, want one mentioning %q", raw, problems, want)
+		}
+	}
+}
+
+func TestParseGenerateDirective(t *testing.T) {
+	txt, problems := parseSchemaTXT("generate=kd_ps:{company}-{yyyy}{mm}-{seq:6}\ngenerate=no_lab:LAB{yy}{mm}{dd}{seq:4};sequence=no_lab\n")
+	if len(problems) > 0 {
+		t.Fatalf("txt problems: %v", problems)
+	}
+	yml, problems := parseSchemaYAML("pasien", `generate:
+  kd_ps: "{company}-{yyyy}{mm}-{seq:6}"
+  no_lab:
+    pattern: "LAB{yy}{mm}{dd}{seq:4}"
+    sequence: no_lab
+`)
+	if len(problems) > 0 {
+		t.Fatalf("yaml problems: %v", problems)
+	}
+	for name, def := range map[string]fileSchemaDef{"txt": txt, "yaml": yml} {
+		if g := def.Generators["kd_ps"]; g.Pattern.String() != "{company}-{yyyy}{mm}-{seq:6}" || g.Sequence != "" {
+			t.Errorf("%s: kd_ps %+v", name, g)
+		}
+		if g := def.Generators["no_lab"]; g.Pattern.String() != "LAB{yy}{mm}{dd}{seq:4}" || g.Sequence != "no_lab" {
+			t.Errorf("%s: no_lab %+v", name, g)
+		}
+	}
+
+	for raw, want := range map[string]string{
+		"generate=kd_ps:{company}-{yyyy}\n":          "exactly once",
+		"generate=kd_ps:{week}{seq:3}\n":             "unknown token",
+		"generate=kd_ps:{seq:3};prefix=A\n":          "unknown option",
+		"generate=kd_ps:{seq:3};sequence=Bad Name\n": "invalid sequence name",
+		"generate={seq:3}\n":                         "expected column:pattern",
+	} {
+		if _, problems := parseSchemaTXT(raw); !strings.Contains(strings.Join(problems, "; "), want) {
+			t.Errorf("%q: problems %v, want one mentioning %q", raw, problems, want)
+		}
+	}
+}
+
+func TestParseFilesDirective(t *testing.T) {
+	txt, problems := parseSchemaTXT("files=gambar:image/jpeg|image/png;max=2MB\nfiles=doc_mitra:application/pdf\n")
+	if len(problems) > 0 {
+		t.Fatalf("txt problems: %v", problems)
+	}
+	yml, problems := parseSchemaYAML("pasien", `files:
+  gambar:
+    types: [image/jpeg, image/png]
+    max_size: 2MB
+  doc_mitra:
+    types: application/pdf
+`)
+	if len(problems) > 0 {
+		t.Fatalf("yaml problems: %v", problems)
+	}
+	for name, def := range map[string]fileSchemaDef{"txt": txt, "yaml": yml} {
+		fc := def.Files["gambar"]
+		if fc.MaxSize != 2<<20 || !fc.AllowsType("image/png") || fc.AllowsType("application/pdf") {
+			t.Errorf("%s: gambar %+v", name, fc)
+		}
+		if fc := def.Files["doc_mitra"]; fc.MaxSize != 0 || !fc.AllowsType("application/pdf") {
+			t.Errorf("%s: doc_mitra %+v", name, fc)
+		}
+	}
+	if !(FileColumn{Types: []string{"image/*"}}).AllowsType("image/webp; q=1") {
+		t.Errorf("image/* should match image/webp")
+	}
+
+	for raw, want := range map[string]string{
+		"files=gambar:\n":                    "at least one content type",
+		"files=gambar:image jpeg\n":          "invalid content type",
+		"files=gambar:image/png;max=big\n":   "max_size",
+		"files=gambar:image/png;limit=2MB\n": "unknown option",
+		"files=image/png\n":                  "expected column",
+	} {
+		if _, problems := parseSchemaTXT(raw); !strings.Contains(strings.Join(problems, "; "), want) {
+			t.Errorf("%q: problems %v, want one mentioning %q", raw, problems, want)
+		}
+	}
+}
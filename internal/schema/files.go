@@ -0,0 +1,114 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileColumn marks a column that holds an uploaded file's storage key (schema file `files`).
+// Uploads go through /v1/crud/{table}/{pk}/files/{column}; only declared columns accept them.
+type FileColumn struct {
+	Types   []string `json:"types"`              // allowed content types; "image/*" matches any image
+	MaxSize int64    `json:"max_size,omitempty"` // bytes; 0 = FILE_MAX_SIZE_MB only
+}
+
+// AllowsType reports whether a (sniffed) content type is allowed for the column.
+func (f FileColumn) AllowsType(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, t := range f.Types {
+		if t == ct || strings.HasSuffix(t, "/*") && strings.HasPrefix(ct, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mimeTypeRE = regexp.MustCompile(`^[a-z0-9][a-z0-9.+-]*/([a-z0-9][a-z0-9.+-]*|\*)$`)
+	fileSizeRE = regexp.MustCompile(`^([0-9]+)\s*(b|kb|mb)?$`)
+)
+
+// parseFilesTXT parses the txt form: files=<column>:image/jpeg|image/png;max=2MB
+func parseFilesTXT(val string) (string, FileColumn, []string) {
+	col, spec, ok := strings.Cut(val, ":")
+	col = strings.TrimSpace(col)
+	if !ok || !tableNameRE.MatchString(col) {
+		return "", FileColumn{}, []string{"files: expected column:type|type[;max=size]"}
+	}
+	types, opt, _ := strings.Cut(spec, ";")
+	fields := map[string]any{"types": strings.ReplaceAll(types, "|", ",")}
+	if opt = strings.TrimSpace(opt); opt != "" {
+		k, v, _ := strings.Cut(opt, "=")
+		if strings.TrimSpace(k) != "max" {
+			return "", FileColumn{}, []string{fmt.Sprintf("files.%s: unknown option %q (expected max=size)", col, opt)}
+		}
+		fields["max_size"] = strings.TrimSpace(v)
+	}
+	fc, problems := decodeFileColumn(col, fields)
+	return col, fc, problems
+}
+
+// decodeFileColumn validates one column's upload limits: {types: [...], max_size: 2MB}.
+func decodeFileColumn(col string, v any) (FileColumn, []string) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return FileColumn{}, []string{fmt.Sprintf("files.%s: must be a mapping with types and max_size", col)}
+	}
+	fc := FileColumn{}
+	problems := []string{}
+	bad := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("files.%s.", col)+fmt.Sprintf(format, args...))
+	}
+	for _, key := range sortedDocKeys(m) {
+		switch key {
+		case "types":
+			list, ok := docStringList(m[key])
+			if !ok {
+				bad("types: must be a list of content types")
+				continue
+			}
+			for _, ct := range list {
+				ct = strings.ToLower(strings.TrimSpace(ct))
+				if !mimeTypeRE.MatchString(ct) {
+					bad("types: invalid content type %q", ct)
+					continue
+				}
+				fc.Types = append(fc.Types, ct)
+			}
+		case "max_size":
+			s, _ := docString(m[key])
+			n, ok := parseFileSize(s)
+			if !ok {
+				bad("max_size: expected a size such as 500KB or 2MB")
+				continue
+			}
+			fc.MaxSize = n
+		default:
+			bad("unknown key %q", key)
+		}
+	}
+	if len(fc.Types) == 0 && len(problems) == 0 {
+		bad("types: at least one content type is required")
+	}
+	return fc, problems
+}
+
+func parseFileSize(s string) (int64, bool) {
+	m := fileSizeRE.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	switch m[2] {
+	case "kb":
+		n <<= 10
+	case "mb":
+		n <<= 20
+	}
+	return n, true
+}
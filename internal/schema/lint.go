@@ -0,0 +1,262 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"mylab-api-go/internal/database/eloquent"
+)
+
+// LintIssue is one problem found by Lint. Field names the directive (e.g. "casts.tgl_lahir").
+type LintIssue struct {
+	Table   string `json:"table"`
+	Path    string `json:"path,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Lint checks every schema file in SCHEMA_DIR against the live database: parse problems,
+// unknown columns, a missing tenant column, a primary key mismatch, casts that do not fit the
+// column type, invalid computed expressions, relations to unknown tables or keys, generators on
+// unknown or non-text columns, file columns likewise and UI hints on unknown columns or lookups.
+func Lint(ctx context.Context, q columnQuerier) ([]LintIssue, error) {
+	dir := SchemaDir()
+	if dir == "" {
+		return nil, fmt.Errorf("SCHEMA_DIR is not set")
+	}
+	tables, err := schemaDirTables(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []LintIssue{}
+	for _, table := range tables {
+		found, err := lintTable(ctx, q, table)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		issues = append(issues, found...)
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Table < issues[j].Table })
+	return issues, nil
+}
+
+func lintTable(ctx context.Context, q columnQuerier, table string) ([]LintIssue, error) {
+	issues := []LintIssue{}
+	path := ""
+	add := func(field, format string, args ...any) {
+		issues = append(issues, LintIssue{Table: table, Path: path, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	def, _, err := tryLoadSchemaFile(table)
+	var fe *FileError
+	if errors.As(err, &fe) {
+		path = fe.Path
+		for _, p := range fe.Problems {
+			add("file", "%s", p)
+		}
+		return issues, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range Statuses() {
+		if st.Table == table {
+			path = st.Path
+		}
+	}
+
+	cols, dbCasts, err := lintColumns(ctx, q, table)
+	if err != nil {
+		return nil, err
+	}
+	if cols == nil {
+		add("table", "not found in the database")
+		return issues, nil
+	}
+	isCol := func(c string) bool { return cols[c] }
+
+	for _, list := range []struct {
+		field string
+		cols  []string
+	}{{"columns", def.Columns}, {"fillable", def.Fillable}, {"hidden", def.Hidden}, {"guarded", def.Guarded}} {
+		for _, c := range list.cols {
+			if !cols[c] && def.Computed[c] == "" {
+				add(list.field, "unknown column %q", c)
+			}
+		}
+	}
+	for _, alias := range sortedKeys(def.Aliases) {
+		if target := def.Aliases[alias]; !cols[target] {
+			add("aliases."+alias, "unknown column %q", target)
+		}
+	}
+	for _, col := range sortedKeys(def.Casts) {
+		ct := def.Casts[col]
+		if !cols[col] {
+			if def.Computed[col] == "" {
+				add("casts."+col, "unknown column")
+			}
+			continue
+		}
+		if !castFits(ct, dbCasts[col]) {
+			add("casts."+col, "cast %s does not fit the column type (introspected as %s)", ct, dbCasts[col])
+		}
+	}
+	for _, col := range sortedKeys(def.Rules) {
+		if !cols[col] {
+			add("rules."+col, "unknown column")
+		}
+	}
+	for _, name := range sortedKeys(def.Computed) {
+		if !eloquent.IsValidComputedName(name) || cols[name] {
+			add("computed."+name, "invalid name (must be a new identifier)")
+			continue
+		}
+		if err := eloquent.ValidateComputedExpr(def.Computed[name], isCol); err != nil {
+			add("computed."+name, "invalid expression: %v", err)
+		}
+	}
+
+	pk, err := introspectPrimaryKey(ctx, q, table)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case def.PrimaryKey != "" && !cols[def.PrimaryKey]:
+		add("primary_key", "unknown column %q", def.PrimaryKey)
+	case def.PrimaryKey != "" && pk != "" && def.PrimaryKey != pk:
+		add("primary_key", "file says %q but the database primary key is %q", def.PrimaryKey, pk)
+	case def.PrimaryKey == "" && pk == "":
+		add("primary_key", "the table has no primary key; set primary_key")
+	}
+
+	switch {
+	case def.Global:
+	case def.TenantColumn != "":
+		if !cols[def.TenantColumn] {
+			add("tenant_column", "unknown column %q", def.TenantColumn)
+		}
+	case !cols["company_id"] && !cols["com_id"]:
+		add("tenant", "no tenant column (company_id/com_id); set tenant_column or tenant: global")
+	}
+
+	for _, col := range sortedKeys(def.Generators) {
+		switch {
+		case !cols[col]:
+			add("generate."+col, "unknown column")
+		case castFamily(dbCasts[col]) != "":
+			add("generate."+col, "generated codes are text but the column introspects as %s", dbCasts[col])
+		}
+	}
+
+	for _, col := range sortedKeys(def.Files) {
+		switch {
+		case !cols[col]:
+			add("files."+col, "unknown column")
+		case castFamily(dbCasts[col]) != "":
+			add("files."+col, "file keys are text but the column introspects as %s", dbCasts[col])
+		}
+	}
+
+	for _, name := range sortedKeys(def.Relations) {
+		rel := def.Relations[name]
+		if !cols[rel.LocalKey] {
+			add("relations."+name, "unknown local key %q", rel.LocalKey)
+		}
+		if !tableNameRE.MatchString(rel.Table) {
+			add("relations."+name, "invalid target table %q", rel.Table)
+			continue
+		}
+		targetCols, _, err := lintColumns(ctx, q, rel.Table)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case targetCols == nil:
+			add("relations."+name, "target table %q not found in the database", rel.Table)
+		case !targetCols[rel.ForeignKey]:
+			add("relations."+name, "unknown foreign key %s.%s", rel.Table, rel.ForeignKey)
+		}
+	}
+	for _, col := range sortedKeys(def.UI) {
+		if !cols[col] && def.Computed[col] == "" {
+			add("ui."+col, "unknown column")
+		}
+		lk := def.UI[col].Lookup
+		if lk == nil {
+			continue
+		}
+		targetCols, _, err := lintColumns(ctx, q, lk.Table)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case targetCols == nil:
+			add("ui."+col, "lookup table %q not found in the database", lk.Table)
+		case !targetCols[lk.Value]:
+			add("ui."+col, "unknown lookup column %s.%s", lk.Table, lk.Value)
+		case lk.Label != "" && !targetCols[lk.Label]:
+			add("ui."+col, "unknown lookup column %s.%s", lk.Table, lk.Label)
+		}
+	}
+	return issues, nil
+}
+
+// lintColumns returns the table's columns and introspected casts; nil columns when the table
+// does not exist.
+func lintColumns(ctx context.Context, q columnQuerier, table string) (map[string]bool, map[string]eloquent.CastType, error) {
+	names, casts, _, err := introspectColumns(ctx, q, table)
+	var ve *eloquent.ValidationError
+	if errors.As(err, &ve) && ve.Errors["table"] == "not found" {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	cols := make(map[string]bool, len(names))
+	for _, c := range names {
+		cols[c] = true
+	}
+	return cols, casts, nil
+}
+
+// castFits reports whether a file cast can apply to a column whose type introspects as dbCast.
+// Text columns accept any cast; otherwise the families must match (numbers, temporal, ...).
+func castFits(file, dbCast eloquent.CastType) bool {
+	dbFamily := castFamily(dbCast)
+	fileFamily := castFamily(file)
+	if dbFamily == "" || fileFamily == "" || dbFamily == fileFamily {
+		return true
+	}
+	// Legacy 0/1 flag columns.
+	return fileFamily == "bool" && dbFamily == "number"
+}
+
+func castFamily(ct eloquent.CastType) string {
+	switch ct.Kind() {
+	case "", eloquent.CastString:
+		return ""
+	case eloquent.CastInt, eloquent.CastFloat, eloquent.CastDecimal:
+		return "number"
+	case eloquent.CastDateTime, eloquent.CastDate, eloquent.CastTime:
+		return "temporal"
+	case eloquent.CastEnum:
+		// Enum labels are text.
+		return ""
+	default:
+		return strings.ToLower(string(ct.Kind()))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -6,13 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
+	"mylab-api-go/internal/database/dialect"
 	"mylab-api-go/internal/database/eloquent"
 )
 
+var tableNameRE = regexp.MustCompile("^[a-z0-9_]+$")
+
 type columnQuerier interface {
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 }
@@ -20,12 +23,14 @@ type columnQuerier interface {
 // LoadSchema loads an eloquent.Schema for a table.
 //
 // Order:
-// 1) File-based schema from SCHEMA_DIR/<table>.txt (if present)
-// 2) DB introspection via information_schema (fallback)
+// 1) File-based schema from SCHEMA_DIR/<table>.yaml|.yml|.json|.txt (if present)
+// 2) DB introspection via information_schema (fallback when there is no file)
 //
 // Notes:
 // - This is designed to remove hardcoded Go model schema for standard CRUD.
-// - Tenant enforcement requires company_id to exist in the table.
+// - Tenant enforcement uses tenant_column= (schema file), else company_id, else com_id.
+// - Tables declared tenant=global are shared and not tenant-scoped.
+// - An invalid schema file is a *FileError, never a silent fallback to introspection.
 func LoadSchema(ctx context.Context, q columnQuerier, table string) (eloquent.Schema, error) {
 	table = strings.ToLower(strings.TrimSpace(table))
 	if table == "" {
@@ -35,7 +40,10 @@ func LoadSchema(ctx context.Context, q columnQuerier, table string) (eloquent.Sc
 		return eloquent.Schema{}, &eloquent.ValidationError{Errors: map[string]string{"database": "not configured"}}
 	}
 
-	def, ok := tryLoadSchemaFile(table)
+	def, ok, err := tryLoadSchemaFile(table)
+	if err != nil {
+		return eloquent.Schema{}, err
+	}
 	if ok {
 		// Fill missing parts by introspection if needed.
 		return buildSchemaFromDefAndDB(ctx, q, table, def)
@@ -44,51 +52,101 @@ func LoadSchema(ctx context.Context, q columnQuerier, table string) (eloquent.Sc
 	return buildSchemaFromDB(ctx, q, table)
 }
 
-type fileSchemaDef struct {
-	PrimaryKey string
-	Timestamps *bool
-	Fillable   []string
-	Columns    []string
-	Aliases    map[string]string
-	Casts      map[string]eloquent.CastType
+// Directives are the schema-file settings that also apply outside generic CRUD
+// (for example the query DSL, which validates columns via information_schema).
+type Directives struct {
+	Hidden       []string
+	Computed     map[string]string
+	TenantColumn string
+	Global       bool
+	UI           map[string]ColumnUI
+	Files        map[string]FileColumn
 }
 
-func tryLoadSchemaFile(table string) (fileSchemaDef, bool) {
-	dir := strings.TrimSpace(os.Getenv("SCHEMA_DIR"))
-	if dir == "" {
-		return fileSchemaDef{}, false
-	}
-	path := filepath.Join(dir, table+".txt")
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return fileSchemaDef{}, false
-	}
-	def, err := parseSchemaTXT(string(b))
-	if err != nil {
-		// treat parse error as "file not usable"; caller will fall back to DB
-		return fileSchemaDef{}, false
+// LoadDirectives returns schema-file directives for a table. ok=false when there is no file;
+// an invalid file is a *FileError.
+func LoadDirectives(table string) (Directives, bool, error) {
+	def, ok, err := tryLoadSchemaFile(strings.ToLower(strings.TrimSpace(table)))
+	if err != nil || !ok {
+		return Directives{}, false, err
 	}
-	return def, true
+	return Directives{Hidden: def.Hidden, Computed: def.Computed, TenantColumn: def.TenantColumn, Global: def.Global, UI: def.UI, Files: def.Files}, true, nil
+}
+
+type fileSchemaDef struct {
+	PrimaryKey   string
+	Timestamps   *bool
+	Versioned    bool
+	Fillable     []string
+	Columns      []string
+	Aliases      map[string]string
+	Hidden       []string
+	Guarded      []string
+	Computed     map[string]string
+	Relations    map[string]eloquent.Relation
+	Casts        map[string]eloquent.CastType
+	Rules        map[string][]eloquent.Rule
+	TenantColumn string
+	Global       bool
+	UI           map[string]ColumnUI
+	Generators   map[string]eloquent.Generator
+	Files        map[string]FileColumn
+
+	// Where the definition was loaded from (set by tryLoadSchemaFile).
+	path, format string
+}
+
+func newFileSchemaDef() fileSchemaDef {
+	return fileSchemaDef{Aliases: map[string]string{}, Casts: map[string]eloquent.CastType{}, Rules: map[string][]eloquent.Rule{}, Computed: map[string]string{}, Relations: map[string]eloquent.Relation{}, UI: map[string]ColumnUI{}, Generators: map[string]eloquent.Generator{}, Files: map[string]FileColumn{}}
 }
 
 // parseSchemaTXT is a very small INI-like parser.
 // Example:
 // primary_key=kd_ps
 // timestamps=true
+// versioned=true
 // aliases=com_id:company_id
 // fillable=nama_ps,alamat
 // columns=kd_ps,nama_ps,alamat,company_id,created_at,updated_at
-// casts=company_id:int,created_at:datetime
-func parseSchemaTXT(raw string) (fileSchemaDef, error) {
-	def := fileSchemaDef{Aliases: map[string]string{}, Casts: map[string]eloquent.CastType{}}
+// hidden=password
+// guarded=pnc_sync
+// casts=company_id:int,created_at:datetime,total:decimal,tgl_lahir:date,jk:enum:L|P,tags:array:string
+// rules=nama_ps:required|max:100
+// rules=jk:nullable|in:L,P
+// computed=usia:date_part('year', age(tgl_lahir))
+// relation=dokter:belongsTo:kd_dr->dokter.kd_dr
+// tenant_column=lab_id
+// tenant=global
+// generate=kd_ps:{company}-{yyyy}{mm}-{seq:6}
+// generate=no_lab:LAB{yy}{mm}{dd}{seq:4};sequence=no_lab
+// files=gambar:image/jpeg|image/png;max=2MB
+//
+// rules=, computed=, relation=, generate=, files= and ui= may be repeated (one column per line) because values contain commas.
+// tenant_column= names the tenant column when it is not company_id/com_id; tenant=global marks a
+// shared reference table (readable by every tenant, writable by super-admins only).
+// generate= fills the column on insert when the payload leaves it empty (see eloquent.CodePattern);
+// the counter is <table>.<column> unless sequence= names one shared with other tables.
+// files= declares a column that takes uploads (/v1/crud/{table}/{pk}/files/{column}).
+// versioned=true keeps a full row snapshot per create/update/delete (/v1/crud/{table}/{pk}/history).
+//
+// Unknown keys, casts, rules and malformed values are reported as problems (with line numbers);
+// a file with problems is not used.
+func parseSchemaTXT(raw string) (fileSchemaDef, []string) {
+	def := newFileSchemaDef()
+	problems := []string{}
+	bad := func(n int, format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("line %d: ", n)+fmt.Sprintf(format, args...))
+	}
 	lines := strings.Split(raw, "\n")
-	for _, line := range lines {
+	for i, line := range lines {
+		n := i + 1
 		s := strings.TrimSpace(line)
 		if s == "" || strings.HasPrefix(s, "#") || strings.HasPrefix(s, "//") {
 			continue
 		}
 		parts := strings.SplitN(s, "=", 2)
 		if len(parts) != 2 {
+			bad(n, "expected key=value")
 			continue
 		}
 		key := strings.ToLower(strings.TrimSpace(parts[0]))
@@ -97,23 +155,42 @@ func parseSchemaTXT(raw string) (fileSchemaDef, error) {
 		case "primary_key", "pk":
 			def.PrimaryKey = strings.TrimSpace(val)
 		case "timestamps":
-			v := strings.ToLower(strings.TrimSpace(val))
-			b := v == "1" || v == "true" || v == "yes" || v == "y"
+			b, ok := parseBool(val)
+			if !ok {
+				bad(n, "timestamps: must be true or false")
+				continue
+			}
 			def.Timestamps = &b
+		case "versioned":
+			b, ok := parseBool(val)
+			if !ok {
+				bad(n, "versioned: must be true or false")
+				continue
+			}
+			def.Versioned = b
 		case "fillable":
 			def.Fillable = splitCSV(val)
 		case "columns":
 			def.Columns = splitCSV(val)
+		case "hidden":
+			def.Hidden = splitCSV(val)
+		case "guarded":
+			def.Guarded = splitCSV(val)
+		case "tenant_column":
+			def.TenantColumn = strings.TrimSpace(val)
+		case "tenant":
+			if !strings.EqualFold(val, "global") {
+				bad(n, "tenant: only \"global\" is supported (use tenant_column= for a custom column)")
+				continue
+			}
+			def.Global = true
 		case "aliases":
 			// comma separated k:v
 			for _, kv := range splitCSV(val) {
-				p := strings.SplitN(kv, ":", 2)
-				if len(p) != 2 {
-					continue
-				}
-				k := strings.TrimSpace(p[0])
-				v := strings.TrimSpace(p[1])
-				if k == "" || v == "" {
+				k, v, ok := strings.Cut(kv, ":")
+				k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+				if !ok || k == "" || v == "" {
+					bad(n, "aliases: expected alias:column, got %q", kv)
 					continue
 				}
 				def.Aliases[k] = v
@@ -121,33 +198,149 @@ func parseSchemaTXT(raw string) (fileSchemaDef, error) {
 		case "casts":
 			// comma separated col:type
 			for _, kv := range splitCSV(val) {
-				p := strings.SplitN(kv, ":", 2)
-				if len(p) != 2 {
-					continue
+				col, typ, ok := strings.Cut(kv, ":")
+				if err := addCast(def.Casts, strings.TrimSpace(col), typ, ok); err != "" {
+					bad(n, "%s", err)
 				}
-				col := strings.TrimSpace(p[0])
-				typ := strings.ToLower(strings.TrimSpace(p[1]))
-				if col == "" || typ == "" {
+			}
+		case "rules":
+			// col:rule|rule:arg
+			col, spec, ok := strings.Cut(val, ":")
+			if err := addRules(def.Rules, strings.TrimSpace(col), spec, ok); err != "" {
+				bad(n, "%s", err)
+			}
+		case "computed":
+			// name:expression (validated against table columns in buildSchemaFromDefAndDB)
+			name, expr, ok := strings.Cut(val, ":")
+			name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+			if !ok || name == "" || expr == "" {
+				bad(n, "computed: expected name:expression")
+				continue
+			}
+			def.Computed[name] = expr
+		case "relation", "relations":
+			// name:type:local_col->table.foreign_col
+			name, spec, _ := strings.Cut(val, ":")
+			rel, err := parseRelationSpec(strings.TrimSpace(name), spec)
+			if err != "" {
+				bad(n, "%s", err)
+				continue
+			}
+			def.Relations[rel.Name] = rel
+		case "generate":
+			// col:pattern[;sequence=name]
+			col, spec, ok := strings.Cut(val, ":")
+			pattern, opt, _ := strings.Cut(spec, ";")
+			sequence := ""
+			if opt = strings.TrimSpace(opt); opt != "" {
+				k, v, _ := strings.Cut(opt, "=")
+				if strings.TrimSpace(k) != "sequence" {
+					bad(n, "generate.%s: unknown option %q (expected sequence=name)", strings.TrimSpace(col), opt)
 					continue
 				}
-				switch typ {
-				case string(eloquent.CastString):
-					def.Casts[col] = eloquent.CastString
-				case string(eloquent.CastInt):
-					def.Casts[col] = eloquent.CastInt
-				case string(eloquent.CastFloat):
-					def.Casts[col] = eloquent.CastFloat
-				case string(eloquent.CastBool):
-					def.Casts[col] = eloquent.CastBool
-				case string(eloquent.CastDateTime):
-					def.Casts[col] = eloquent.CastDateTime
-				default:
-					// ignore unknown
-				}
+				sequence = strings.TrimSpace(v)
+			}
+			if err := addGenerator(def.Generators, strings.TrimSpace(col), pattern, sequence, ok); err != "" {
+				bad(n, "%s", err)
+			}
+		case "files":
+			// col:type|type[;max=size]
+			col, fc, errs := parseFilesTXT(val)
+			for _, e := range errs {
+				bad(n, "%s", e)
+			}
+			if len(errs) == 0 {
+				def.Files[col] = fc
+			}
+		case "ui":
+			// col:label.id=...;label.en=...;widget=...;lookup=table.col[:label];order=n
+			col, ui, errs := parseUITXT(val)
+			for _, e := range errs {
+				bad(n, "%s", e)
+			}
+			if len(errs) == 0 {
+				def.UI[col] = ui
 			}
+		default:
+			bad(n, "unknown key %q", key)
 		}
 	}
-	return def, nil
+	return def, problems
+}
+
+// addCast parses one col:type pair into casts; it returns a problem message or "".
+func addCast(casts map[string]eloquent.CastType, col, typ string, ok bool) string {
+	if !ok || col == "" {
+		return fmt.Sprintf("casts: expected column:type, got %q", col+":"+typ)
+	}
+	ct, valid := eloquent.ParseCastType(typ)
+	if !valid {
+		return fmt.Sprintf("casts.%s: unknown cast %q", col, strings.TrimSpace(typ))
+	}
+	casts[col] = ct
+	return ""
+}
+
+// addRules parses one column's rule spec into rules; it returns a problem message or "".
+func addRules(rules map[string][]eloquent.Rule, col, spec string, ok bool) string {
+	if !ok || col == "" {
+		return "rules: expected column:rule|rule"
+	}
+	parsed, err := eloquent.ParseRules(spec)
+	if err != nil {
+		return fmt.Sprintf("rules.%s: %v", col, err)
+	}
+	rules[col] = append(rules[col], parsed...)
+	return ""
+}
+
+var sequenceNameRE = regexp.MustCompile(`^[a-z0-9_.]{1,128}$`)
+
+// addGenerator parses one column's code pattern into gens; it returns a problem message or "".
+func addGenerator(gens map[string]eloquent.Generator, col, pattern, sequence string, ok bool) string {
+	if !ok || !tableNameRE.MatchString(col) {
+		return "generate: expected column:pattern"
+	}
+	p, err := eloquent.ParseCodePattern(pattern)
+	if err != nil {
+		return fmt.Sprintf("generate.%s: %v", col, err)
+	}
+	if sequence != "" && !sequenceNameRE.MatchString(sequence) {
+		return fmt.Sprintf("generate.%s: invalid sequence name %q", col, sequence)
+	}
+	gens[col] = eloquent.Generator{Pattern: p, Sequence: sequence}
+	return ""
+}
+
+// parseRelationSpec parses "type:local_col->table.foreign_col"; it returns a problem message or "".
+func parseRelationSpec(name, spec string) (eloquent.Relation, string) {
+	typRaw, keysRaw, ok := strings.Cut(spec, ":")
+	typ, validType := eloquent.ParseRelationType(typRaw)
+	if name == "" || !ok || !validType {
+		return eloquent.Relation{}, fmt.Sprintf("relation %q: expected name:belongsTo|hasOne|hasMany:local_col->table.foreign_col", name)
+	}
+	local, target, ok := strings.Cut(keysRaw, "->")
+	table, foreign, ok2 := strings.Cut(strings.TrimSpace(target), ".")
+	if !ok || !ok2 {
+		return eloquent.Relation{}, fmt.Sprintf("relation %q: expected local_col->table.foreign_col", name)
+	}
+	return eloquent.Relation{
+		Name:       name,
+		Type:       typ,
+		LocalKey:   strings.TrimSpace(local),
+		Table:      strings.ToLower(strings.TrimSpace(table)),
+		ForeignKey: strings.TrimSpace(foreign),
+	}, ""
+}
+
+func parseBool(raw string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "1", "true", "yes", "y", "on":
+		return true, true
+	case "0", "false", "no", "n", "off":
+		return false, true
+	}
+	return false, false
 }
 
 func splitCSV(s string) []string {
@@ -180,18 +373,83 @@ func buildSchemaFromDefAndDB(ctx context.Context, q columnQuerier, table string,
 	if len(def.Aliases) > 0 {
 		schema.Aliases = def.Aliases
 	}
+	if len(def.Hidden) > 0 {
+		schema.Hidden = def.Hidden
+	}
+	if len(def.Guarded) > 0 {
+		schema.Guarded = def.Guarded
+	}
 	if len(def.Casts) > 0 {
-		schema.Casts = def.Casts
+		// File casts override the introspected ones per column.
+		if schema.Casts == nil {
+			schema.Casts = map[string]eloquent.CastType{}
+		}
+		for col, ct := range def.Casts {
+			schema.Casts[col] = ct
+		}
+	}
+	if len(def.Rules) > 0 {
+		schema.Rules = def.Rules
 	}
 	if def.Timestamps != nil {
 		schema.Timestamps = *def.Timestamps
 	}
+	schema.Versioned = def.Versioned
+
+	// Directives that only check against the table's columns: a mismatch is a broken schema
+	// file (500 schema_invalid, shown in /healthz/schemas), not a bad request.
+	problems := []string{}
+	if def.Global {
+		schema.Global = true
+	} else if def.TenantColumn != "" {
+		if !tableNameRE.MatchString(def.TenantColumn) || !schema.HasColumn(def.TenantColumn) {
+			problems = append(problems, fmt.Sprintf("tenant_column: unknown column %q", def.TenantColumn))
+		}
+		schema.TenantColumn = def.TenantColumn
+	}
+	for _, col := range sortedKeys(def.Generators) {
+		if !schema.HasColumn(col) {
+			problems = append(problems, "generate."+col+": unknown column")
+		}
+	}
+	if len(def.Generators) > 0 {
+		schema.Generators = def.Generators
+	}
+	for _, name := range sortedKeys(def.Computed) {
+		if !eloquent.IsValidComputedName(name) || schema.HasColumn(name) {
+			problems = append(problems, "computed."+name+": invalid name (must be a new identifier)")
+			continue
+		}
+		if err := eloquent.ValidateComputedExpr(def.Computed[name], schema.HasColumn); err != nil {
+			problems = append(problems, "computed."+name+": invalid expression: "+err.Error())
+		}
+	}
+	if len(def.Computed) > 0 {
+		schema.Computed = def.Computed
+	}
+	for _, name := range sortedKeys(def.Relations) {
+		rel := def.Relations[name]
+		switch {
+		case !tableNameRE.MatchString(name) || schema.HasColumn(name):
+			problems = append(problems, "relation."+name+": invalid name (must not be a column)")
+		case !schema.HasColumn(rel.LocalKey):
+			problems = append(problems, "relation."+name+": unknown local key")
+		case !tableNameRE.MatchString(rel.Table) || !tableNameRE.MatchString(rel.ForeignKey):
+			problems = append(problems, "relation."+name+": invalid target")
+		}
+	}
+	if len(def.Relations) > 0 {
+		schema.Relations = def.Relations
+	}
+	if len(problems) > 0 {
+		return eloquent.Schema{}, fileFailed(table, def.path, def.format, problems)
+	}
 
 	return schema, nil
 }
 
 func buildSchemaFromDB(ctx context.Context, q columnQuerier, table string) (eloquent.Schema, error) {
-	cols, casts, err := introspectColumns(ctx, q, table)
+	cols, casts, infos, err := introspectColumns(ctx, q, table)
 	if err != nil {
 		return eloquent.Schema{}, err
 	}
@@ -217,6 +475,7 @@ func buildSchemaFromDB(ctx context.Context, q columnQuerier, table string) (eloq
 		PrimaryKey: pk,
 		Columns:    cols,
 		Casts:      casts,
+		ColumnInfo: infos,
 		Timestamps: timestamps,
 		Now: func() time.Time {
 			return time.Now()
@@ -224,57 +483,201 @@ func buildSchemaFromDB(ctx context.Context, q columnQuerier, table string) (eloq
 	}, nil
 }
 
-func introspectColumns(ctx context.Context, q columnQuerier, table string) ([]string, map[string]eloquent.CastType, error) {
+func introspectColumns(ctx context.Context, q columnQuerier, table string) ([]string, map[string]eloquent.CastType, map[string]eloquent.ColumnInfo, error) {
 	table = strings.ToLower(strings.TrimSpace(table))
 	if table == "" {
-		return nil, nil, &eloquent.ValidationError{Errors: map[string]string{"table": "required"}}
+		return nil, nil, nil, &eloquent.ValidationError{Errors: map[string]string{"table": "required"}}
 	}
 
-	schemaName := strings.TrimSpace(os.Getenv("DB_SCHEMA"))
-	if schemaName == "" {
-		schemaName = "public"
+	d := dialect.Current()
+	schemaName, schemaCond, args := tableSchemaFilter(d, "table_schema")
+	// udt_name is Postgres-only; MySQL's column_type carries the enum labels ("enum('L','P')").
+	typeDetail := "udt_name"
+	if d.Name() == dialect.MySQLName {
+		typeDetail = "column_type"
 	}
 
 	rows, err := q.QueryContext(ctx,
-		`SELECT column_name, data_type 
+		`SELECT column_name, data_type, `+typeDetail+`, is_nullable,
+		        character_maximum_length, numeric_precision, numeric_scale, column_default
 		 FROM information_schema.columns 
-		 WHERE table_schema = $1 AND table_name = $2
+		 WHERE `+schemaCond+` AND table_name = `+d.Placeholder(len(args)+1)+`
 		 ORDER BY ordinal_position`,
-		schemaName, table,
+		append(args, table)...,
 	)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	defer rows.Close()
 
 	cols := []string{}
 	casts := map[string]eloquent.CastType{}
+	infos := map[string]eloquent.ColumnInfo{}
+	enumTypes := map[string]string{} // column -> enum type name
 	for rows.Next() {
-		var name, typ string
-		if err := rows.Scan(&name, &typ); err != nil {
-			return nil, nil, err
+		var (
+			name, typ, udt, nullable string
+			maxLen, prec, scale      sql.NullInt64
+			def                      sql.NullString
+		)
+		if err := rows.Scan(&name, &typ, &udt, &nullable, &maxLen, &prec, &scale, &def); err != nil {
+			return nil, nil, nil, err
 		}
 		name = strings.TrimSpace(name)
 		if name == "" {
 			continue
 		}
 		cols = append(cols, name)
-		casts[name] = guessCastType(strings.TrimSpace(typ))
+		casts[name] = guessCastType(strings.TrimSpace(typ), strings.TrimSpace(udt))
+		infos[name] = eloquent.ColumnInfo{
+			DataType:   strings.TrimSpace(typ),
+			Nullable:   strings.EqualFold(strings.TrimSpace(nullable), "YES"),
+			MaxLength:  int(maxLen.Int64),
+			Precision:  int(prec.Int64),
+			Scale:      int(scale.Int64),
+			Default:    strings.TrimSpace(def.String),
+			HasDefault: def.Valid && strings.TrimSpace(def.String) != "",
+		}
+		if strings.EqualFold(strings.TrimSpace(typ), "USER-DEFINED") && strings.TrimSpace(udt) != "" {
+			enumTypes[name] = strings.TrimSpace(udt)
+		}
+		if strings.EqualFold(strings.TrimSpace(typ), "enum") {
+			info := infos[name]
+			info.EnumValues = parseMySQLEnum(udt)
+			infos[name] = info
+			if len(info.EnumValues) > 0 {
+				casts[name] = eloquent.EnumCast(info.EnumValues)
+			}
+		}
 	}
 	if err := rows.Err(); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
+	// Important: close rows before issuing another query on the same tx/connection.
+	_ = rows.Close()
 	if len(cols) == 0 {
-		return nil, nil, &eloquent.ValidationError{Errors: map[string]string{"table": "not found"}}
+		return nil, nil, nil, &eloquent.ValidationError{Errors: map[string]string{"table": "not found"}}
+	}
+
+	for col, typeName := range enumTypes {
+		labels, err := introspectEnumLabels(ctx, q, schemaName, typeName)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(labels) == 0 {
+			continue
+		}
+		info := infos[col]
+		info.EnumValues = labels
+		infos[col] = info
+		casts[col] = eloquent.EnumCast(labels)
 	}
-	return cols, casts, nil
+	return cols, casts, infos, nil
 }
 
-func introspectPrimaryKey(ctx context.Context, q columnQuerier, table string) (string, error) {
+// tableSchemaFilter returns the information_schema condition on col for the configured schema:
+// DB_SCHEMA, else the dialect default ("public" on Postgres, the current database on MySQL).
+func tableSchemaFilter(d dialect.Dialect, col string) (string, string, []any) {
 	schemaName := strings.TrimSpace(os.Getenv("DB_SCHEMA"))
 	if schemaName == "" {
-		schemaName = "public"
+		schemaName = d.DefaultSchema()
+	}
+	if schemaName == "" {
+		return "", col + " = DATABASE()", nil
+	}
+	return schemaName, col + " = " + d.Placeholder(1), []any{schemaName}
+}
+
+// parseMySQLEnum extracts the labels from a MySQL column_type such as enum('L','P').
+func parseMySQLEnum(columnType string) []string {
+	t := strings.TrimSpace(columnType)
+	if !strings.HasPrefix(strings.ToLower(t), "enum(") || !strings.HasSuffix(t, ")") {
+		return nil
+	}
+	body := t[len("enum(") : len(t)-1]
+	labels := []string{}
+	for len(body) > 0 {
+		if body[0] != '\'' {
+			return nil
+		}
+		var sb strings.Builder
+		i := 1
+		for ; i < len(body); i++ {
+			if body[i] == '\'' {
+				if i+1 < len(body) && body[i+1] == '\'' {
+					sb.WriteByte('\'')
+					i++
+					continue
+				}
+				break
+			}
+			sb.WriteByte(body[i])
+		}
+		labels = append(labels, sb.String())
+		body = strings.TrimPrefix(body[min(i+1, len(body)):], ",")
+	}
+	return labels
+}
+
+// introspectEnumLabels returns the labels of a Postgres enum type (empty for non-enum user types).
+func introspectEnumLabels(ctx context.Context, q columnQuerier, schemaName, typeName string) ([]string, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT e.enumlabel
+		 FROM pg_type t
+		 JOIN pg_enum e ON e.enumtypid = t.oid
+		 JOIN pg_namespace n ON n.oid = t.typnamespace
+		 WHERE n.nspname = $1 AND t.typname = $2
+		 ORDER BY e.enumsortorder`,
+		schemaName, typeName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []string{}
+	for rows.Next() {
+		var l string
+		if err := rows.Scan(&l); err != nil {
+			return nil, err
+		}
+		labels = append(labels, l)
+	}
+	return labels, rows.Err()
+}
+
+// ListTables returns the base tables of the current schema (DB_SCHEMA, else the dialect default),
+// sorted by name. Views are not listed: generic CRUD writes need a real table.
+func ListTables(ctx context.Context, q columnQuerier) ([]string, error) {
+	d := dialect.Current()
+	_, schemaCond, args := tableSchemaFilter(d, "table_schema")
+	rows, err := q.QueryContext(ctx,
+		`SELECT table_name FROM information_schema.tables
+		 WHERE `+schemaCond+` AND table_type = 'BASE TABLE'
+		 ORDER BY table_name`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if name = strings.ToLower(strings.TrimSpace(name)); tableNameRE.MatchString(name) {
+			out = append(out, name)
+		}
 	}
+	return out, rows.Err()
+}
+
+func introspectPrimaryKey(ctx context.Context, q columnQuerier, table string) (string, error) {
+	d := dialect.Current()
+	_, schemaCond, args := tableSchemaFilter(d, "tc.table_schema")
 
 	rows, err := q.QueryContext(ctx,
 		`SELECT kcu.column_name
@@ -282,11 +685,12 @@ func introspectPrimaryKey(ctx context.Context, q columnQuerier, table string) (s
 		 JOIN information_schema.key_column_usage kcu
 		   ON tc.constraint_name = kcu.constraint_name
 		  AND tc.table_schema = kcu.table_schema
+		  AND tc.table_name = kcu.table_name
 		 WHERE tc.constraint_type = 'PRIMARY KEY'
-		   AND tc.table_schema = $1
-		   AND tc.table_name = $2
+		   AND `+schemaCond+`
+		   AND tc.table_name = `+d.Placeholder(len(args)+1)+`
 		 ORDER BY kcu.ordinal_position`,
-		schemaName, table,
+		append(args, table)...,
 	)
 	if err != nil {
 		return "", err
@@ -306,21 +710,64 @@ func introspectPrimaryKey(ctx context.Context, q columnQuerier, table string) (s
 	return pk, nil
 }
 
-func guessCastType(dbType string) eloquent.CastType {
+// guessCastType maps an information_schema data_type to a cast. detail is udt_name on Postgres
+// (array element type, e.g. _int4) and column_type on MySQL.
+func guessCastType(dbType, detail string) eloquent.CastType {
 	t := strings.ToLower(strings.TrimSpace(dbType))
+	switch t {
+	case "array":
+		elem := guessCastType(pgUDTDataType(strings.TrimPrefix(strings.ToLower(detail), "_")), "")
+		if elem == eloquent.CastJSON {
+			elem = eloquent.CastString
+		}
+		return eloquent.CastArray + ":" + elem
+	case "date":
+		return eloquent.CastDate
+	case "time", "time without time zone", "time with time zone":
+		return eloquent.CastTime
+	case "json", "jsonb":
+		return eloquent.CastJSON
+	case "uuid":
+		return eloquent.CastUUID
+	case "numeric", "decimal":
+		return eloquent.CastDecimal
+	case "interval", "point":
+		return eloquent.CastString
+	case "tinyint":
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(detail)), "tinyint(1)") {
+			return eloquent.CastBool // MySQL BOOLEAN
+		}
+		return eloquent.CastInt
+	}
 	switch {
 	case strings.Contains(t, "int"):
 		return eloquent.CastInt
-	case strings.Contains(t, "numeric"), strings.Contains(t, "decimal"), strings.Contains(t, "double"), strings.Contains(t, "real"), strings.Contains(t, "float"):
+	case strings.Contains(t, "double"), strings.Contains(t, "real"), strings.Contains(t, "float"):
 		return eloquent.CastFloat
 	case strings.Contains(t, "bool"):
 		return eloquent.CastBool
-	case strings.Contains(t, "timestamp"), strings.Contains(t, "date"), strings.Contains(t, "time"):
+	case strings.Contains(t, "timestamp"), strings.Contains(t, "datetime"):
 		return eloquent.CastDateTime
 	default:
 		return eloquent.CastString
 	}
 }
 
+// pgUDTDataType maps a Postgres udt_name (array element) to the data_type spelling guessCastType knows.
+func pgUDTDataType(udt string) string {
+	switch udt {
+	case "int2", "int4", "int8":
+		return "integer"
+	case "float4", "float8":
+		return "double precision"
+	case "bool":
+		return "boolean"
+	case "timestamp", "timestamptz":
+		return "timestamp"
+	default:
+		return udt // numeric, date, uuid, text, varchar, ...
+	}
+}
+
 var _ = errors.New
 var _ = fmt.Sprintf
@@ -0,0 +1,172 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mylab-api-go/internal/database/eloquent"
+)
+
+// fakeDriver answers queries with canned rows: the first registered result whose key is a
+// substring of the query. Queries and their args are recorded.
+type fakeDriver struct {
+	results []fakeResult
+	queries []string
+	args    [][]driver.NamedValue
+}
+
+type fakeResult struct {
+	match string
+	cols  []string
+	rows  [][]driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.queries = append(c.d.queries, query)
+	c.d.args = append(c.d.args, args)
+	for _, r := range c.d.results {
+		if strings.Contains(query, r.match) {
+			return &fakeRows{cols: r.cols, rows: r.rows}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type fakeConnector struct{ d *fakeDriver }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{c.d}, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return c.d }
+
+func TestIntrospectColumns(t *testing.T) {
+	t.Setenv("DB_SCHEMA", "")
+	d := &fakeDriver{results: []fakeResult{
+		{match: "pg_enum", cols: []string{"enumlabel"}, rows: [][]driver.Value{{"L"}, {"P"}}},
+		{match: "information_schema.columns", cols: []string{"column_name", "data_type", "udt_name", "is_nullable", "character_maximum_length", "numeric_precision", "numeric_scale", "column_default"}, rows: [][]driver.Value{
+			{"kd_ps", "integer", "int4", "NO", nil, int64(32), int64(0), "nextval('pasien_kd_ps_seq'::regclass)"},
+			{"nama_ps", "character varying", "varchar", "NO", int64(100), nil, nil, nil},
+			{"jk", "USER-DEFINED", "jenis_kelamin", "YES", nil, nil, nil, nil},
+			{"tarif", "numeric", "numeric", "YES", nil, int64(12), int64(2), nil},
+		}},
+	}}
+	db := sql.OpenDB(fakeConnector{d})
+	defer db.Close()
+
+	cols, casts, infos, err := introspectColumns(context.Background(), db, " Pasien ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cols, []string{"kd_ps", "nama_ps", "jk", "tarif"}) {
+		t.Fatalf("cols = %v", cols)
+	}
+
+	// The column query is scoped to the schema and the lower-cased table name.
+	q := d.queries[0]
+	if !strings.Contains(q, "table_schema = $1 AND table_name = $2") || !strings.Contains(q, "ORDER BY ordinal_position") {
+		t.Fatalf("query = %s", q)
+	}
+	if len(d.args[0]) != 2 || d.args[0][0].Value != "public" || d.args[0][1].Value != "pasien" {
+		t.Fatalf("args = %v", d.args[0])
+	}
+	if len(d.args) != 2 || d.args[1][0].Value != "public" || d.args[1][1].Value != "jenis_kelamin" {
+		t.Fatalf("enum label query args = %v", d.args)
+	}
+
+	want := map[string]eloquent.ColumnInfo{
+		"kd_ps":   {DataType: "integer", Precision: 32, Default: "nextval('pasien_kd_ps_seq'::regclass)", HasDefault: true},
+		"nama_ps": {DataType: "character varying", MaxLength: 100},
+		"jk":      {DataType: "USER-DEFINED", Nullable: true, EnumValues: []string{"L", "P"}},
+		"tarif":   {DataType: "numeric", Nullable: true, Precision: 12, Scale: 2},
+	}
+	if !reflect.DeepEqual(infos, want) {
+		t.Fatalf("infos = %#v", infos)
+	}
+	if casts["jk"] != eloquent.EnumCast([]string{"L", "P"}) || casts["tarif"] != eloquent.CastDecimal || casts["kd_ps"] != eloquent.CastInt {
+		t.Fatalf("casts = %v", casts)
+	}
+}
+
+func TestIntrospectColumnsNotFound(t *testing.T) {
+	db := sql.OpenDB(fakeConnector{&fakeDriver{}})
+	defer db.Close()
+	_, _, _, err := introspectColumns(context.Background(), db, "nope")
+	if ve, ok := err.(*eloquent.ValidationError); !ok || ve.Errors["table"] != "not found" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSchemaFileColumnProblems(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SCHEMA_DIR", dir)
+	t.Setenv("DB_SCHEMA", "")
+	file := "tenant_column=lab\ngenerate=kode:LAB-{seq:4}\ncomputed=nama:upper(nope)\nrelation=kd_ps:belongsTo:kd_ps->pasien.kd_ps\n"
+	if err := os.WriteFile(filepath.Join(dir, "cabang.txt"), []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d := &fakeDriver{results: []fakeResult{
+		{match: "table_constraints", cols: []string{"column_name"}, rows: [][]driver.Value{{"id"}}},
+		{match: "information_schema.columns", cols: []string{"column_name", "data_type", "udt_name", "is_nullable", "character_maximum_length", "numeric_precision", "numeric_scale", "column_default"}, rows: [][]driver.Value{
+			{"id", "integer", "int4", "NO", nil, int64(32), int64(0), nil},
+			{"kd_ps", "integer", "int4", "NO", nil, int64(32), int64(0), nil},
+			{"company_id", "integer", "int4", "NO", nil, int64(32), int64(0), nil},
+		}},
+	}}
+	db := sql.OpenDB(fakeConnector{d})
+	defer db.Close()
+
+	// A directive that does not fit the table is a broken file, not a bad request.
+	_, err := LoadSchema(context.Background(), db, "cabang")
+	fe, ok := err.(*FileError)
+	if !ok || fe.Path != filepath.Join(dir, "cabang.txt") {
+		t.Fatalf("expected *FileError, got %v", err)
+	}
+	want := []string{
+		`tenant_column: unknown column "lab"`,
+		"generate.kode: unknown column",
+		"computed.nama: invalid expression",
+		"relation.kd_ps: invalid name (must not be a column)",
+	}
+	if len(fe.Problems) != len(want) {
+		t.Fatalf("problems = %q", fe.Problems)
+	}
+	for i, p := range want {
+		if !strings.HasPrefix(fe.Problems[i], p) {
+			t.Fatalf("problems = %q", fe.Problems)
+		}
+	}
+	for _, st := range Statuses() {
+		if st.Table == "cabang" && (st.OK || len(st.Problems) != len(want)) {
+			t.Fatalf("status = %+v", st)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColumnUI holds optional form hints for one column (schema file `ui`). They are only used by
+// the metadata endpoints; CRUD ignores them.
+type ColumnUI struct {
+	Label  map[string]string `json:"label,omitempty"`  // language code -> label, e.g. id, en
+	Widget string            `json:"widget,omitempty"` // see uiWidgets
+	Lookup *Lookup           `json:"lookup,omitempty"` // option source for select/lookup widgets
+	Order  int               `json:"order,omitempty"`  // display order; 0 = after ordered columns
+}
+
+// Lookup points a field at the table it picks values from: table.value[:label].
+type Lookup struct {
+	Table string `json:"table"`
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+}
+
+var uiWidgets = map[string]bool{
+	"text": true, "textarea": true, "number": true, "decimal": true, "email": true, "password": true,
+	"date": true, "datetime": true, "time": true, "checkbox": true, "select": true, "radio": true,
+	"lookup": true, "json": true, "hidden": true,
+}
+
+var (
+	langRE   = regexp.MustCompile(`^[a-z]{2}(-[a-z]{2})?$`)
+	lookupRE = regexp.MustCompile(`^([a-z0-9_]+)\.([a-z0-9_]+)(?::([a-z0-9_]+))?$`)
+)
+
+// parseUITXT parses the txt form: ui=<column>:label.id=Nama pasien;label.en=Patient name;widget=text;order=1
+func parseUITXT(val string) (string, ColumnUI, []string) {
+	col, spec, ok := strings.Cut(val, ":")
+	col = strings.TrimSpace(col)
+	if !ok || col == "" {
+		return "", ColumnUI{}, []string{"ui: expected column:key=value;key=value"}
+	}
+	fields := map[string]any{}
+	label := map[string]any{}
+	problems := []string{}
+	for _, part := range strings.Split(spec, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		if !ok || k == "" {
+			problems = append(problems, fmt.Sprintf("ui.%s: expected key=value, got %q", col, part))
+			continue
+		}
+		if lang, isLabel := strings.CutPrefix(k, "label."); isLabel {
+			label[lang] = v
+			continue
+		}
+		fields[k] = v
+	}
+	if len(label) > 0 {
+		fields["label"] = label
+	}
+	ui, more := decodeColumnUI(col, fields)
+	return col, ui, append(problems, more...)
+}
+
+// decodeColumnUI validates one column's hints from a parsed YAML/JSON mapping (or the txt form).
+func decodeColumnUI(col string, v any) (ColumnUI, []string) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return ColumnUI{}, []string{fmt.Sprintf("ui.%s: must be a mapping", col)}
+	}
+	ui := ColumnUI{}
+	problems := []string{}
+	bad := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("ui.%s.", col)+fmt.Sprintf(format, args...))
+	}
+	for _, key := range sortedDocKeys(m) {
+		val := m[key]
+		switch key {
+		case "label":
+			labels, ok := val.(map[string]any)
+			if !ok {
+				bad("label: must be a mapping of language to text (id, en, ...)")
+				continue
+			}
+			ui.Label = map[string]string{}
+			for _, lang := range sortedDocKeys(labels) {
+				s, ok := docString(labels[lang])
+				if !langRE.MatchString(lang) || !ok || s == "" {
+					bad("label.%s: must be a non-empty string keyed by a language code", lang)
+					continue
+				}
+				ui.Label[lang] = s
+			}
+		case "widget":
+			s, ok := docString(val)
+			if !ok || !uiWidgets[strings.ToLower(s)] {
+				bad("widget: unknown widget %v (allowed: %s)", val, strings.Join(sortedWidgets(), ", "))
+				continue
+			}
+			ui.Widget = strings.ToLower(s)
+		case "lookup":
+			s, _ := docString(val)
+			m := lookupRE.FindStringSubmatch(strings.ToLower(s))
+			if m == nil {
+				bad("lookup: expected table.value_column[:label_column], got %v", val)
+				continue
+			}
+			ui.Lookup = &Lookup{Table: m[1], Value: m[2], Label: m[3]}
+		case "order":
+			s, _ := docString(val)
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				bad("order: must be a non-negative integer")
+				continue
+			}
+			ui.Order = n
+		default:
+			bad("unknown key %q", key)
+		}
+	}
+	if ui.Widget == "lookup" && ui.Lookup == nil {
+		bad("widget: lookup requires lookup: table.value_column")
+	}
+	return ui, problems
+}
+
+func sortedWidgets() []string {
+	out := make([]string, 0, len(uiWidgets))
+	for w := range uiWidgets {
+		out = append(out, w)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,113 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tabular import/export for the CRUD gateway.
+//
+// Two formats are supported without third-party libraries: CSV (encoding/csv) and XLSX
+// (Office Open XML read and written with archive/zip + encoding/xml). Only cell values are
+// read; formulas use their cached result and styles only matter for recognising dates.
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unsupported file format (expected .csv or .xlsx)")
+	ErrTooManyRows   = errors.New("too many rows")
+)
+
+// ReadOptions limits what Read returns.
+type ReadOptions struct {
+	Sheet   string // XLSX sheet name; empty = first sheet
+	MaxRows int    // 0 = unlimited; otherwise ErrTooManyRows past this many rows (header included)
+}
+
+// DetectFormat picks the format from the file name, falling back to the first bytes
+// (XLSX is a zip archive).
+func DetectFormat(filename string, head []byte) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".txt":
+		return CSV, nil
+	case ".xlsx":
+		return XLSX, nil
+	}
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		return XLSX, nil
+	}
+	if len(head) > 0 && !bytes.ContainsRune(head, 0) {
+		return CSV, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Read returns all rows of the file as strings; rows are not padded to a common width.
+func Read(r io.ReaderAt, size int64, format Format, opt ReadOptions) ([][]string, error) {
+	switch format {
+	case CSV:
+		return readCSV(io.NewSectionReader(r, 0, size), opt)
+	case XLSX:
+		return readXLSX(r, size, opt)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// readCSV accepts a UTF-8 BOM and comma, semicolon or tab separators (spreadsheet exports in
+// id-ID locales use ";"). The separator is guessed from the first line.
+func readCSV(r io.Reader, opt ReadOptions) ([][]string, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(raw) {
+		return nil, fmt.Errorf("csv: file is not UTF-8 text")
+	}
+
+	cr := csv.NewReader(bytes.NewReader(raw))
+	cr.Comma = guessSeparator(raw)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	rows := [][]string{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv: %w", err)
+		}
+		if opt.MaxRows > 0 && len(rows) >= opt.MaxRows {
+			return nil, ErrTooManyRows
+		}
+		rows = append(rows, rec)
+	}
+}
+
+func guessSeparator(raw []byte) rune {
+	line := raw
+	if i := bytes.IndexByte(raw, '\n'); i >= 0 {
+		line = raw[:i]
+	}
+	best, bestN := ',', bytes.Count(line, []byte{','})
+	for _, sep := range []rune{';', '\t'} {
+		if n := bytes.Count(line, []byte(string(sep))); n > bestN {
+			best, bestN = sep, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,83 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadCSVSeparatorAndBOM(t *testing.T) {
+	raw := []byte("\xef\xbb\xbfnama;tgl_lahir\n\"Budi; S\";1990-01-02\nSiti;\n")
+	rows, err := Read(bytes.NewReader(raw), int64(len(raw)), CSV, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"nama", "tgl_lahir"}, {"Budi; S", "1990-01-02"}, {"Siti", ""}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+
+	if _, err := Read(bytes.NewReader(raw), int64(len(raw)), CSV, ReadOptions{MaxRows: 2}); !errors.Is(err, ErrTooManyRows) {
+		t.Fatalf("MaxRows: err = %v", err)
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Info" sheetId="1" r:id="rId2"/><sheet name="Pasien" sheetId="2" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="worksheet" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>nama</t></si><si><t>tgl_lahir</t></si><si><r><t>Bu</t></r><r><t>di</t></r><rPh><t>x</t></rPh></si></sst>`,
+		"xl/styles.xml": `<styleSheet><numFmts><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/><numFmt numFmtId="165" formatCode="&quot;Rp&quot;#,##0"/></numFmts>
+<cellXfs><xf numFmtId="0"/><xf numFmtId="164"/><xf numFmtId="165"/><xf numFmtId="22"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>hp</t></is></c></row>
+<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3" s="1"><v>32874</v></c><c r="C3" s="2"><v>150000</v></c><c r="D3"><v>6.2812345678E+12</v></c></row>
+<row r="4"><c r="B4" s="3"><v>45000.5</v></c><c r="C4" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>info</t></is></c></row></sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	raw := buf.Bytes()
+
+	if f, err := DetectFormat("upload.bin", raw[:8]); err != nil || f != XLSX {
+		t.Fatalf("DetectFormat = %q, %v", f, err)
+	}
+
+	rows, err := Read(bytes.NewReader(raw), int64(len(raw)), XLSX, ReadOptions{Sheet: "pasien"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"nama", "tgl_lahir", "", "hp"},
+		nil,
+		{"Budi", "1990-01-01", "150000", "6281234567800"},
+		{"", "2023-03-15 12:00:00", "true"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+
+	rows, err = Read(bytes.NewReader(raw), int64(len(raw)), XLSX, ReadOptions{})
+	if err != nil || len(rows) != 1 || rows[0][0] != "info" {
+		t.Fatalf("first sheet = %q, %v", rows, err)
+	}
+	if _, err := Read(bytes.NewReader(raw), int64(len(raw)), XLSX, ReadOptions{Sheet: "pasien", MaxRows: 2}); !errors.Is(err, ErrTooManyRows) {
+		t.Fatalf("MaxRows: err = %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind tells a Writer how to render a column's values.
+type Kind string
+
+const (
+	KindText     Kind = "text"
+	KindNumber   Kind = "number"   // int64, float64, or an exact decimal as string / fmt.Stringer
+	KindBool     Kind = "bool"     // bool
+	KindDate     Kind = "date"     // time.Time, date part only
+	KindDateTime Kind = "datetime" // time.Time, wall clock as given (convert the zone first)
+	KindTime     Kind = "time"     // time.Time or "15:04:05" text
+)
+
+// Column is one exported column: its header text and how to render its values.
+type Column struct {
+	Title string
+	Kind  Kind
+}
+
+// Writer streams rows into a CSV or XLSX file. Call Header once, Row per row, then Close,
+// which finishes the file (it does not close the underlying io.Writer).
+type Writer interface {
+	Header(cols []Column) error
+	Row(values []any) error
+	Close() error
+}
+
+// WriteOptions tunes the output; the zero value is fine.
+type WriteOptions struct {
+	Sheet     string // XLSX sheet name (default "Sheet1")
+	Delimiter rune   // CSV separator (default ',')
+}
+
+// NewWriter returns a streaming writer for format. Nothing is buffered beyond one row
+// (plus the XLSX package parts, which are small and fixed).
+func NewWriter(w io.Writer, format Format, opt WriteOptions) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w, opt)
+	case XLSX:
+		return newXLSXWriter(w, opt)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// ContentType is the MIME type of a format's files.
+func ContentType(format Format) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type csvWriter struct {
+	buf  *bufio.Writer
+	cw   *csv.Writer
+	cols []Column
+	rec  []string
+}
+
+// newCSVWriter starts with a UTF-8 BOM so spreadsheet apps don't guess a legacy code page.
+func newCSVWriter(w io.Writer, opt WriteOptions) (*csvWriter, error) {
+	buf := bufio.NewWriter(w)
+	if _, err := buf.WriteString("\xef\xbb\xbf"); err != nil {
+		return nil, err
+	}
+	cw := csv.NewWriter(buf)
+	if opt.Delimiter != 0 {
+		cw.Comma = opt.Delimiter
+	}
+	cw.UseCRLF = true
+	return &csvWriter{buf: buf, cw: cw}, nil
+}
+
+func (c *csvWriter) Header(cols []Column) error {
+	c.cols = cols
+	c.rec = make([]string, len(cols))
+	for i, col := range cols {
+		c.rec[i] = csvSafe(col.Title)
+	}
+	return c.cw.Write(c.rec)
+}
+
+func (c *csvWriter) Row(values []any) error {
+	for i := range c.rec {
+		c.rec[i] = ""
+		if i < len(values) {
+			c.rec[i] = csvSafe(textOf(c.cols[i].Kind, values[i]))
+		}
+	}
+	if err := c.cw.Write(c.rec); err != nil {
+		return err
+	}
+	// csv.Writer sits on our bufio.Writer; flushing it only moves bytes into buf.
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.cw.Flush()
+	if err := c.cw.Error(); err != nil {
+		return err
+	}
+	return c.buf.Flush()
+}
+
+// csvSafe defuses CSV formula injection: spreadsheet apps evaluate a cell starting with
+// = + - @ (or tab / CR before one), e.g. =HYPERLINK(...) stored by a client. Such text gets a
+// leading ' so it is shown as text. Plain numbers like -12.5 are left alone. XLSX cells are
+// typed, so only CSV needs this.
+func csvSafe(s string) string {
+	if s == "" || !strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return s
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "eEnNiIxX_") {
+		return s
+	}
+	return "'" + s
+}
+
+// textOf renders a value as cell text (CSV, and XLSX cells that cannot be typed).
+func textOf(kind Kind, v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case int:
+		return strconv.Itoa(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case time.Time:
+		switch kind {
+		case KindDate:
+			return t.Format("2006-01-02")
+		case KindTime:
+			return t.Format("15:04:05")
+		default:
+			return t.Format("2006-01-02 15:04:05")
+		}
+	case fmt.Stringer:
+		return t.String()
+	case []byte:
+		return string(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// numberText returns v as a plain decimal literal for a numeric cell; false when it is not one
+// or has more significant digits than a spreadsheet keeps (15), e.g. a 16-digit NIK.
+func numberText(v any) (string, bool) {
+	var s string
+	switch t := v.(type) {
+	case int64:
+		s = strconv.FormatInt(t, 10)
+	case int:
+		s = strconv.Itoa(t)
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(t, 'g', -1, 64), true
+	case string:
+		s = t
+	case fmt.Stringer:
+		s = t.String()
+	default:
+		return "", false
+	}
+	s = strings.TrimSpace(s)
+	if _, err := strconv.ParseFloat(s, 64); err != nil || strings.ContainsAny(s, "eEnNiI") {
+		return "", false
+	}
+	digits := strings.TrimLeft(strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s), "0")
+	if strings.Contains(s, ".") {
+		digits = strings.TrimRight(digits, "0")
+	}
+	if len(digits) > 15 {
+		return "", false
+	}
+	return s, true
+}
@@ -0,0 +1,142 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type decimal string
+
+func (d decimal) String() string { return string(d) }
+
+func writeSample(t *testing.T, format Format) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, WriteOptions{Sheet: "Pasien [2026]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols := []Column{
+		{"Nama", KindText}, {"NIK", KindNumber}, {"Saldo", KindNumber}, {"Aktif", KindBool},
+		{"Tgl Lahir", KindDate}, {"Dibuat", KindDateTime}, {"Jam", KindTime},
+	}
+	if err := w.Header(cols); err != nil {
+		t.Fatal(err)
+	}
+	wita := time.FixedZone("WITA", 8*3600)
+	rows := [][]any{
+		{"Budi <&> \"S\"", int64(3201234567890123), decimal("150000.50"), true,
+			time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 18, 10, 30, 0, 0, wita), "07:45:00"},
+		{"Siti", nil, int64(42), false, nil, nil, nil},
+	}
+	for _, row := range rows {
+		if err := w.Row(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	want := [][]string{
+		{"Nama", "NIK", "Saldo", "Aktif", "Tgl Lahir", "Dibuat", "Jam"},
+		{"Budi <&> \"S\"", "3201234567890123", "150000.50", "true", "1990-01-01", "2026-03-18 10:30:00", "07:45:00"},
+		{"Siti", "", "42", "false"},
+	}
+
+	raw := writeSample(t, XLSX)
+	got, err := Read(bytes.NewReader(raw), int64(len(raw)), XLSX, ReadOptions{Sheet: "Pasien 2026"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("xlsx rows = %q, want %q", got, want)
+	}
+
+	raw = writeSample(t, CSV)
+	if !bytes.HasPrefix(raw, []byte("\xef\xbb\xbfNama,NIK,")) {
+		t.Fatalf("csv should start with a BOM and the header: %q", raw[:20])
+	}
+	got, err = Read(bytes.NewReader(raw), int64(len(raw)), CSV, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want[2] = append(want[2], "", "", "")
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("csv rows = %q, want %q", got, want)
+	}
+}
+
+func TestNumberText(t *testing.T) {
+	for _, tc := range []struct {
+		in   any
+		want string
+		ok   bool
+	}{
+		{int64(42), "42", true},
+		{decimal("0.000123"), "0.000123", true},
+		{"123456789012345", "123456789012345", true},
+		{"1234567890123456", "", false}, // 16 significant digits
+		{"12.50", "12.50", true},
+		{"NaN", "", false},
+		{"abc", "", false},
+	} {
+		got, ok := numberText(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("numberText(%v) = %q, %v; want %q, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+	if got := columnName(27); got != "AB" {
+		t.Errorf("columnName(27) = %q", got)
+	}
+}
+
+func TestCSVFormulaInjection(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, CSV, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Header([]Column{{"=Nama", KindText}, {"Saldo", KindNumber}}); err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]any{
+		{`=HYPERLINK("http://evil.example","klik")`, int64(-5)},
+		{"+62812", decimal("-12.50")},
+		{"-2+3", "-1e3"},
+		{"@SUM(A1:A2)", nil},
+		{"\t=1+1", nil},
+		{"\r=1+1", nil},
+		{"Budi - Sari", "1e3"},
+	}
+	for _, row := range rows {
+		if err := w.Row(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()), CSV, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"'=Nama", "Saldo"},
+		{`'=HYPERLINK("http://evil.example","klik")`, "-5"},
+		{"+62812", "-12.50"}, // a number to a spreadsheet, not a formula
+		{"'-2+3", "'-1e3"},
+		{"'@SUM(A1:A2)", ""},
+		{"'\t=1+1", ""},
+		{"'=1+1", ""}, // csv.Writer with UseCRLF drops a lone CR
+		{"Budi - Sari", "1e3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("csv rows = %q, want %q", got, want)
+	}
+}
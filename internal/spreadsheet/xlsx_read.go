@@ -0,0 +1,391 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxXMLPart bounds how much of one zip member we inflate (zip bombs).
+const maxXMLPart = 256 << 20
+
+func readXLSX(r io.ReaderAt, size int64, opt ReadOptions) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %w", err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	sheetPath, date1904, err := findSheet(files, opt.Sheet)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, err
+	}
+	dateStyles, err := readDateStyles(files["xl/styles.xml"])
+	if err != nil {
+		return nil, err
+	}
+	sheet, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("xlsx: missing %s", sheetPath)
+	}
+	return readSheet(sheet, shared, dateStyles, date1904, opt.MaxRows)
+}
+
+func openPart(f *zip.File) (*xml.Decoder, io.Closer, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("xlsx: %s: %w", f.Name, err)
+	}
+	return xml.NewDecoder(io.LimitReader(rc, maxXMLPart)), rc, nil
+}
+
+// findSheet resolves the sheet's part name through workbook.xml and its relationships.
+func findSheet(files map[string]*zip.File, name string) (string, bool, error) {
+	wb, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", false, fmt.Errorf("xlsx: missing xl/workbook.xml")
+	}
+	var doc struct {
+		Pr struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(wb, &doc); err != nil {
+		return "", false, err
+	}
+	date1904 := doc.Pr.Date1904 == "1" || doc.Pr.Date1904 == "true"
+	if len(doc.Sheets) == 0 {
+		return "", false, fmt.Errorf("xlsx: workbook has no sheets")
+	}
+	rid := doc.Sheets[0].RID
+	if name != "" {
+		rid = ""
+		for _, s := range doc.Sheets {
+			if strings.EqualFold(s.Name, name) {
+				rid = s.RID
+			}
+		}
+		if rid == "" {
+			return "", false, fmt.Errorf("xlsx: sheet %q not found", name)
+		}
+	}
+
+	rels, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return "", false, fmt.Errorf("xlsx: missing xl/_rels/workbook.xml.rels")
+	}
+	var rdoc struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(rels, &rdoc); err != nil {
+		return "", false, err
+	}
+	for _, rel := range rdoc.Rels {
+		if rel.ID != rid {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), date1904, nil
+		}
+		return path.Join("xl", rel.Target), date1904, nil
+	}
+	return "", false, fmt.Errorf("xlsx: sheet relationship %q not found", rid)
+}
+
+func decodePart(f *zip.File, v any) error {
+	dec, rc, err := openPart(f)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("xlsx: %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// readSharedStrings concatenates every <t> of each <si>, skipping phonetic runs (<rPh>).
+func readSharedStrings(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+	dec, rc, err := openPart(f)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	out := []string{}
+	var (
+		cur    strings.Builder
+		inSI   bool
+		inText bool
+		inPhon int
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("xlsx: %s: %w", f.Name, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				inSI = true
+				cur.Reset()
+			case "rPh":
+				inPhon++
+			case "t":
+				inText = inSI && inPhon == 0
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				inSI = false
+				out = append(out, cur.String())
+			case "rPh":
+				inPhon--
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				cur.Write(t)
+			}
+		}
+	}
+}
+
+// readDateStyles returns which cellXfs indexes use a date/time number format.
+func readDateStyles(f *zip.File) (map[int]bool, error) {
+	out := map[int]bool{}
+	if f == nil {
+		return out, nil
+	}
+	var doc struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		Xfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := decodePart(f, &doc); err != nil {
+		return nil, err
+	}
+	custom := map[int]bool{}
+	for _, nf := range doc.NumFmts {
+		custom[nf.ID] = isDateFormat(nf.Code)
+	}
+	for i, xf := range doc.Xfs {
+		if isDate, ok := custom[xf.NumFmtID]; ok {
+			out[i] = isDate
+			continue
+		}
+		out[i] = builtinDateFormat(xf.NumFmtID)
+	}
+	return out, nil
+}
+
+func builtinDateFormat(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+}
+
+// isDateFormat reports whether a custom number format renders dates or times: it has a
+// y/m/d/h/s token outside quoted text, escapes and [brackets] (colors, locales, elapsed time).
+func isDateFormat(code string) bool {
+	inQuote, inBracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inQuote:
+			inQuote = c != '"'
+		case inBracket:
+			inBracket = c != ']'
+		case c == '"':
+			inQuote = true
+		case c == '[':
+			inBracket = true
+		case c == '\\' || c == '_' || c == '*':
+			i++
+		case strings.IndexByte("ymdhsYMDHS", c) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+func readSheet(f *zip.File, shared []string, dateStyles map[int]bool, date1904 bool, maxRows int) ([][]string, error) {
+	dec, rc, err := openPart(f)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	rows := [][]string{}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("xlsx: %s: %w", f.Name, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R      string `xml:"r,attr"`
+				T      string `xml:"t,attr"`
+				S      int    `xml:"s,attr"`
+				V      string `xml:"v"`
+				Inline struct {
+					T  string `xml:"t"`
+					Rs []struct {
+						T string `xml:"t"`
+					} `xml:"r"`
+				} `xml:"is"`
+			} `xml:"c"`
+		}
+		if err := dec.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("xlsx: %s: %w", f.Name, err)
+		}
+
+		// Rows and cells may be sparse; r attributes place them.
+		idx := len(rows)
+		if row.R > 0 {
+			idx = row.R - 1
+		}
+		if idx < len(rows) {
+			return nil, fmt.Errorf("xlsx: %s: rows out of order", f.Name)
+		}
+		if maxRows > 0 && idx >= maxRows {
+			return nil, ErrTooManyRows
+		}
+		for len(rows) < idx {
+			rows = append(rows, nil)
+		}
+
+		cells := []string{}
+		for _, c := range row.Cells {
+			col := len(cells)
+			if c.R != "" {
+				if col, err = columnIndex(c.R); err != nil {
+					return nil, fmt.Errorf("xlsx: %s: %w", f.Name, err)
+				}
+			}
+			if col < len(cells) {
+				return nil, fmt.Errorf("xlsx: %s: cells out of order in row %d", f.Name, idx+1)
+			}
+			for len(cells) < col {
+				cells = append(cells, "")
+			}
+			var v string
+			switch c.T {
+			case "s":
+				i, err := strconv.Atoi(strings.TrimSpace(c.V))
+				if err != nil || i < 0 || i >= len(shared) {
+					return nil, fmt.Errorf("xlsx: %s: cell %s: bad shared string index", f.Name, c.R)
+				}
+				v = shared[i]
+			case "inlineStr":
+				v = c.Inline.T
+				for _, r := range c.Inline.Rs {
+					v += r.T
+				}
+			case "b":
+				v = map[string]string{"1": "true", "0": "false"}[strings.TrimSpace(c.V)]
+			case "e":
+				v = ""
+			case "n", "":
+				v = formatNumber(strings.TrimSpace(c.V), dateStyles[c.S], date1904)
+			default: // "str" (formula text), "d" (ISO date)
+				v = c.V
+			}
+			cells = append(cells, v)
+		}
+		rows = append(rows, cells)
+	}
+}
+
+// columnIndex converts the letters of a cell reference ("AB12") to a 0-based column.
+func columnIndex(ref string) (int, error) {
+	n := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		n = n*26 + int(ref[i]-'A'+1)
+		if n > 16384 {
+			return 0, fmt.Errorf("bad cell reference %q", ref)
+		}
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("bad cell reference %q", ref)
+	}
+	return n - 1, nil
+}
+
+// formatNumber renders a numeric cell the way a user typed it: plain decimals (no exponent)
+// and, for date-styled cells, "2006-01-02", "15:04:05" or "2006-01-02 15:04:05".
+func formatNumber(raw string, isDate, date1904 bool) string {
+	if raw == "" {
+		return ""
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return raw
+	}
+	if !isDate {
+		if strings.ContainsAny(raw, "eE") {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		return raw
+	}
+	t := serialToTime(f, date1904)
+	switch {
+	case f < 1:
+		return t.Format("15:04:05")
+	case t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0:
+		return t.Format("2006-01-02")
+	default:
+		return t.Format("2006-01-02 15:04:05")
+	}
+}
+
+// serialToTime converts a spreadsheet serial date. The 1900 system counts from 1899-12-30
+// (absorbing Excel's phantom 1900-02-29 for every date after February 1900).
+func serialToTime(f float64, date1904 bool) time.Time {
+	base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		base = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(f)
+	secs := math.Round((f - days) * 86400)
+	return base.AddDate(0, 0, int(days)).Add(time.Duration(secs) * time.Second)
+}
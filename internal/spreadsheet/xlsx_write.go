@@ -0,0 +1,250 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The workbook has one sheet, written last and streamed row by row with inline strings
+// (no shared-string table to buffer). Styles: 1 date, 2 datetime, 3 time, 4 bold header.
+const (
+	styleDate     = 1
+	styleDateTime = 2
+	styleTime     = 3
+	styleHeader   = 4
+
+	maxCellText = 32767 // spreadsheet limit per cell
+)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="3"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm:ss"/><numFmt numFmtId="166" formatCode="hh:mm:ss"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="5"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`
+
+type xlsxWriter struct {
+	zw      *zip.Writer
+	sheet   *bufio.Writer
+	cols    []Column
+	refs    []string // column letters
+	row     int
+	started bool
+}
+
+func newXLSXWriter(w io.Writer, opt WriteOptions) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sheetName(opt.Sheet)))
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	for _, part := range []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		fw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(fw, part.body); err != nil {
+			return nil, err
+		}
+	}
+	fw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zw: zw, sheet: bufio.NewWriter(fw)}, nil
+}
+
+// sheetName drops the characters sheet names may not contain and caps the length at 31.
+func sheetName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+	if s == "" {
+		return "Sheet1"
+	}
+	if utf8.RuneCountInString(s) > 31 {
+		s = string([]rune(s)[:31])
+	}
+	return s
+}
+
+func (x *xlsxWriter) start() {
+	if x.started {
+		return
+	}
+	x.started = true
+	// Freeze the header row.
+	x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews><sheetData>`)
+}
+
+func (x *xlsxWriter) Header(cols []Column) error {
+	x.start()
+	x.cols = cols
+	x.refs = make([]string, len(cols))
+	for i := range cols {
+		x.refs[i] = columnName(i)
+	}
+	x.openRow()
+	for i, col := range cols {
+		x.textCell(i, col.Title, styleHeader)
+	}
+	return x.closeRow()
+}
+
+func (x *xlsxWriter) Row(values []any) error {
+	x.start()
+	x.openRow()
+	for i := range x.cols {
+		if i >= len(values) || values[i] == nil {
+			continue
+		}
+		x.cell(i, values[i])
+	}
+	return x.closeRow()
+}
+
+func (x *xlsxWriter) Close() error {
+	x.start()
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+func (x *xlsxWriter) openRow() {
+	x.row++
+	x.sheet.WriteString(`<row r="` + strconv.Itoa(x.row) + `">`)
+}
+
+func (x *xlsxWriter) closeRow() error {
+	x.sheet.WriteString(`</row>`)
+	if x.sheet.Buffered() >= 32<<10 {
+		return x.sheet.Flush()
+	}
+	return nil
+}
+
+func (x *xlsxWriter) ref(i int) string {
+	return x.refs[i] + strconv.Itoa(x.row)
+}
+
+func (x *xlsxWriter) cell(i int, v any) {
+	kind := x.cols[i].Kind
+	switch kind {
+	case KindNumber:
+		if s, ok := numberText(v); ok {
+			x.valueCell(i, "", s, 0)
+			return
+		}
+	case KindBool:
+		if b, ok := v.(bool); ok {
+			x.valueCell(i, "b", map[bool]string{true: "1", false: "0"}[b], 0)
+			return
+		}
+	case KindDate, KindDateTime:
+		if t, ok := v.(time.Time); ok && t.Year() >= 1900 {
+			style := styleDateTime
+			if kind == KindDate {
+				style = styleDate
+				t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+			}
+			x.valueCell(i, "", strconv.FormatFloat(serialDate(t), 'f', -1, 64), style)
+			return
+		}
+	case KindTime:
+		if f, ok := dayFraction(v); ok {
+			x.valueCell(i, "", strconv.FormatFloat(f, 'f', -1, 64), styleTime)
+			return
+		}
+	}
+	x.textCell(i, textOf(kind, v), 0)
+}
+
+func (x *xlsxWriter) valueCell(i int, typ, value string, style int) {
+	x.sheet.WriteString(`<c r="` + x.ref(i) + `"`)
+	if typ != "" {
+		x.sheet.WriteString(` t="` + typ + `"`)
+	}
+	if style != 0 {
+		x.sheet.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	x.sheet.WriteString(`><v>` + value + `</v></c>`)
+}
+
+func (x *xlsxWriter) textCell(i int, s string, style int) {
+	if s == "" {
+		return
+	}
+	if len(s) > maxCellText {
+		s = strings.ToValidUTF8(s[:maxCellText], "")
+	}
+	x.sheet.WriteString(`<c r="` + x.ref(i) + `" t="inlineStr"`)
+	if style != 0 {
+		x.sheet.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	x.sheet.WriteString(`><is><t xml:space="preserve">`)
+	xml.EscapeText(x.sheet, []byte(s))
+	x.sheet.WriteString(`</t></is></c>`)
+}
+
+// columnName converts a 0-based column index to letters (0 -> A, 27 -> AB).
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// serialDate is the inverse of serialToTime (1900 system) for the wall clock of t.
+func serialDate(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return wall.Sub(base).Seconds() / 86400
+}
+
+// dayFraction converts a time of day (time.Time or "15:04[:05[.ffffff]]") to a fraction of a day.
+func dayFraction(v any) (float64, bool) {
+	var t time.Time
+	switch tv := v.(type) {
+	case time.Time:
+		t = tv
+	case string:
+		var err error
+		for _, layout := range []string{"15:04:05.999999999", "15:04"} {
+			if t, err = time.Parse(layout, strings.TrimSpace(tv)); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	secs := float64(t.Hour()*3600+t.Minute()*60+t.Second()) + float64(t.Nanosecond())/1e9
+	return secs / 86400, true
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DownloadPrefix is the public route that serves signed downloads: /files/{key}?expires=&signature=.
+// It sits outside /v1 because browsers load it without the Authorization header (img src, links).
+const DownloadPrefix = "/files/"
+
+// Signer issues and checks short-lived download URLs. The signature covers the key and the
+// expiry, so a URL cannot be reused for another file or extended.
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewSigner(secret string, ttl time.Duration) *Signer {
+	return &Signer{secret: []byte("file-download:" + secret), ttl: ttl}
+}
+
+// URL returns the signed download path for key and its expiry time.
+func (s *Signer) URL(key string, now time.Time) (string, time.Time) {
+	exp := now.Add(s.ttl).Truncate(time.Second)
+	expires := strconv.FormatInt(exp.Unix(), 10)
+	q := url.Values{"expires": {expires}, "signature": {s.signature(key, expires)}}
+	return DownloadPrefix + key + "?" + q.Encode(), exp
+}
+
+// Verify checks a signature for key; expired or tampered URLs fail.
+func (s *Signer) Verify(key, expires, signature string, now time.Time) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.signature(key, expires)))
+}
+
+func (s *Signer) signature(key, expires string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
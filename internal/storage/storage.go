@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File storage for CRUD attachments.
+//
+// Objects are addressed by a slash-separated key chosen by the gateway
+// ("12/pasien/P001/gambar/20260318-9f2c...jpg"); keys never come from clients verbatim.
+// Downloads go through the gateway with short-lived signed URLs (see Signer), so the
+// backend itself never has to be public.
+
+// ErrNotFound is returned by Backend.Get for a missing object.
+var ErrNotFound = errors.New("file not found")
+
+// Backend stores file contents.
+type Backend interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Config is the FILE_* / S3_* environment.
+type Config struct {
+	Driver     string        // local|s3
+	Dir        string        // local: root directory
+	MaxSize    int64         // bytes, upper bound for every column
+	URLTTL     time.Duration // signed download URL lifetime
+	SigningKey string        // FILE_SIGNING_KEY, else JWT_SECRET
+	S3         S3Config
+}
+
+// ConfigFromEnv reads the file storage environment with its defaults.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Driver:     strings.ToLower(envOr("FILE_STORAGE", "local")),
+		Dir:        envOr("FILE_STORAGE_DIR", "storage/files"),
+		SigningKey: envOr("FILE_SIGNING_KEY", strings.TrimSpace(os.Getenv("JWT_SECRET"))),
+		S3: S3Config{
+			Endpoint:  strings.TrimRight(envOr("S3_ENDPOINT", ""), "/"),
+			Region:    envOr("S3_REGION", "us-east-1"),
+			Bucket:    envOr("S3_BUCKET", ""),
+			AccessKey: envOr("S3_ACCESS_KEY_ID", ""),
+			SecretKey: envOr("S3_SECRET_ACCESS_KEY", ""),
+			PathStyle: !strings.EqualFold(envOr("S3_PATH_STYLE", "true"), "false"),
+		},
+	}
+	maxMB, err := strconv.ParseInt(envOr("FILE_MAX_SIZE_MB", "10"), 10, 64)
+	if err != nil || maxMB <= 0 {
+		return Config{}, fmt.Errorf("FILE_MAX_SIZE_MB must be a positive integer")
+	}
+	cfg.MaxSize = maxMB << 20
+	ttl, err := strconv.ParseInt(envOr("FILE_URL_TTL", "300"), 10, 64)
+	if err != nil || ttl <= 0 {
+		return Config{}, fmt.Errorf("FILE_URL_TTL must be a positive number of seconds")
+	}
+	cfg.URLTTL = time.Duration(ttl) * time.Second
+	if cfg.SigningKey == "" {
+		return Config{}, fmt.Errorf("FILE_SIGNING_KEY (or JWT_SECRET) is required")
+	}
+	return cfg, nil
+}
+
+// New opens the configured backend.
+func New(cfg Config) (Backend, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocal(cfg.Dir)
+	case "s3":
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("FILE_STORAGE not supported: %q (local|s3)", cfg.Driver)
+	}
+}
+
+func envOr(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+// ValidKey reports whether key is a relative, slash-separated path without empty, "." or ".."
+// segments, so it can never escape the storage root.
+func ValidKey(key string) bool {
+	if key == "" || len(key) > 512 || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return false
+		}
+		for _, c := range seg {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Local stores files under a directory (default storage/files; mount a volume in Docker).
+type Local struct {
+	root string
+}
+
+func NewLocal(dir string) (*Local, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("FILE_STORAGE_DIR is empty")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &Local{root: dir}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid file key %q", key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temp file in the target directory and renames it, so readers never see a
+// partial file.
+func (l *Local) Put(_ context.Context, key string, body io.Reader, size int64, _ string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	n, err := io.Copy(tmp, body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && n != size {
+		return fmt.Errorf("short write: %d of %d bytes", n, size)
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
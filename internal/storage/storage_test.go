@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"12/pasien/P001/gambar/20260318-ab12.jpg": true,
+		"global/dokter/D_1/foto/x.png":            true,
+		"":                                        false,
+		"/etc/passwd":                             false,
+		"12/../13/pasien/x.jpg":                   false,
+		"12//x.jpg":                               false,
+		"12/pasien/a b.jpg":                       false,
+		`12\pasien\x.jpg`:                         false,
+	} {
+		if got := ValidKey(key); got != want {
+			t.Errorf("ValidKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestLocalBackend(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "12/pasien/P001/gambar/a.txt"
+	if err := l.Put(ctx, key, strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	rc, err := l.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	b, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(b) != "hello" {
+		t.Fatalf("Get = %q", b)
+	}
+	if err := l.Put(ctx, "12/x.txt", strings.NewReader("abc"), 5, ""); err == nil {
+		t.Fatalf("expected short write error")
+	}
+	if err := l.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := l.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after delete: %v", err)
+	}
+	if err := l.Put(ctx, "../escape.txt", strings.NewReader("x"), 1, ""); err == nil {
+		t.Fatalf("expected invalid key error")
+	}
+}
+
+func TestSigner(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	s := NewSigner("secret", 5*time.Minute)
+	raw, exp := s.URL("12/pasien/P001/gambar/a.jpg", now)
+	if !exp.Equal(now.Add(5 * time.Minute)) {
+		t.Fatalf("expires %v", exp)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := strings.TrimPrefix(u.Path, DownloadPrefix)
+	q := u.Query()
+	if !s.Verify(key, q.Get("expires"), q.Get("signature"), now) {
+		t.Fatalf("fresh URL rejected: %s", raw)
+	}
+	if s.Verify("12/pasien/P002/gambar/a.jpg", q.Get("expires"), q.Get("signature"), now) {
+		t.Fatalf("signature accepted for another key")
+	}
+	if s.Verify(key, q.Get("expires"), q.Get("signature"), now.Add(6*time.Minute)) {
+		t.Fatalf("expired URL accepted")
+	}
+	if NewSigner("other", 5*time.Minute).Verify(key, q.Get("expires"), q.Get("signature"), now) {
+		t.Fatalf("signature accepted with another secret")
+	}
+}
@@ -0,0 +1,341 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts  = 8
+	DefaultPollInterval = 5 * time.Second
+	DefaultTimeout      = 10 * time.Second
+	DefaultBatchSize    = 50
+
+	backoffBase = 30 * time.Second
+	backoffMax  = time.Hour
+)
+
+// Signature headers sent with every delivery. The signature is
+// hex(HMAC-SHA256(secret, "<timestamp>.<body>")) prefixed with "sha256=".
+const (
+	HeaderEventID   = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns the signature header value for body sent at timestamp (unix seconds).
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before the next attempt after `attempts` failed attempts:
+// 30s, 1m, 2m, 4m, ... capped at 1h.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	d := backoffBase
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= backoffMax {
+			return backoffMax
+		}
+	}
+	return d
+}
+
+// Dispatcher delivers pending outbox rows. Several gateway instances can run one each:
+// rows are claimed with FOR UPDATE SKIP LOCKED and a lease (locked_until).
+type Dispatcher struct {
+	store        *Store
+	client       *http.Client
+	allowed      []*net.IPNet // private networks deliveries may reach (see AllowNetworks)
+	MaxAttempts  int
+	PollInterval time.Duration
+	BatchSize    int
+	Now          func() time.Time
+}
+
+func NewDispatcher(store *Store, timeout time.Duration) *Dispatcher {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	d := &Dispatcher{
+		store:        store,
+		MaxAttempts:  DefaultMaxAttempts,
+		PollInterval: DefaultPollInterval,
+		BatchSize:    DefaultBatchSize,
+		Now:          time.Now,
+	}
+	dialer := &net.Dialer{Timeout: timeout, Control: d.checkDial}
+	d.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// No proxy: the address check must see the real destination.
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// A redirect is not followed (it could point anywhere); the 3xx counts as a failure.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return d
+}
+
+// AllowNetworks lets deliveries reach the given private networks (comma-separated CIDRs or
+// IPs), e.g. plugin services on the internal network. Everything else that is not a public
+// unicast address (loopback, RFC 1918, link-local incl. 169.254.169.254, ...) is refused.
+func (d *Dispatcher) AllowNetworks(raw string) error {
+	nets := []*net.IPNet{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				return fmt.Errorf("webhook: invalid network %q", part)
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			bits := 8 * len(ip)
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(part)
+		if err != nil {
+			return fmt.Errorf("webhook: invalid network %q", part)
+		}
+		nets = append(nets, n)
+	}
+	d.allowed = nets
+	return nil
+}
+
+// checkDial runs after name resolution, so it sees the address actually dialled (no DNS
+// rebinding between a check and the connection).
+func (d *Dispatcher) checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("webhook: refusing to dial %s", address)
+	}
+	for _, n := range d.allowed {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+	if !publicIP(ip) {
+		return fmt.Errorf("webhook: refusing to dial non-public address %s", ip)
+	}
+	return nil
+}
+
+// publicIP reports whether ip is a routable public unicast address.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip))
+}
+
+// sharedAddressSpace is RFC 6598 carrier-grade NAT space, internal in practice.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0).To4(), Mask: net.CIDRMask(10, 32)}
+
+// Run polls the outbox until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := d.DispatchOnce(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf(`{"ts":%q,"level":"error","msg":"webhook dispatch failed","error":%q}`, time.Now().UTC().Format(time.RFC3339Nano), err.Error())
+				}
+				break
+			}
+			// Keep draining while full batches come back.
+			if n < d.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type claimedDelivery struct {
+	id       int64
+	eventID  string
+	event    string
+	table    string
+	payload  string
+	attempts int
+	url      sql.NullString
+	secret   sql.NullString
+	active   sql.NullBool
+}
+
+// DispatchOnce claims and delivers one batch. It returns the number of claimed rows.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	if err := d.store.ensureTables(ctx); err != nil {
+		return 0, err
+	}
+	lease := int(d.client.Timeout/time.Second) + 30
+
+	rows, err := d.store.db.QueryContext(ctx, fmt.Sprintf(`
+with claimed as (
+  update %[1]s o set locked_until = now() + interval '%[3]d seconds'
+  where o.id in (
+    select id from %[1]s
+    where status = 'pending' and next_attempt_at <= now() and (locked_until is null or locked_until < now())
+    order by id
+    limit $1
+    for update skip locked
+  )
+  returning o.id, o.event_id, o.event, o.table_name, o.payload::text, o.attempts, o.subscription_id
+)
+select c.id, c.event_id, c.event, c.table_name, c.payload, c.attempts, s.url, s.secret, s.active
+from claimed c left join %[2]s s on s.id = c.subscription_id
+order by c.id
+`, OutboxTable, SubscriptionsTable, lease), d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	batch := []claimedDelivery{}
+	for rows.Next() {
+		var c claimedDelivery
+		if err := rows.Scan(&c.id, &c.eventID, &c.event, &c.table, &c.payload, &c.attempts, &c.url, &c.secret, &c.active); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		batch = append(batch, c)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return 0, err
+	}
+	_ = rows.Close()
+
+	for _, c := range batch {
+		if ctx.Err() != nil {
+			return len(batch), ctx.Err()
+		}
+		if !c.url.Valid || !c.active.Valid || !c.active.Bool {
+			if err := d.markDead(ctx, c.id, c.attempts, 0, "subscription removed or inactive"); err != nil {
+				return len(batch), err
+			}
+			continue
+		}
+		status, derr := d.deliver(ctx, c)
+		if derr == nil {
+			if err := d.markDelivered(ctx, c.id, status); err != nil {
+				return len(batch), err
+			}
+			continue
+		}
+		if err := d.markFailed(ctx, c, status, derr.Error()); err != nil {
+			return len(batch), err
+		}
+	}
+	return len(batch), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, c claimedDelivery) (int, error) {
+	body := []byte(c.payload)
+	ts := d.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url.String, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mylab-api-webhooks")
+	req.Header.Set(HeaderEventID, c.eventID)
+	req.Header.Set(HeaderEvent, c.table+"."+c.event)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(c.secret.String, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) markDelivered(ctx context.Context, id int64, status int) error {
+	_, err := d.store.db.ExecContext(ctx, fmt.Sprintf(`
+update %s set status = 'delivered', attempts = attempts + 1, delivered_at = now(),
+  last_status = $2, last_error = null, locked_until = null
+where id = $1
+`, OutboxTable), id, status)
+	return err
+}
+
+func (d *Dispatcher) markFailed(ctx context.Context, c claimedDelivery, status int, msg string) error {
+	attempts := c.attempts + 1
+	if attempts >= d.MaxAttempts {
+		return d.markDead(ctx, c.id, c.attempts, status, msg)
+	}
+	next := d.Now().Add(Backoff(attempts))
+	_, err := d.store.db.ExecContext(ctx, fmt.Sprintf(`
+update %s set attempts = $2, next_attempt_at = $3, last_status = $4, last_error = $5, locked_until = null
+where id = $1
+`, OutboxTable), c.id, attempts, next, nullableStatus(status), truncate(msg, 500))
+	return err
+}
+
+func (d *Dispatcher) markDead(ctx context.Context, id int64, prevAttempts int, status int, msg string) error {
+	_, err := d.store.db.ExecContext(ctx, fmt.Sprintf(`
+update %s set status = 'dead', attempts = $2, last_status = $3, last_error = $4, locked_until = null
+where id = $1
+`, OutboxTable), id, prevAttempts+1, nullableStatus(status), truncate(msg, 500))
+	return err
+}
+
+func nullableStatus(status int) any {
+	if status <= 0 {
+		return nil
+	}
+	return status
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
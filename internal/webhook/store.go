@@ -0,0 +1,491 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"mylab-api-go/internal/database/dialect"
+	"mylab-api-go/internal/database/eloquent"
+)
+
+// Outbound webhooks for generic CRUD change events.
+//
+// Flow:
+// - Tenants subscribe per table (or "*") to created/updated/deleted events.
+// - The CRUD controller calls Enqueue inside its db.WithTx, writing one outbox row per matching
+//   subscription (transactional outbox: no event without a committed write and vice versa).
+// - Dispatcher delivers pending rows with HMAC-SHA256 signatures, retries with exponential
+//   backoff and marks rows "dead" after max attempts. Dead rows can be replayed.
+
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+const (
+	SubscriptionsTable = "gateway_webhook_subscriptions"
+	OutboxTable        = "gateway_webhook_outbox"
+)
+
+// Tables returns the gateway-owned tables used by webhooks.
+func Tables() []string {
+	return []string{SubscriptionsTable, OutboxTable}
+}
+
+var tableNameRE = regexp.MustCompile("^[a-z0-9_]+$")
+
+var allEvents = []string{EventCreated, EventUpdated, EventDeleted}
+
+// Subscription is a tenant's webhook endpoint. Table "*" matches every table.
+type Subscription struct {
+	ID        int64     `json:"id"`
+	CompanyID int64     `json:"company_id"`
+	Table     string    `json:"table"`
+	Events    []string  `json:"events"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Event is a change event produced by a CRUD mutation.
+type Event struct {
+	Type      string // created|updated|deleted
+	Table     string
+	PK        string
+	CompanyID int64
+	UserID    int64
+	RequestID string
+	Old       map[string]any
+	New       map[string]any
+}
+
+// Delivery is one outbox row (one event for one subscription).
+type Delivery struct {
+	ID             int64           `json:"id"`
+	EventID        string          `json:"event_id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	Table          string          `json:"table"`
+	PK             string          `json:"pk"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatus     *int            `json:"last_status"`
+	LastError      *string         `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+}
+
+// Store manages subscriptions and the outbox. Tables are created on first use.
+type Store struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// ErrUnsupportedDialect is returned by NewStore on non-Postgres databases
+// (the dispatcher relies on jsonb and FOR UPDATE SKIP LOCKED).
+var ErrUnsupportedDialect = errors.New("webhooks require postgres")
+
+// ErrDisabled is returned by NewStore when WEBHOOKS=off.
+var ErrDisabled = errors.New("webhooks disabled (WEBHOOKS=off)")
+
+// Enabled reports whether webhooks are switched on (WEBHOOKS, default on). The server refuses
+// to start on a database without webhook support while they are on.
+func Enabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("WEBHOOKS"))) {
+	case "off", "false", "0", "disabled":
+		return false
+	}
+	return true
+}
+
+func NewStore(db *sql.DB) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	if !Enabled() {
+		return nil, ErrDisabled
+	}
+	if !dialect.IsPostgres() {
+		return nil, ErrUnsupportedDialect
+	}
+	return &Store{db: db}, nil
+}
+
+// ensureTables runs outside the caller's transaction so a rolled-back mutation
+// does not roll back the DDL.
+func (s *Store) ensureTables(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+	stmts := []string{
+		fmt.Sprintf(`
+create table if not exists %s (
+  id bigserial primary key,
+  company_id bigint not null,
+  table_name text not null default '*',
+  events text not null default 'created,updated,deleted',
+  url text not null,
+  secret text not null,
+  active boolean not null default true,
+  created_at timestamptz not null default now()
+)
+`, SubscriptionsTable),
+		fmt.Sprintf(`create index if not exists %s_company_idx on %s (company_id, table_name)`, SubscriptionsTable, SubscriptionsTable),
+		fmt.Sprintf(`
+create table if not exists %s (
+  id bigserial primary key,
+  event_id text not null unique,
+  subscription_id bigint not null,
+  company_id bigint not null,
+  table_name text not null,
+  pk text not null,
+  event text not null,
+  payload jsonb not null,
+  status text not null default 'pending',
+  attempts int not null default 0,
+  next_attempt_at timestamptz not null default now(),
+  locked_until timestamptz null,
+  last_status int null,
+  last_error text null,
+  created_at timestamptz not null default now(),
+  delivered_at timestamptz null
+)
+`, OutboxTable),
+		fmt.Sprintf(`create index if not exists %s_due_idx on %s (status, next_attempt_at)`, OutboxTable, OutboxTable),
+		fmt.Sprintf(`create index if not exists %s_company_idx on %s (company_id, status)`, OutboxTable, OutboxTable),
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	s.ready = true
+	return nil
+}
+
+// Enqueue writes one outbox row per active subscription matching the event.
+// q must be the mutation's transaction.
+func (s *Store) Enqueue(ctx context.Context, q eloquent.Querier, ev Event) error {
+	switch ev.Type {
+	case EventCreated, EventUpdated, EventDeleted:
+	default:
+		return fmt.Errorf("webhook: invalid event type %q", ev.Type)
+	}
+	if err := s.ensureTables(ctx); err != nil {
+		return err
+	}
+
+	rows, err := q.QueryContext(ctx, fmt.Sprintf(`
+select id from %s
+where company_id = $1 and active
+  and (table_name = '*' or table_name = $2)
+  and (',' || events || ',') like '%%,' || $3 || ',%%'
+order by id
+`, SubscriptionsTable), ev.CompanyID, ev.Table, ev.Type)
+	if err != nil {
+		return err
+	}
+	subIDs := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		subIDs = append(subIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	_ = rows.Close()
+	if len(subIDs) == 0 {
+		return nil
+	}
+
+	occurredAt := time.Now().UTC()
+	insert := fmt.Sprintf(`
+insert into %s (event_id, subscription_id, company_id, table_name, pk, event, payload)
+values ($1,$2,$3,$4,$5,$6,$7)
+`, OutboxTable)
+	for _, subID := range subIDs {
+		eventID, err := randomHex(16)
+		if err != nil {
+			return err
+		}
+		payload, err := json.Marshal(map[string]any{
+			"id":          eventID,
+			"event":       ev.Table + "." + ev.Type,
+			"type":        ev.Type,
+			"table":       ev.Table,
+			"pk":          ev.PK,
+			"company_id":  ev.CompanyID,
+			"user_id":     ev.UserID,
+			"request_id":  ev.RequestID,
+			"occurred_at": occurredAt.Format(time.RFC3339Nano),
+			"data":        normalizeRow(ev.New),
+			"old":         normalizeRow(ev.Old),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := q.ExecContext(ctx, insert, eventID, subID, ev.CompanyID, ev.Table, ev.PK, ev.Type, string(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateSubscription validates and stores a subscription. A secret is generated when empty;
+// it is only returned here.
+func (s *Store) CreateSubscription(ctx context.Context, sub Subscription) (Subscription, error) {
+	errs := map[string]string{}
+	if sub.CompanyID <= 0 {
+		errs["company_id"] = "invalid"
+	}
+	sub.Table = strings.ToLower(strings.TrimSpace(sub.Table))
+	if sub.Table == "" {
+		sub.Table = "*"
+	}
+	if sub.Table != "*" && !tableNameRE.MatchString(sub.Table) {
+		errs["table"] = "invalid name (allowed: a-z0-9_ only, or *)"
+	}
+	events, ok := normalizeEvents(sub.Events)
+	if !ok {
+		errs["events"] = "allowed: " + strings.Join(allEvents, ", ")
+	}
+	sub.Events = events
+	sub.URL = strings.TrimSpace(sub.URL)
+	if u, err := url.Parse(sub.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs["url"] = "must be an absolute http(s) URL"
+	}
+	sub.Secret = strings.TrimSpace(sub.Secret)
+	if sub.Secret == "" {
+		secret, err := randomHex(32)
+		if err != nil {
+			return Subscription{}, err
+		}
+		sub.Secret = secret
+	} else if len(sub.Secret) < 16 {
+		errs["secret"] = "must be at least 16 characters"
+	}
+	if len(errs) > 0 {
+		return Subscription{}, &eloquent.ValidationError{Errors: errs}
+	}
+	if err := s.ensureTables(ctx); err != nil {
+		return Subscription{}, err
+	}
+
+	sub.Active = true
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(`
+insert into %s (company_id, table_name, events, url, secret, active)
+values ($1,$2,$3,$4,$5,true)
+returning id, created_at
+`, SubscriptionsTable), sub.CompanyID, sub.Table, strings.Join(sub.Events, ","), sub.URL, sub.Secret).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// ListSubscriptions returns a tenant's subscriptions (secrets omitted).
+func (s *Store) ListSubscriptions(ctx context.Context, companyID int64) ([]Subscription, error) {
+	if err := s.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+select id, company_id, table_name, events, url, active, created_at
+from %s where company_id = $1 order by id
+`, SubscriptionsTable), companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Subscription{}
+	for rows.Next() {
+		var sub Subscription
+		var events string
+		if err := rows.Scan(&sub.ID, &sub.CompanyID, &sub.Table, &events, &sub.URL, &sub.Active, &sub.CreatedAt); err != nil {
+			return nil, err
+		}
+		sub.Events = strings.Split(events, ",")
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+// DeleteSubscription removes a tenant's subscription. Pending deliveries for it are dead-lettered
+// by the dispatcher.
+func (s *Store) DeleteSubscription(ctx context.Context, companyID, id int64) error {
+	if err := s.ensureTables(ctx); err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(`delete from %s where id = $1 and company_id = $2`, SubscriptionsTable), id, companyID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return &eloquent.NotFoundError{Table: SubscriptionsTable, PK: id}
+	}
+	return nil
+}
+
+// ListDeliveries returns a tenant's outbox rows, newest first. status is optional.
+func (s *Store) ListDeliveries(ctx context.Context, companyID int64, status string, page, perPage int) ([]Delivery, bool, error) {
+	if err := s.ensureTables(ctx); err != nil {
+		return nil, false, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if perPage <= 0 {
+		perPage = eloquent.DefaultPerPage
+	}
+	if perPage > eloquent.MaxPerPage {
+		perPage = eloquent.MaxPerPage
+	}
+
+	args := []any{companyID}
+	where := "company_id = $1"
+	if status != "" {
+		args = append(args, status)
+		where += " and status = $2"
+	}
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+select id, event_id, subscription_id, table_name, pk, event, status, attempts, next_attempt_at,
+  last_status, last_error, created_at, delivered_at, payload::text
+from %s where %s
+order by id desc
+limit %d offset %d
+`, OutboxTable, where, perPage+1, (page-1)*perPage), args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	out := []Delivery{}
+	for rows.Next() {
+		var d Delivery
+		var lastStatus sql.NullInt64
+		var lastError sql.NullString
+		var deliveredAt sql.NullTime
+		var payload string
+		if err := rows.Scan(&d.ID, &d.EventID, &d.SubscriptionID, &d.Table, &d.PK, &d.Event, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &lastStatus, &lastError, &d.CreatedAt, &deliveredAt, &payload); err != nil {
+			return nil, false, err
+		}
+		if lastStatus.Valid {
+			v := int(lastStatus.Int64)
+			d.LastStatus = &v
+		}
+		if lastError.Valid {
+			d.LastError = &lastError.String
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		d.Payload = json.RawMessage(payload)
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	hasMore := len(out) > perPage
+	if hasMore {
+		out = out[:perPage]
+	}
+	return out, hasMore, nil
+}
+
+// Replay resets a delivered or dead outbox row to pending so the dispatcher sends it again
+// (same event id, so receivers can de-duplicate).
+func (s *Store) Replay(ctx context.Context, companyID, id int64) error {
+	if err := s.ensureTables(ctx); err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+update %s
+set status = 'pending', attempts = 0, next_attempt_at = now(), locked_until = null, last_error = null
+where id = $1 and company_id = $2 and status <> 'pending'
+`, OutboxTable), id, companyID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return &eloquent.NotFoundError{Table: OutboxTable, PK: id}
+	}
+	return nil
+}
+
+func normalizeEvents(in []string) ([]string, bool) {
+	if len(in) == 0 {
+		return append([]string{}, allEvents...), true
+	}
+	seen := map[string]bool{}
+	for _, e := range in {
+		e = strings.ToLower(strings.TrimSpace(e))
+		switch e {
+		case EventCreated, EventUpdated, EventDeleted:
+			seen[e] = true
+		default:
+			return nil, false
+		}
+	}
+	out := []string{}
+	for _, e := range allEvents {
+		if seen[e] {
+			out = append(out, e)
+		}
+	}
+	return out, true
+}
+
+// normalizeRow converts driver values into JSON-friendly ones.
+func normalizeRow(row map[string]any) map[string]any {
+	if row == nil {
+		return nil
+	}
+	out := make(map[string]any, len(row))
+	for k, v := range row {
+		if b, ok := v.([]byte); ok {
+			out[k] = string(b)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	got := Sign("secret", 1700000000, []byte(`{"a":1}`))
+	want := "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got != want {
+		t.Fatalf("Sign = %q, want %q", got, want)
+	}
+	if Sign("secret", 1700000000, []byte(`{"a":2}`)) == got || Sign("other", 1700000000, []byte(`{"a":1}`)) == got {
+		t.Fatalf("signature must depend on body and secret")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		0:  30 * time.Second,
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		8:  time.Hour,
+		20: time.Hour,
+	}
+	for attempts, want := range cases {
+		if got := Backoff(attempts); got != want {
+			t.Fatalf("Backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.10":     false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::ffff:127.0.0.1": false,
+	} {
+		if got := publicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("publicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestDeliverRefusesPrivateAddressesAndRedirects(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil, time.Second)
+	c := claimedDelivery{eventID: "e1", event: "created", table: "pasien", payload: `{}`,
+		url: sql.NullString{String: srv.URL + "/hook", Valid: true}, secret: sql.NullString{String: "s", Valid: true}}
+
+	// httptest listens on loopback: refused at dial time.
+	if _, err := d.deliver(context.Background(), c); err == nil || !strings.Contains(err.Error(), "non-public") || hits != 0 {
+		t.Fatalf("err = %v, hits = %d", err, hits)
+	}
+
+	if err := d.AllowNetworks("127.0.0.0/8, ::1"); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := d.deliver(context.Background(), c); err != nil || status != http.StatusNoContent {
+		t.Fatalf("allowed network: status = %d, err = %v", status, err)
+	}
+	// The redirect is not followed and counts as a failed attempt.
+	c.url.String = srv.URL + "/redirect"
+	if status, err := d.deliver(context.Background(), c); err == nil || status != http.StatusFound || hits != 2 {
+		t.Fatalf("redirect: status = %d, err = %v, hits = %d", status, err, hits)
+	}
+
+	if err := d.AllowNetworks("10.0.0.0/33"); err == nil {
+		t.Fatal("invalid network must be rejected")
+	}
+}
@@ -0,0 +1,411 @@
+// Package yaml parses the block-style YAML subset used by schema files and the OpenAPI contract.
+// There is no YAML dependency in this module; anything outside the subset is an error rather
+// than a silent misread.
+package yaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a YAML document into map[string]any / []any / string / bool / json.Number / nil:
+//
+//   - block mappings ("key: value", "key:" followed by an indented block)
+//   - block sequences ("- item", "- key: value" mappings) and flow sequences ("[a, b, 'c,d']")
+//   - plain, 'single' and "double" quoted scalars; true/false, numbers, null and ~ are typed
+//   - literal (|) and folded (>) block scalars with the -/+ chomping indicators
+//   - comments (# at line start or after whitespace) and an optional leading "---"
+//
+// Anchors, tags, multi-document streams, non-empty flow mappings and plain multi-line scalars
+// are rejected. Quoted scalars always stay strings.
+func Parse(raw string) (map[string]any, error) {
+	lines, err := splitLines(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	v, next, err := parseBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].n)
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("line %d: top level must be a mapping", lines[0].n)
+	}
+	return m, nil
+}
+
+type line struct {
+	n      int // 1-based line number
+	indent int
+	text   string
+	block  *string // content of a | or > block scalar started on this line
+}
+
+var blockIndicatorRE = regexp.MustCompile(`(^|: |^- )([|>])([+-]?)$`)
+
+func splitLines(raw string) ([]line, error) {
+	src := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	out := []line{}
+	for i := 0; i < len(src); i++ {
+		l := src[i]
+		body := strings.TrimLeft(l, " ")
+		if strings.HasPrefix(body, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		text := strings.TrimSpace(stripComment(body))
+		if text == "" {
+			continue
+		}
+		if text == "---" || text == "..." {
+			if len(out) == 0 && text == "---" {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: multiple documents are not supported", i+1)
+		}
+		cur := line{n: i + 1, indent: len(l) - len(body), text: text}
+
+		if m := blockIndicatorRE.FindStringSubmatch(text); m != nil {
+			content, next := blockScalar(src, i+1, cur.indent, m[2], m[3])
+			cur.text = strings.TrimSpace(strings.TrimSuffix(text, m[2]+m[3]))
+			cur.block = &content
+			i = next - 1
+		}
+		out = append(out, cur)
+	}
+	return out, nil
+}
+
+// blockScalar reads the lines of a block scalar starting at src[i] (more indented than parent,
+// or blank) and returns the content and the index of the first line after it.
+func blockScalar(src []string, i, parent int, style, chomp string) (string, int) {
+	indent := -1
+	parts := []string{}
+	for ; i < len(src); i++ {
+		l := src[i]
+		body := strings.TrimLeft(l, " ")
+		if body == "" {
+			parts = append(parts, "")
+			continue
+		}
+		n := len(l) - len(body)
+		if n <= parent {
+			break
+		}
+		if indent < 0 {
+			indent = n
+		}
+		if n < indent {
+			break
+		}
+		parts = append(parts, l[indent:])
+	}
+
+	// Trailing blank lines belong to the scalar only for keep (+) chomping.
+	content := len(parts)
+	for content > 0 && parts[content-1] == "" {
+		content--
+	}
+	trailing := len(parts) - content
+	parts = parts[:content]
+
+	var s string
+	if style == "|" {
+		s = strings.Join(parts, "\n")
+	} else {
+		var b strings.Builder
+		for j, p := range parts {
+			switch {
+			case j == 0:
+			case p == "" || parts[j-1] == "":
+				b.WriteByte('\n')
+			case strings.HasPrefix(p, " ") || strings.HasPrefix(parts[j-1], " "):
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(p)
+		}
+		s = b.String()
+	}
+	switch chomp {
+	case "-":
+	case "+":
+		s += "\n" + strings.Repeat("\n", trailing)
+	default:
+		if s != "" {
+			s += "\n"
+		}
+	}
+	return s, i
+}
+
+// stripComment drops a trailing comment outside quotes.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '\'' && c == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++ // '' is an escaped quote inside a single-quoted string
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '\'' || c == '"') && (i == 0 || s[i-1] == ' ' || s[i-1] == '[' || s[i-1] == ','):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func parseBlock(lines []line, i, indent int) (any, int, error) {
+	if isSeqItem(lines[i].text) {
+		return parseSeq(lines, i, indent)
+	}
+	return parseMap(lines, i, indent)
+}
+
+func parseSeq(lines []line, i, indent int) (any, int, error) {
+	out := []any{}
+	for i < len(lines) && lines[i].indent == indent && isSeqItem(lines[i].text) {
+		l := lines[i]
+		item := strings.TrimSpace(strings.TrimPrefix(l.text, "-"))
+		switch {
+		case l.block != nil && item == "":
+			out = append(out, *l.block)
+			i++
+		case item == "":
+			// "-" followed by an indented block.
+			i++
+			if i >= len(lines) || lines[i].indent <= indent {
+				out = append(out, nil)
+				continue
+			}
+			v, next, err := parseBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, next, err
+			}
+			out, i = append(out, v), next
+		case isSeqItem(item):
+			return nil, i, fmt.Errorf("line %d: nested block sequences are not supported", l.n)
+		case keyEnd(item) >= 0:
+			// "- key: value": a mapping whose keys line up with the text after "- ".
+			lines[i] = line{n: l.n, indent: indent + len(l.text) - len(item), text: item, block: l.block}
+			v, next, err := parseMap(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, next, err
+			}
+			out, i = append(out, v), next
+		default:
+			v, err := parseInline(item, l.n)
+			if err != nil {
+				return nil, i, err
+			}
+			out = append(out, v)
+			i++
+		}
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, i, fmt.Errorf("line %d: unexpected indentation", lines[i].n)
+	}
+	return out, i, nil
+}
+
+func parseMap(lines []line, i, indent int) (any, int, error) {
+	out := map[string]any{}
+	for i < len(lines) && lines[i].indent == indent {
+		l := lines[i]
+		if isSeqItem(l.text) {
+			return nil, i, fmt.Errorf("line %d: list item where a key was expected", l.n)
+		}
+		end := keyEnd(l.text)
+		if end < 0 {
+			return nil, i, fmt.Errorf("line %d: expected \"key: value\"", l.n)
+		}
+		key, err := scalar(strings.TrimSpace(l.text[:end]), l.n)
+		if err != nil {
+			return nil, i, err
+		}
+		k := fmt.Sprint(key)
+		if key == nil || k == "" {
+			return nil, i, fmt.Errorf("line %d: empty key", l.n)
+		}
+		if _, dup := out[k]; dup {
+			return nil, i, fmt.Errorf("line %d: duplicate key %q", l.n, k)
+		}
+		rest := strings.TrimSpace(l.text[end+1:])
+		i++
+
+		if l.block != nil {
+			out[k] = *l.block
+			continue
+		}
+		if rest != "" {
+			v, err := parseInline(rest, l.n)
+			if err != nil {
+				return nil, i, err
+			}
+			out[k] = v
+			continue
+		}
+		switch {
+		case i < len(lines) && lines[i].indent > indent:
+			v, next, err := parseBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, next, err
+			}
+			out[k], i = v, next
+		case i < len(lines) && lines[i].indent == indent && isSeqItem(lines[i].text):
+			// "key:" followed by a list at the same indentation.
+			v, next, err := parseSeq(lines, i, indent)
+			if err != nil {
+				return nil, next, err
+			}
+			out[k], i = v, next
+		default:
+			out[k] = nil
+		}
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, i, fmt.Errorf("line %d: unexpected indentation", lines[i].n)
+	}
+	return out, i, nil
+}
+
+// keyEnd returns the index of the ':' ending a mapping key (followed by a space or end of
+// line, outside quotes), or -1.
+func keyEnd(text string) int {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '\'' || c == '"') && i == 0:
+			quote = c
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+func parseInline(s string, n int) (any, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		return parseFlowSeq(s, n)
+	case s == "{}":
+		return map[string]any{}, nil
+	case strings.HasPrefix(s, "{"):
+		return nil, fmt.Errorf("line %d: flow mappings are not supported; use an indented block", n)
+	case strings.HasPrefix(s, "|") || strings.HasPrefix(s, ">"):
+		return nil, fmt.Errorf("line %d: invalid block scalar header %q", n, s)
+	case strings.HasPrefix(s, "&") || strings.HasPrefix(s, "*") || strings.HasPrefix(s, "!"):
+		return nil, fmt.Errorf("line %d: anchors, aliases and tags are not supported", n)
+	case keyEnd(s) >= 0:
+		// "a: b: c" is a YAML error, not the string "b: c".
+		return nil, fmt.Errorf("line %d: unexpected \": \" in a plain value; quote the value", n)
+	}
+	return scalar(s, n)
+}
+
+func parseFlowSeq(s string, n int) (any, error) {
+	if !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("line %d: unterminated flow sequence", n)
+	}
+	body := strings.TrimSpace(s[1 : len(s)-1])
+	out := []any{}
+	if body == "" {
+		return out, nil
+	}
+	var (
+		quote byte
+		start int
+	)
+	items := []string{}
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '{':
+			return nil, fmt.Errorf("line %d: nested flow collections are not supported", n)
+		case c == ',':
+			items = append(items, body[start:i])
+			start = i + 1
+		}
+	}
+	items = append(items, body[start:])
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue // trailing comma
+		}
+		v, err := scalar(item, n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// Plain scalars in JSON number syntax become json.Number; "007" or "1_000" stay strings.
+var numberRE = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+func scalar(s string, n int) (any, error) {
+	if s == "" {
+		return "", nil
+	}
+	switch s[0] {
+	case '"':
+		if len(s) < 2 || s[len(s)-1] != '"' {
+			return nil, fmt.Errorf("line %d: unterminated string", n)
+		}
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid string %s", n, s)
+		}
+		return v, nil
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("line %d: unterminated string", n)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if numberRE.MatchString(s) {
+		return json.Number(s), nil
+	}
+	return s, nil
+}
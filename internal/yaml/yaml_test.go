@@ -0,0 +1,100 @@
+package yaml
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	raw := `---
+info:
+  title: "API: v1"   # comment
+  version: 0.1.0
+  description: |
+    Line one.
+    Line two # not a comment
+
+  summary: >-
+    folded
+    text
+servers:
+  - url: http://localhost:8080
+    description: local
+  - plain
+tags: [a, 'b, c', "d"]
+flags:
+  on: true
+  count: 12
+  ratio: 0.5
+  code: '200'
+  zip: 007
+  none: ~
+  empty: {}
+quoted: 'it''s # not a comment' # comment
+escaped: "say \"hi\" # still text"
+`
+	got, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"info": map[string]any{
+			"title":       "API: v1",
+			"version":     "0.1.0",
+			"description": "Line one.\nLine two # not a comment\n",
+			"summary":     "folded text",
+		},
+		"servers": []any{
+			map[string]any{"url": "http://localhost:8080", "description": "local"},
+			"plain",
+		},
+		"tags": []any{"a", "b, c", "d"},
+		"flags": map[string]any{
+			"on": true, "count": json.Number("12"), "ratio": json.Number("0.5"),
+			"code": "200", "zip": "007", "none": nil, "empty": map[string]any{},
+		},
+		"quoted":  "it's # not a comment",
+		"escaped": `say "hi" # still text`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		a, _ := json.MarshalIndent(got, "", "  ")
+		t.Fatalf("got %s", a)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"a: 1\na: 2\n":          "duplicate key",
+		"a:\n\tb: 1\n":          "tabs",
+		"a: {b: 1}\n":           "flow mappings",
+		"a: &x 1\n":             "anchors",
+		"a: 1\n---\nb: 2\n":     "multiple documents",
+		"a:\n  b: 1\n   c: 2\n": "unexpected indentation",
+		"- a\n":                 "top level",
+		"a: b: c\n":             "plain value",
+	}
+	for raw, want := range cases {
+		if _, err := Parse(raw); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want error containing %q", raw, err, want)
+		}
+	}
+}
+
+// The static OpenAPI contract is served merged with generated paths, so it must stay parseable.
+func TestParseOpenAPIContract(t *testing.T) {
+	b, err := os.ReadFile("../../Docs/openapi/openapi.yaml")
+	if err != nil {
+		t.Skip(err)
+	}
+	doc, err := Parse(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, _ := doc["paths"].(map[string]any)
+	if _, ok := paths["/v1/auth/logout"]; !ok {
+		t.Fatalf("paths: missing /v1/auth/logout")
+	}
+}